@@ -0,0 +1,255 @@
+package aggregate
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jeremyseow/csv-parser/csv"
+)
+
+var (
+	errNoHeader        = errors.New("aggregation needs a header")
+	errNotNumeric      = errors.New("not a number")
+	errBadAggregation  = errors.New("bad aggregation")
+	errNothingToReturn = errors.New("no aggregations given")
+)
+
+type Func string
+
+const (
+	Count      Func = "count"
+	Sum        Func = "sum"
+	Min        Func = "min"
+	Max        Func = "max"
+	Avg        Func = "avg"
+	Distinct   Func = "distinct"
+	Percentile Func = "percentile"
+)
+
+// Aggregation is one output column. Count with an empty Column counts records, every other
+// aggregation skips empty fields. Percentile is between 0 and 100 and only used by Percentile.
+type Aggregation struct {
+	Func       Func
+	Column     string
+	Percentile float64
+	As         string
+}
+
+func (agg Aggregation) name() string {
+	switch {
+	case agg.As != "":
+		return agg.As
+	case agg.Func == Count && agg.Column == "":
+		return "count"
+	case agg.Func == Percentile:
+		return fmt.Sprintf("p%s(%s)", formatNumber(agg.Percentile), agg.Column)
+	}
+	return fmt.Sprintf("%s(%s)", agg.Func, agg.Column)
+}
+
+// ParseAggregations reads a comma separated list such as "count,sum(amount),p95(latency):slow".
+// Besides the Func names it accepts mean for avg, median for p50 and pN for any percentile N.
+func ParseAggregations(spec string) ([]Aggregation, error) {
+	var aggs []Aggregation
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		item, as, _ := strings.Cut(item, ":")
+		name, column := item, ""
+		if open := strings.IndexByte(item, '('); open >= 0 {
+			if !strings.HasSuffix(item, ")") {
+				return nil, fmt.Errorf("%w: %q", errBadAggregation, item)
+			}
+			name, column = item[:open], strings.TrimSpace(item[open+1:len(item)-1])
+		}
+
+		agg := Aggregation{Column: column, As: as}
+		switch name = strings.ToLower(strings.TrimSpace(name)); {
+		case name == "count":
+			agg.Func = Count
+		case name == "sum", name == "min", name == "max", name == "avg", name == "distinct":
+			agg.Func = Func(name)
+		case name == "mean":
+			agg.Func = Avg
+		case name == "median":
+			agg.Func, agg.Percentile = Percentile, 50
+		case strings.HasPrefix(name, "p"):
+			p, err := strconv.ParseFloat(name[1:], 64)
+			if err != nil || p < 0 || p > 100 {
+				return nil, fmt.Errorf("%w: %q", errBadAggregation, item)
+			}
+			agg.Func, agg.Percentile = Percentile, p
+		default:
+			return nil, fmt.Errorf("%w: %q", errBadAggregation, item)
+		}
+
+		if agg.Func != Count && agg.Column == "" {
+			return nil, fmt.Errorf("%w: %s needs a column", errBadAggregation, name)
+		}
+		aggs = append(aggs, agg)
+	}
+
+	if len(aggs) == 0 {
+		return nil, errNothingToReturn
+	}
+	return aggs, nil
+}
+
+type aggregator struct {
+	groupBy     []string
+	aggs        []Aggregation
+	memoryLimit int64
+	tempDir     string
+
+	// records are projected to the group by fields followed by the aggregated columns,
+	// aggPos[i] is where the column of aggs[i] is in that projection, or -1 for count(*)
+	inputPos []int
+	aggPos   []int
+}
+
+type Option func(*aggregator)
+
+// WithMemoryLimit sets roughly how many bytes of group state are kept in memory before
+// records of new groups are spilled to disk. It defaults to 256MB.
+func WithMemoryLimit(bytes int64) Option {
+	return func(a *aggregator) {
+		a.memoryLimit = bytes
+	}
+}
+
+// WithTempDir sets where spill files are created, the system temp dir by default
+func WithTempDir(dir string) Option {
+	return func(a *aggregator) {
+		a.tempDir = dir
+	}
+}
+
+// Aggregate groups the records of r by the groupBy columns and writes one record per group
+// to w, holding the group by values followed by the aggregations, sorted by the group by values.
+// When the groups outgrow the memory limit, the records of groups not yet in memory are
+// partitioned into temp files by hash and aggregated one partition at a time.
+func Aggregate(r csv.RecordReader, w *csv.CsvWriter, groupBy []string, aggs []Aggregation, options ...Option) error {
+	if len(aggs) == 0 {
+		return errNothingToReturn
+	}
+
+	a := &aggregator{
+		groupBy:     groupBy,
+		aggs:        aggs,
+		memoryLimit: 256 << 20,
+	}
+	for _, op := range options {
+		op(a)
+	}
+
+	header, err := r.Header()
+	if err != nil {
+		return err
+	}
+	if header == nil {
+		return errNoHeader
+	}
+
+	if err := a.resolve(header); err != nil {
+		return err
+	}
+
+	outputHeader := append([]string{}, groupBy...)
+	for _, agg := range aggs {
+		outputHeader = append(outputHeader, agg.name())
+	}
+	if err := w.Write(outputHeader); err != nil {
+		return err
+	}
+
+	s := &spiller{aggregator: a}
+	defer s.cleanup()
+
+	runs, err := s.process(&projectingSource{reader: r, positions: a.inputPos}, 0)
+	if err != nil {
+		return err
+	}
+
+	if err := mergeRuns(runs, len(groupBy), w); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func (a *aggregator) resolve(header []string) error {
+	names := append([]string{}, a.groupBy...)
+	for _, agg := range a.aggs {
+		if agg.Func != Count || agg.Column != "" {
+			names = append(names, agg.Column)
+		}
+	}
+	positions, err := csv.ColumnPositions(header, names)
+	if err != nil {
+		return err
+	}
+
+	// group by columns come first even when a column is also aggregated
+	a.inputPos = append(a.inputPos, positions[:len(a.groupBy)]...)
+	positions = positions[len(a.groupBy):]
+
+	projected := map[string]int{}
+	for _, agg := range a.aggs {
+		if agg.Func == Count && agg.Column == "" {
+			a.aggPos = append(a.aggPos, -1)
+			continue
+		}
+
+		pos, ok := projected[agg.Column]
+		if !ok {
+			pos = len(a.inputPos)
+			projected[agg.Column] = pos
+			a.inputPos = append(a.inputPos, positions[0])
+		}
+		positions = positions[1:]
+		a.aggPos = append(a.aggPos, pos)
+	}
+	return nil
+}
+
+// rowSource yields projected rows
+type rowSource interface {
+	next() ([]string, error)
+}
+
+type projectingSource struct {
+	reader    csv.RecordReader
+	positions []int
+}
+
+func (ps *projectingSource) next() ([]string, error) {
+	record, err := ps.reader.ReadRecord()
+	if err != nil {
+		return nil, err
+	}
+
+	row := make([]string, len(ps.positions))
+	for i, pos := range ps.positions {
+		// short records, read leniently, count as empty fields
+		if pos < len(record) {
+			row[i] = record[pos]
+		}
+	}
+	return row, nil
+}
+
+type csvSource struct {
+	reader *csv.CsvReader
+}
+
+func (cs *csvSource) next() ([]string, error) {
+	return cs.reader.ReadRecord()
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
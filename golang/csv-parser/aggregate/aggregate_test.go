@@ -0,0 +1,146 @@
+package aggregate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/stretchr/testify/assert"
+)
+
+const sales = `country,city,amount,user
+SG,Singapore,10,a
+MY,Kuala Lumpur,5,b
+SG,Singapore,30,a
+MY,Penang,,c
+SG,Singapore,20,d
+ID,Jakarta,7.5,e
+`
+
+func TestAggregate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		groupBy  []string
+		aggs     string
+		expected string
+		err      error
+	}{
+		{
+			name:     "count and sum by one column",
+			input:    sales,
+			groupBy:  []string{"country"},
+			aggs:     "count,sum(amount)",
+			expected: "country,count,sum(amount)\nID,1,7.5\nMY,2,5\nSG,3,60\n",
+		},
+		{
+			name:     "several columns and aliases",
+			input:    sales,
+			groupBy:  []string{"country", "city"},
+			aggs:     "count(amount):n,avg(amount):avg",
+			expected: "country,city,n,avg\nID,Jakarta,1,7.5\nMY,Kuala Lumpur,1,5\nMY,Penang,0,\nSG,Singapore,3,20\n",
+		},
+		{
+			name:     "min, max and distinct",
+			input:    sales,
+			groupBy:  []string{"country"},
+			aggs:     "min(amount),max(city),distinct(user)",
+			expected: "country,min(amount),max(city),distinct(user)\nID,7.5,Jakarta,1\nMY,5,Penang,2\nSG,10,Singapore,2\n",
+		},
+		{
+			name:     "percentiles",
+			input:    "k,v\na,1\na,2\na,3\na,4\n",
+			groupBy:  []string{"k"},
+			aggs:     "median(v),p25(v),p100(v)",
+			expected: "k,p50(v),p25(v),p100(v)\na,2.5,1.75,4\n",
+		},
+		{
+			name:     "no group by",
+			input:    sales,
+			aggs:     "count,sum(amount)",
+			expected: "count,sum(amount)\n6,72.5\n",
+		},
+		{
+			name:    "not numeric",
+			input:   sales,
+			groupBy: []string{"country"},
+			aggs:    "sum(city)",
+			err:     errNotNumeric,
+		},
+		{
+			name:    "unknown column",
+			input:   sales,
+			groupBy: []string{"region"},
+			aggs:    "count",
+			err:     csv.ErrUnknownColumn,
+		},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			aggs, err := ParseAggregations(currTestCase.aggs)
+			assert.NoError(t, err)
+
+			var sb strings.Builder
+			reader := csv.NewCsvReader(strings.NewReader(currTestCase.input), csv.WithHeader(true))
+			err = Aggregate(reader, csv.NewCsvWriter(&sb), currTestCase.groupBy, aggs)
+			if currTestCase.err != nil {
+				assert.True(t, errors.Is(err, currTestCase.err), err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, currTestCase.expected, sb.String())
+		})
+	}
+}
+
+func TestAggregateSpill(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("key,value\n")
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&sb, "k%d,%d\n", i%1500, i)
+	}
+	input := sb.String()
+
+	aggs, err := ParseAggregations("count,sum(value),distinct(value),p50(value)")
+	assert.NoError(t, err)
+
+	run := func(options ...Option) string {
+		var out strings.Builder
+		reader := csv.NewCsvReader(strings.NewReader(input), csv.WithHeader(true))
+		assert.NoError(t, Aggregate(reader, csv.NewCsvWriter(&out), []string{"key"}, aggs, options...))
+		return out.String()
+	}
+
+	tempDir := t.TempDir()
+	inMemory := run()
+	spilled := run(WithMemoryLimit(16<<10), WithTempDir(tempDir))
+	assert.Equal(t, inMemory, spilled)
+	assert.Equal(t, 1501, strings.Count(spilled, "\n"))
+
+	// the spill files are cleaned up
+	entries, err := os.ReadDir(tempDir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestParseAggregations(t *testing.T) {
+	aggs, err := ParseAggregations("count, mean(a), p99.9(b):slow")
+	assert.NoError(t, err)
+	assert.Equal(t, []Aggregation{
+		{Func: Count},
+		{Func: Avg, Column: "a"},
+		{Func: Percentile, Column: "b", Percentile: 99.9, As: "slow"},
+	}, aggs)
+
+	for _, spec := range []string{"", "sum", "p101(a)", "bogus(a)", "sum(a"} {
+		_, err := ParseAggregations(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,251 @@
+package aggregate
+
+import (
+	"container/heap"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/jeremyseow/csv-parser/internal/spill"
+)
+
+const (
+	// past this depth a partition is aggregated in memory whatever its size,
+	// since hashing again cannot split a single huge group
+	maxDepth = 4
+)
+
+// run is a sorted sequence of output rows, either in memory or in a csv file
+type run struct {
+	rows [][]string
+	path string
+}
+
+type spiller struct {
+	*aggregator
+	dir string
+}
+
+func (s *spiller) cleanup() {
+	if s.dir != "" {
+		os.RemoveAll(s.dir)
+	}
+}
+
+func (s *spiller) createTemp(pattern string) (*os.File, error) {
+	if s.dir == "" {
+		dir, err := os.MkdirTemp(s.tempDir, "csv-aggregate-")
+		if err != nil {
+			return nil, err
+		}
+		s.dir = dir
+	}
+	return os.CreateTemp(s.dir, pattern)
+}
+
+// process aggregates the rows of source. Once the groups in memory pass the memory limit,
+// rows of new groups go to partition files which are processed in turn afterwards. Every
+// group ends up in exactly one run, so the runs can simply be merged.
+func (s *spiller) process(source rowSource, depth int) ([]run, error) {
+	groups := map[string]*group{}
+	var used int64
+	var partitions []*spill.Partition
+
+	numKeys := len(s.groupBy)
+	for {
+		row, err := source.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		key := strings.Join(row[:numKeys], "\x00")
+		g, ok := groups[key]
+		if !ok {
+			if used > s.memoryLimit && depth < maxDepth {
+				if partitions == nil {
+					if partitions, err = spill.NewPartitions(s.createTemp); err != nil {
+						return nil, err
+					}
+				}
+				if err := partitions[spill.Index(key, depth)].Writer.Write(row); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			var size int64
+			g, size = s.newGroup(append([]string{}, row[:numKeys]...))
+			groups[key] = g
+			used += size + int64(len(key))
+		}
+
+		grown, err := s.add(g, row)
+		if err != nil {
+			return nil, err
+		}
+		used += grown
+	}
+
+	rows := s.sortedResults(groups)
+	if partitions == nil {
+		return []run{{rows: rows}}, nil
+	}
+
+	path, err := s.writeRun(rows)
+	if err != nil {
+		return nil, err
+	}
+	runs := []run{{path: path}}
+
+	for _, p := range partitions {
+		subRuns, err := s.processPartition(p, depth)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, subRuns...)
+	}
+
+	return runs, nil
+}
+
+func (s *spiller) processPartition(p *spill.Partition, depth int) ([]run, error) {
+	if err := p.Rewind(); err != nil {
+		return nil, err
+	}
+	defer p.Remove()
+
+	subRuns, err := s.process(&csvSource{reader: csv.NewCsvReader(p.File)}, depth+1)
+	if err != nil {
+		return nil, err
+	}
+
+	// keep at most one partition's results in memory at a time
+	for i, r := range subRuns {
+		if r.rows != nil {
+			path, err := s.writeRun(r.rows)
+			if err != nil {
+				return nil, err
+			}
+			subRuns[i] = run{path: path}
+		}
+	}
+	return subRuns, nil
+}
+
+func (s *spiller) writeRun(rows [][]string) (string, error) {
+	file, err := s.createTemp("run-*.csv")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if err := csv.NewCsvWriter(file).WriteAll(rows); err != nil {
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+func (s *spiller) sortedResults(groups map[string]*group) [][]string {
+	rows := make([][]string, 0, len(groups))
+	for _, g := range groups {
+		rows = append(rows, s.result(g))
+	}
+
+	numKeys := len(s.groupBy)
+	sort.Slice(rows, func(i, j int) bool {
+		return compareKeys(rows[i], rows[j], numKeys) < 0
+	})
+	return rows
+}
+
+func compareKeys(a, b []string, numKeys int) int {
+	for i := 0; i < numKeys; i++ {
+		if c := strings.Compare(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// runCursor is the next row of a run during the merge
+type runCursor struct {
+	row  []string
+	next func() ([]string, error)
+}
+
+type runHeap struct {
+	cursors []*runCursor
+	numKeys int
+}
+
+func (h *runHeap) Len() int { return len(h.cursors) }
+func (h *runHeap) Less(i, j int) bool {
+	return compareKeys(h.cursors[i].row, h.cursors[j].row, h.numKeys) < 0
+}
+func (h *runHeap) Swap(i, j int) { h.cursors[i], h.cursors[j] = h.cursors[j], h.cursors[i] }
+func (h *runHeap) Push(x any)    { h.cursors = append(h.cursors, x.(*runCursor)) }
+func (h *runHeap) Pop() any {
+	last := h.cursors[len(h.cursors)-1]
+	h.cursors = h.cursors[:len(h.cursors)-1]
+	return last
+}
+
+func mergeRuns(runs []run, numKeys int, w *csv.CsvWriter) error {
+	h := &runHeap{numKeys: numKeys}
+	for _, r := range runs {
+		var next func() ([]string, error)
+		if r.path == "" {
+			rows := r.rows
+			next = func() ([]string, error) {
+				if len(rows) == 0 {
+					return nil, io.EOF
+				}
+				row := rows[0]
+				rows = rows[1:]
+				return row, nil
+			}
+		} else {
+			file, err := os.Open(r.path)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			next = csv.NewCsvReader(file).ReadRecord
+		}
+
+		row, err := next()
+		if err == io.EOF {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		h.cursors = append(h.cursors, &runCursor{row: row, next: next})
+	}
+	heap.Init(h)
+
+	for h.Len() > 0 {
+		cursor := h.cursors[0]
+		if err := w.Write(cursor.row); err != nil {
+			return err
+		}
+
+		row, err := cursor.next()
+		if err == io.EOF {
+			heap.Pop(h)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		cursor.row = row
+		heap.Fix(h, 0)
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package aggregate
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// rough per item overheads used to estimate memory use
+const (
+	groupOverhead    = 64
+	stateOverhead    = 48
+	distinctOverhead = 48
+	valueOverhead    = 8
+)
+
+type group struct {
+	key    []string
+	states []*state
+}
+
+// state accumulates one aggregation of one group
+type state struct {
+	count int64
+	sum   float64
+
+	// min and max are compared as numbers while every value is numeric, as strings otherwise
+	allNumeric       bool
+	minNum, maxNum   float64
+	minText, maxText string
+
+	distinct map[string]struct{}
+	values   []float64
+}
+
+func (a *aggregator) newGroup(key []string) (*group, int64) {
+	g := &group{key: key, states: make([]*state, len(a.aggs))}
+	size := int64(groupOverhead)
+	for i := range g.states {
+		g.states[i] = &state{allNumeric: true}
+		size += stateOverhead
+	}
+	for _, k := range key {
+		size += int64(len(k))
+	}
+	return g, size
+}
+
+// add folds a projected row into the group and returns how many bytes the group grew by
+func (a *aggregator) add(g *group, row []string) (int64, error) {
+	var grown int64
+	for i, agg := range a.aggs {
+		st := g.states[i]
+		pos := a.aggPos[i]
+		if pos < 0 {
+			st.count++
+			continue
+		}
+
+		field := row[pos]
+		if field == "" {
+			continue
+		}
+
+		switch agg.Func {
+		case Count:
+			st.count++
+
+		case Distinct:
+			if st.distinct == nil {
+				st.distinct = map[string]struct{}{}
+			}
+			if _, ok := st.distinct[field]; !ok {
+				st.distinct[field] = struct{}{}
+				grown += int64(len(field)) + distinctOverhead
+			}
+
+		case Min, Max:
+			num, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			numeric := err == nil
+			if st.count == 0 {
+				st.minText, st.maxText = field, field
+				st.minNum, st.maxNum = num, num
+				st.allNumeric = numeric
+				grown += int64(len(field))
+			} else {
+				st.allNumeric = st.allNumeric && numeric
+				if field < st.minText {
+					st.minText = field
+				}
+				if field > st.maxText {
+					st.maxText = field
+				}
+				if numeric {
+					st.minNum = math.Min(st.minNum, num)
+					st.maxNum = math.Max(st.maxNum, num)
+				}
+			}
+			st.count++
+
+		default:
+			num, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil {
+				return grown, fmt.Errorf("%w: %q in column %s", errNotNumeric, field, agg.Column)
+			}
+			st.count++
+			st.sum += num
+			if agg.Func == Percentile {
+				st.values = append(st.values, num)
+				grown += valueOverhead
+			}
+		}
+	}
+
+	return grown, nil
+}
+
+// result turns the group into an output row
+func (a *aggregator) result(g *group) []string {
+	row := append(make([]string, 0, len(g.key)+len(a.aggs)), g.key...)
+	for i, agg := range a.aggs {
+		row = append(row, g.states[i].result(agg))
+	}
+	return row
+}
+
+func (st *state) result(agg Aggregation) string {
+	switch agg.Func {
+	case Count:
+		return strconv.FormatInt(st.count, 10)
+	case Distinct:
+		return strconv.Itoa(len(st.distinct))
+	case Sum:
+		return formatNumber(st.sum)
+	}
+
+	// the remaining aggregations have no value for a group without any
+	if st.count == 0 {
+		return ""
+	}
+
+	switch agg.Func {
+	case Avg:
+		return formatNumber(st.sum / float64(st.count))
+	case Min:
+		if st.allNumeric {
+			return formatNumber(st.minNum)
+		}
+		return st.minText
+	case Max:
+		if st.allNumeric {
+			return formatNumber(st.maxNum)
+		}
+		return st.maxText
+	case Percentile:
+		return formatNumber(percentile(st.values, agg.Percentile))
+	}
+
+	return ""
+}
+
+// percentile interpolates linearly between the closest ranks
+func percentile(values []float64, p float64) float64 {
+	sort.Float64s(values)
+
+	rank := p / 100 * float64(len(values)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return values[lower]
+	}
+	return values[lower] + (values[upper]-values[lower])*(rank-float64(lower))
+}
@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/jeremyseow/csv-parser/csv"
+)
+
+const programName = "csvtool"
+
+// exit codes returned by Run
+const (
+	ExitOK      = 0
+	ExitInvalid = 1 // the input could not be parsed or failed a check
+	ExitUsage   = 2
+	ExitIO      = 3
+)
+
+var (
+	errUsage   = errors.New("usage error")
+	errInvalid = errors.New("invalid input")
+	// errReported marks errors that were already printed, such as the list of validation failures
+	errReported = errors.New("reported")
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(a *app, args []string) error
+}
+
+// app carries the standard streams so that commands can be run from tests
+type app struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func commands() map[string]*command {
+	list := []*command{
+		{name: "cat", summary: "print the records of one or more files, aligning differing headers with -union", run: runCat},
+		{name: "head", summary: "print the first records", run: runHead},
+		{name: "tail", summary: "print the last records", run: runTail},
+		{name: "count", summary: "count the records", run: runCount},
+		{name: "headers", summary: "list the header columns", run: runHeaders},
+		{name: "validate", summary: "check that files parse, reporting every error", run: runValidate},
+		{name: "select", summary: "keep, reorder and rename columns", run: runSelect},
+		{name: "filter", summary: "keep the records matching an expression", run: runFilter},
+		{name: "groupby", summary: "group records and compute count, sum, min, max, avg, distinct and percentiles", run: runGroupBy},
+		{name: "sort", summary: "sort by one or more typed columns, spilling to disk for large files", run: runSort},
+		{name: "join", summary: "join two files on key columns: inner, left, right or full", run: runJoin},
+		{name: "diff", summary: "compare two versions of a file by key, listing added, removed and changed records", run: runDiff},
+		{name: "dedup", summary: "drop duplicate records, by whole record or by key columns", run: runDedup},
+		{name: "split", summary: "split into files by record count, size or column value", run: runSplit},
+		{name: "sample", summary: "pick a random or stratified sample of the records", run: runSample},
+		{name: "manifest", summary: "print a manifest of row count, columns and hashes, or verify a file against one", run: runManifest},
+		{name: "mask", summary: "mask columns by hashing, redacting, truncating, shifting dates or bucketing numbers", run: runMask},
+		{name: "serve", summary: "serve csv parsing, validation and profiling over HTTP", run: runServe},
+		{name: "convert", summary: "convert between csv, json, ndjson, tsv, markdown and ascii tables", run: runConvert},
+	}
+
+	byName := map[string]*command{}
+	for _, cmd := range list {
+		byName[cmd.name] = cmd
+	}
+	return byName
+}
+
+// Run executes the command named by args[0] and returns the process exit code
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	a := &app{stdin: stdin, stdout: stdout, stderr: stderr}
+
+	if len(args) == 0 {
+		a.usage()
+		return ExitUsage
+	}
+
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		a.usage()
+		return ExitOK
+	}
+
+	cmd, ok := commands()[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "%s: unknown command %q\n", programName, args[0])
+		a.usage()
+		return ExitUsage
+	}
+
+	err := cmd.run(a, args[1:])
+	if err == nil {
+		return ExitOK
+	}
+
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitOK
+	}
+
+	code := exitCode(err)
+	if !errors.Is(err, errReported) {
+		fmt.Fprintf(stderr, "%s %s: %v\n", programName, cmd.name, err)
+	}
+	return code
+}
+
+func (a *app) usage() {
+	fmt.Fprintf(a.stderr, "usage: %s <command> [flags] [file ...]\n\ncommands:\n", programName)
+
+	byName := commands()
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(a.stderr, "  %-10s %s\n", name, byName[name].summary)
+	}
+	fmt.Fprintf(a.stderr, "\nfiles default to stdin, use - to read stdin explicitly.\nrun '%s <command> -h' for the flags of a command.\n", programName)
+}
+
+func (a *app) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(programName+" "+name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	return fs
+}
+
+// inputError wraps failures to open or read an input
+type inputError struct {
+	name string
+	err  error
+}
+
+func (e *inputError) Error() string {
+	return fmt.Sprintf("%s: %v", e.name, e.err)
+}
+
+func (e *inputError) Unwrap() error {
+	return e.err
+}
+
+func exitCode(err error) int {
+	var parseErr *csv.ParseError
+	switch {
+	case errors.Is(err, errUsage), errors.Is(err, csv.ErrUnknownColumn):
+		return ExitUsage
+	case errors.As(err, &parseErr), errors.Is(err, errInvalid):
+		return ExitInvalid
+	}
+
+	return ExitIO
+}
+
+func usageErrorf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", errUsage, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,436 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name     string
+		args     []string
+		stdin    string
+		expected string
+		exitCode int
+	}{
+		{
+			name:     "cat file",
+			args:     []string{"cat", "-header=false", "../csv/data/test2.csv"},
+			expected: "1,2,\"\"\"3\"\"\"\n4,5,\"\n6\"\n7,8,\"\"\",9\"\"\"\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "cat keeps the first header only",
+			args:     []string{"cat", "-", "-"},
+			stdin:    "a,b\n1,2\n",
+			expected: "a,b\n1,2\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "cat fill without union",
+			args:     []string{"cat", "-fill", "NULL"},
+			exitCode: ExitUsage,
+		},
+		{
+			name:     "sample everything",
+			args:     []string{"sample", "-n", "5", "-seed", "7"},
+			stdin:    "a,b\n1,2\n3,4\n",
+			expected: "a,b\n1,2\n3,4\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "sample by key",
+			args:     []string{"sample", "-n", "1", "-by", "k", "-seed", "7"},
+			stdin:    "k\nx\nx\nx\ny\n",
+			expected: "k\nx\ny\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "sample with -n and -fraction",
+			args:     []string{"sample", "-n", "5", "-fraction", "0.5"},
+			exitCode: ExitUsage,
+		},
+		{
+			name:     "validate strict",
+			args:     []string{"validate", "-strict", "-final-newline"},
+			stdin:    "a,b\r\n1,2\n3,4,\r\n\r\n5,\"6\"x\r\n7,8",
+			expected: "<stdin>: line break is not CRLF at line: 2, column: 4\n<stdin>: trailing delimiter at line: 3, column: 4\n<stdin>: blank line at line: 4, column: 2\n<stdin>: mismatched escape char at line: 5, column: 6\n<stdin>: no line break after the last record at line: 6, column: 3\n<stdin>: 5 error(s), 0 valid records\n",
+			exitCode: ExitInvalid,
+		},
+		{
+			name:     "validate runaway quote",
+			args:     []string{"validate", "-max-quoted-lines", "2"},
+			stdin:    "id,note\n1,\"open\n2,x\n3,y\n4,z\n",
+			expected: "<stdin>: unterminated quoted field at line: 2, column: 3\n<stdin>: 1 error(s), 3 valid records\n",
+			exitCode: ExitInvalid,
+		},
+		{
+			name:     "headers normalized",
+			args:     []string{"headers", "-normalize-header", "trim,snake"},
+			stdin:    "\ufeffCustomer ID, OrderDate ,unit-price\n",
+			expected: "1\tcustomer_id\n2\torder_date\n3\tunit_price\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "unknown header normalization",
+			args:     []string{"headers", "-normalize-header", "kebab"},
+			exitCode: ExitUsage,
+		},
+		{
+			name:     "head",
+			args:     []string{"head", "-n", "1"},
+			stdin:    "a,b\n1,2\n3,4\n",
+			expected: "a,b\n1,2\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "tail",
+			args:     []string{"tail", "-n", "2"},
+			stdin:    "a,b\n1,2\n3,4\n5,6\n",
+			expected: "a,b\n3,4\n5,6\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "count",
+			args:     []string{"count", "-d", `\t`},
+			stdin:    "a\tb\n1\t2\n3\t4\n",
+			expected: "2\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "headers",
+			args:     []string{"headers"},
+			stdin:    "id,name\n1,x\n",
+			expected: "1\tid\n2\tname\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "validate reports every error",
+			args:     []string{"validate"},
+			stdin:    "a,b\n1\n2,\"3\"x\n4,5\n",
+			expected: "<stdin>: wrong number of fields at line: 2, column: 1\n<stdin>: mismatched escape char at line: 3, column: 6\n<stdin>: 2 error(s), 1 valid records\n",
+			exitCode: ExitInvalid,
+		},
+		{
+			name:     "convert to ndjson",
+			args:     []string{"convert", "-to", "ndjson", "-infer"},
+			stdin:    "id,name\n1,x\n",
+			expected: "{\"id\":1,\"name\":\"x\"}\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "convert from ndjson",
+			args:     []string{"convert", "-from", "ndjson", "-to", "csv"},
+			stdin:    "{\"id\":1,\"name\":\"x\"}\n",
+			expected: "id,name\n1,x\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "select",
+			args:     []string{"select", "-c", "name:who,1"},
+			stdin:    "id,name,email\n1,x,x@example.com\n",
+			expected: "who,id\nx,1\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "select unknown column",
+			args:     []string{"select", "-c", "nope"},
+			stdin:    "id,name\n1,x\n",
+			exitCode: ExitUsage,
+		},
+		{
+			name:     "filter",
+			args:     []string{"filter", `amount > 100 && country == "SG"`},
+			stdin:    "id,amount,country\n1,150,SG\n2,50,SG\n3,300,MY\n",
+			expected: "id,amount,country\n1,150,SG\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "filter syntax error",
+			args:     []string{"filter", `amount >`},
+			stdin:    "id,amount\n1,150\n",
+			exitCode: ExitUsage,
+		},
+		{
+			name:     "groupby",
+			args:     []string{"groupby", "-by", "country", "-agg", "count,sum(amount)", "-memory", "1K"},
+			stdin:    "id,amount,country\n1,150,SG\n2,50,SG\n3,300,MY\n",
+			expected: "country,count,sum(amount)\nMY,1,300\nSG,2,200\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "sort",
+			args:     []string{"sort", "-k", "amount:number:desc", "-memory", "1K"},
+			stdin:    "id,amount\n1,9\n2,10\n3,100\n",
+			expected: "id,amount\n3,100\n2,10\n1,9\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "join",
+			args:     []string{"join", "-on", "1", "-type", "left", "-", "../csv/data/test1.csv"},
+			stdin:    "1,x\n4,a\n5,b\n7,c\n",
+			expected: "1,x,2,3\n4,a,5,6\n5,b,,\n7,c,8,9\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "join without keys",
+			args:     []string{"join", "-", "../csv/data/test1.csv"},
+			exitCode: ExitUsage,
+		},
+		{
+			name:     "diff",
+			args:     []string{"diff", "-key", "1", "-", "../csv/data/test1.csv"},
+			stdin:    "1,3\n7,9\n4,5\n",
+			expected: "columns added: 2\n~ 1=4: 3 5 -> 6\n0 added, 0 removed, 1 changed, 1 unchanged\n",
+			exitCode: ExitInvalid,
+		},
+		{
+			name:     "dedup",
+			args:     []string{"dedup", "-key", "id", "-keep", "last"},
+			stdin:    "id,v\n1,a\n2,b\n1,c\n",
+			expected: "id,v\n2,b\n1,c\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "validate unique",
+			args:     []string{"validate", "-unique", "id"},
+			stdin:    "id,v\n1,a\n2,b,x\n1,c\n2,d\n1,e\n",
+			expected: "<stdin>: wrong number of fields at line: 3, column: 1\n<stdin>: duplicate id=\"1\" at lines 2, 4, 6\n<stdin>: 2 error(s), 4 valid records\n",
+			exitCode: ExitInvalid,
+		},
+		{
+			name:     "split without limits",
+			args:     []string{"split"},
+			stdin:    "id\n1\n",
+			exitCode: ExitUsage,
+		},
+		{
+			name:     "convert fixed width",
+			args:     []string{"convert", "-from", "fixed", "-widths", "id:1:3:right:pad=0:int,name:4:6", "-header=false", "-to", "csv"},
+			stdin:    "007alice\n012bob\n",
+			expected: "id,name\n7,alice\n12,bob\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "convert guessed fixed width",
+			args:     []string{"convert", "-from", "fixed", "-to", "ndjson"},
+			stdin:    "id  name\n7   alice\n12  bob\n",
+			expected: "{\"id\":\"7\",\"name\":\"alice\"}\n{\"id\":\"12\",\"name\":\"bob\"}\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "cat excel dialect",
+			args:     []string{"cat", "-dialect", "excel", "-sep-line"},
+			stdin:    "sep=;\nid;formula\n1;=A1*2\n",
+			expected: "\xEF\xBB\xBFsep=,\r\nid,formula\r\n1,'=A1*2\r\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "parse error",
+			args:     []string{"cat"},
+			stdin:    "a,b\n1\n",
+			expected: "a,b\n",
+			exitCode: ExitInvalid,
+		},
+		{
+			name:     "missing file",
+			args:     []string{"count", "does-not-exist.csv"},
+			exitCode: ExitIO,
+		},
+		{
+			name:     "unknown command",
+			args:     []string{"nope"},
+			exitCode: ExitUsage,
+		},
+		{
+			name:     "bad delimiter",
+			args:     []string{"cat", "-d", "ab"},
+			exitCode: ExitUsage,
+		},
+		{
+			name:     "mask without a spec",
+			args:     []string{"mask"},
+			exitCode: ExitUsage,
+		},
+		{
+			name:     "serve with a bad size",
+			args:     []string{"serve", "-max-size", "lots"},
+			exitCode: ExitUsage,
+		},
+		{
+			name:     "bad dialect",
+			args:     []string{"cat", "-dialect", "lotus"},
+			exitCode: ExitUsage,
+		},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr strings.Builder
+			exitCode := Run(currTestCase.args, strings.NewReader(currTestCase.stdin), &stdout, &stderr)
+			assert.Equal(t, currTestCase.exitCode, exitCode, stderr.String())
+			assert.Equal(t, currTestCase.expected, stdout.String())
+		})
+	}
+}
+
+func TestRunSplit(t *testing.T) {
+	dir := t.TempDir()
+
+	var stdout, stderr strings.Builder
+	exitCode := Run([]string{"split", "-by", "country", "-dir", dir}, strings.NewReader("id,country\n1,SG\n2,MY\n3,SG\n"), &stdout, &stderr)
+	assert.Equal(t, ExitOK, exitCode, stderr.String())
+	assert.Equal(t, filepath.Join(dir, "part-SG.csv")+"\n"+filepath.Join(dir, "part-MY.csv")+"\n", stdout.String())
+
+	content, err := os.ReadFile(filepath.Join(dir, "part-SG.csv"))
+	assert.NoError(t, err)
+	assert.Equal(t, "id,country\n1,SG\n3,SG\n", string(content))
+}
+
+func TestRunMask(t *testing.T) {
+	spec := filepath.Join(t.TempDir(), "mask.yaml")
+	assert.NoError(t, os.WriteFile(spec, []byte("columns:\n  email: {op: hash, length: 8}\n  card: {op: partial}\n"), 0o644))
+	t.Setenv("CSVTOOL_MASK_KEY", "secret")
+
+	var stdout, stderr strings.Builder
+	exitCode := Run([]string{"mask", "-spec", spec}, strings.NewReader("email,card\nann@x.com,4111111111111111\n"), &stdout, &stderr)
+	assert.Equal(t, ExitOK, exitCode, stderr.String())
+	assert.Equal(t, "email,card\n70a4a45d,************1111\n", stdout.String())
+
+	// without the key hashing is refused
+	t.Setenv("CSVTOOL_MASK_KEY", "")
+	stdout.Reset()
+	exitCode = Run([]string{"mask", "-spec", spec}, strings.NewReader("email,card\n"), &stdout, &stderr)
+	assert.Equal(t, ExitUsage, exitCode)
+	assert.Empty(t, stdout.String())
+}
+
+func TestRunCatUnion(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	january := filepath.Join(dir, "january.csv")
+	february := filepath.Join(dir, "february.csv")
+	assert.NoError(t, os.WriteFile(january, []byte("id,amount\n1,10\n2,20\n"), 0o644))
+	assert.NoError(t, os.WriteFile(february, []byte("amount,vendor,id\n30,acme,3\n"), 0o644))
+
+	testCases := []struct {
+		name     string
+		args     []string
+		expected string
+		exitCode int
+	}{
+		{
+			name:     "union",
+			args:     []string{"cat", "-union", january, february},
+			expected: "id,amount,vendor\n1,10,\n2,20,\n3,30,acme\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "intersection with the source file",
+			args:     []string{"cat", "--union", "--intersect", "-source", "file", january, february},
+			expected: "id,amount,file\n1,10," + january + "\n2,20," + january + "\n3,30," + february + "\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "fill",
+			args:     []string{"cat", "-union", "-fill", "NULL", february, january},
+			expected: "amount,vendor,id\n30,acme,3\n10,NULL,1\n20,NULL,2\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "without a header",
+			args:     []string{"cat", "-union", "-header=false", january},
+			exitCode: ExitUsage,
+		},
+		{
+			name:     "missing file",
+			args:     []string{"cat", "-union", january, filepath.Join(dir, "march.csv")},
+			exitCode: ExitIO,
+		},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr strings.Builder
+			exitCode := Run(currTestCase.args, strings.NewReader(""), &stdout, &stderr)
+			assert.Equal(t, currTestCase.exitCode, exitCode, stderr.String())
+			assert.Equal(t, currTestCase.expected, stdout.String())
+		})
+	}
+}
+
+func TestRunManifest(t *testing.T) {
+	t.Parallel()
+
+	saved := filepath.Join(t.TempDir(), "manifest.json")
+	var stdout, stderr strings.Builder
+	exitCode := Run([]string{"manifest", "-chunk", "2"}, strings.NewReader("id,name\n1,ann\n2,bob\n3,cat\n4,dan\n"), &stdout, &stderr)
+	assert.Equal(t, ExitOK, exitCode, stderr.String())
+	assert.Contains(t, stdout.String(), `"rows": 4`)
+	assert.NoError(t, os.WriteFile(saved, []byte(stdout.String()), 0o644))
+
+	testCases := []struct {
+		name     string
+		stdin    string
+		expected string
+		exitCode int
+	}{
+		{
+			name:     "same data quoted differently",
+			stdin:    "\"id\",\"name\"\r\n1,ann\r\n2,bob\r\n3,cat\r\n4,\"dan\"\r\n",
+			expected: "<stdin>: ok, 4 records\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "changed and missing records",
+			stdin:    "id,name\n1,ann\n2,bob\n3,cot\n",
+			expected: "<stdin>: records 3-3 differ\n",
+			exitCode: ExitInvalid,
+		},
+		{
+			name:     "extra records",
+			stdin:    "id,name\n1,ann\n2,bob\n3,cat\n4,dan\n5,eve\n",
+			expected: "<stdin>: records 5-5 are extra\n",
+			exitCode: ExitInvalid,
+		},
+		{
+			name:     "renamed column",
+			stdin:    "id,first_name\n1,ann\n2,bob\n",
+			expected: "<stdin>: columns differ\n<stdin>: records 3-4 are missing\n",
+			exitCode: ExitInvalid,
+		},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr strings.Builder
+			exitCode := Run([]string{"manifest", "-verify", saved}, strings.NewReader(currTestCase.stdin), &stdout, &stderr)
+			assert.Equal(t, currTestCase.exitCode, exitCode, stderr.String())
+			assert.Equal(t, currTestCase.expected, stdout.String())
+		})
+	}
+
+	for _, bad := range []string{"null", `{"rows":0,"chunk_size":0}`, `{"chunk_size":-1}`} {
+		path := filepath.Join(t.TempDir(), "bad.json")
+		assert.NoError(t, os.WriteFile(path, []byte(bad), 0o644))
+
+		var stdout, stderr strings.Builder
+		exitCode := Run([]string{"manifest", "-verify", path}, strings.NewReader("id\n1\n"), &stdout, &stderr)
+		assert.Equal(t, ExitIO, exitCode, bad)
+		assert.Contains(t, stderr.String(), "not a manifest", bad)
+	}
+}
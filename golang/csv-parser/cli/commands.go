@@ -0,0 +1,426 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/jeremyseow/csv-parser/dedup"
+)
+
+func runCat(a *app, args []string) error {
+	fs := a.flagSet("cat")
+	var rf readerFlags
+	rf.register(fs)
+	rf.registerOutput(fs)
+	union := fs.Bool("union", false, "align the columns of inputs with differing headers by name")
+	intersect := fs.Bool("intersect", false, "with -union, only keep the columns every input has")
+	fill := fs.String("fill", "", "with -union, the value of the columns an input does not have")
+	source := fs.String("source", "", "with -union, add a column of this name holding the input file name")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if !*union && (*intersect || *fill != "" || *source != "") {
+		return usageErrorf("-intersect, -fill and -source need -union")
+	}
+	if *union && !rf.header {
+		return usageErrorf("-union needs a header")
+	}
+
+	options, err := rf.options()
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewCsvWriter(a.stdout, rf.writerOptions()...)
+	if *union {
+		unionOptions := []csv.UnionOption{csv.WithIntersection(*intersect), csv.WithFill(*fill), csv.WithSourceColumn(*source)}
+		return flushWriter(cw, a.catUnion(fs.Args(), options, unionOptions, cw))
+	}
+
+	headerWritten := false
+	err = a.eachInput(fs.Args(), options, func(name string, cr *csv.CsvReader) error {
+		header, err := cr.Header()
+		if err != nil {
+			return err
+		}
+
+		// only the first file's header is kept
+		if header != nil && !headerWritten {
+			headerWritten = true
+			if err := cw.Write(header); err != nil {
+				return err
+			}
+		}
+
+		return copyRecords(cr, cw, -1)
+	})
+
+	return flushWriter(cw, err)
+}
+
+// catUnion opens every input at once, as the header depends on all of them
+func (a *app) catUnion(paths []string, options []csv.ReaderOption, unionOptions []csv.UnionOption, cw *csv.CsvWriter) error {
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	sources := make([]csv.Source, 0, len(paths))
+	for _, path := range paths {
+		name, r, closeFn, err := a.open(path)
+		if err != nil {
+			return err
+		}
+		defer closeFn()
+		sources = append(sources, csv.Source{Name: name, Reader: csv.NewCsvReader(r, options...)})
+	}
+
+	ur := csv.NewUnionReader(sources, unionOptions...)
+	err := writeHeader(ur, cw)
+	if err == nil {
+		err = copyRecords(ur, cw, -1)
+	}
+	if err != nil && ur.Source() != "" {
+		return &inputError{name: ur.Source(), err: err}
+	}
+	return err
+}
+
+func runHead(a *app, args []string) error {
+	fs := a.flagSet("head")
+	var rf readerFlags
+	rf.register(fs)
+	rf.registerOutput(fs)
+	n := fs.Int("n", 10, "number of records to print")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	path, err := singleInput(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	options, err := rf.options()
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewCsvWriter(a.stdout, rf.writerOptions()...)
+	err = a.withInput(path, options, func(name string, cr *csv.CsvReader) error {
+		if err := writeHeader(cr, cw); err != nil {
+			return err
+		}
+		return copyRecords(cr, cw, *n)
+	})
+
+	return flushWriter(cw, err)
+}
+
+func runTail(a *app, args []string) error {
+	fs := a.flagSet("tail")
+	var rf readerFlags
+	rf.register(fs)
+	rf.registerOutput(fs)
+	n := fs.Int("n", 10, "number of records to print")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	path, err := singleInput(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	options, err := rf.options()
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewCsvWriter(a.stdout, rf.writerOptions()...)
+	err = a.withInput(path, options, func(name string, cr *csv.CsvReader) error {
+		if err := writeHeader(cr, cw); err != nil {
+			return err
+		}
+
+		if *n <= 0 {
+			return nil
+		}
+
+		// keep the last n records in a ring buffer
+		last := make([][]string, 0, *n)
+		next := 0
+		for {
+			record, err := cr.ReadRecord()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+
+			if len(last) < *n {
+				last = append(last, record)
+			} else {
+				last[next] = record
+				next = (next + 1) % *n
+			}
+		}
+
+		for i := range last {
+			if err := cw.Write(last[(next+i)%len(last)]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return flushWriter(cw, err)
+}
+
+func runCount(a *app, args []string) error {
+	fs := a.flagSet("count")
+	var rf readerFlags
+	rf.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	options, err := rf.options()
+	if err != nil {
+		return err
+	}
+
+	total, files := 0, 0
+	err = a.eachInput(fs.Args(), options, func(name string, cr *csv.CsvReader) error {
+		count := 0
+		for {
+			_, err := cr.ReadRecord()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			count++
+		}
+
+		total += count
+		files++
+		if len(fs.Args()) > 1 {
+			fmt.Fprintf(a.stdout, "%d %s\n", count, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if files > 1 {
+		fmt.Fprintf(a.stdout, "%d total\n", total)
+	} else {
+		fmt.Fprintf(a.stdout, "%d\n", total)
+	}
+	return nil
+}
+
+func runHeaders(a *app, args []string) error {
+	fs := a.flagSet("headers")
+	var rf readerFlags
+	rf.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	path, err := singleInput(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	options, err := rf.options()
+	if err != nil {
+		return err
+	}
+	options = append(options, csv.WithHeader(true))
+
+	return a.withInput(path, options, func(name string, cr *csv.CsvReader) error {
+		header, err := cr.Header()
+		if err != nil {
+			return err
+		}
+
+		for i, column := range header {
+			fmt.Fprintf(a.stdout, "%d\t%s\n", i+1, column)
+		}
+		return nil
+	})
+}
+
+func runValidate(a *app, args []string) error {
+	fs := a.flagSet("validate")
+	var rf readerFlags
+	rf.register(fs)
+	unique := fs.String("unique", "", "comma separated key columns that must be unique, reporting the lines of every duplicate")
+	memory := fs.String("memory", "256M", "memory budget for the uniqueness check, e.g. 512M or 2G")
+	tempDir := fs.String("tmp", "", "directory for spill files, the system temp dir by default")
+	strict := fs.Bool("strict", false, "report every deviation from RFC 4180, such as bare LF line breaks or trailing delimiters")
+	finalNewline := fs.Bool("final-newline", false, "report a last record without a line break after it")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	keys := splitNames(*unique)
+	memoryLimit, err := parseSize(*memory)
+	if err != nil {
+		return err
+	}
+
+	options, err := rf.options()
+	if err != nil {
+		return err
+	}
+	options = append(options, csv.WithStrict(*strict), csv.WithFinalNewline(*finalNewline))
+
+	invalid := false
+	err = a.eachInput(fs.Args(), options, func(name string, cr *csv.CsvReader) error {
+		vr := &validatingReader{CsvReader: cr, a: a, name: name}
+		if _, err := cr.Header(); err != nil {
+			if !reportParseError(a, name, err) {
+				return err
+			}
+			vr.numErrors++
+		}
+
+		if keys == nil {
+			for {
+				if _, err := vr.ReadRecord(); err == io.EOF {
+					break
+				} else if err != nil {
+					return err
+				}
+			}
+		} else {
+			err := dedup.Duplicates(vr, keys, func(dup dedup.Duplicate) error {
+				vr.numErrors++
+				lines := make([]string, len(dup.Lines))
+				for i, line := range dup.Lines {
+					lines[i] = strconv.Itoa(line)
+				}
+				_, err := fmt.Fprintf(a.stdout, "%s: duplicate %s at lines %s\n", name, describeKey(keys, dup.Key), strings.Join(lines, ", "))
+				return err
+			}, dedup.WithMemoryLimit(memoryLimit), dedup.WithTempDir(*tempDir))
+			if err != nil {
+				return err
+			}
+		}
+
+		if vr.numErrors > 0 {
+			invalid = true
+			fmt.Fprintf(a.stdout, "%s: %d error(s), %d valid records\n", name, vr.numErrors, vr.records)
+		} else {
+			fmt.Fprintf(a.stdout, "%s: ok, %d records\n", name, vr.records)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if invalid {
+		return errors.Join(errInvalid, errReported)
+	}
+	return nil
+}
+
+// validatingReader reports parse errors as it reads and carries on with the next record
+type validatingReader struct {
+	*csv.CsvReader
+	a         *app
+	name      string
+	records   int
+	numErrors int
+}
+
+func (vr *validatingReader) ReadRecord() ([]string, error) {
+	for {
+		record, err := vr.CsvReader.ReadRecord()
+		if err == nil {
+			vr.records++
+			return record, nil
+		}
+		if err == io.EOF || !reportParseError(vr.a, vr.name, err) {
+			return nil, err
+		}
+		vr.numErrors++
+	}
+}
+
+func describeKey(names, values []string) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = fmt.Sprintf("%s=%q", names[i], value)
+	}
+	return strings.Join(parts, " ")
+}
+
+// reportParseError prints err if it is a parse error and reports whether it was one
+func reportParseError(a *app, name string, err error) bool {
+	var parseErr *csv.ParseError
+	if !errors.As(err, &parseErr) {
+		return false
+	}
+
+	fmt.Fprintf(a.stdout, "%s: %v\n", name, err)
+	return true
+}
+
+func singleInput(paths []string) (string, error) {
+	switch len(paths) {
+	case 0:
+		return "-", nil
+	case 1:
+		return paths[0], nil
+	}
+
+	return "", usageErrorf("expected at most one file, got %d", len(paths))
+}
+
+func writeHeader(cr csv.RecordReader, cw *csv.CsvWriter) error {
+	header, err := cr.Header()
+	if err != nil || header == nil {
+		return err
+	}
+
+	return cw.Write(header)
+}
+
+// copyRecords copies up to limit records from cr to cw, or all of them when limit is negative
+func copyRecords(cr csv.RecordReader, cw *csv.CsvWriter, limit int) error {
+	for n := 0; limit < 0 || n < limit; n++ {
+		record, err := cr.ReadRecord()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func flushWriter(cw *csv.CsvWriter, err error) error {
+	flushErr := cw.Flush()
+	if err != nil {
+		return err
+	}
+	return flushErr
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"github.com/jeremyseow/csv-parser/convert"
+	"github.com/jeremyseow/csv-parser/csv"
+)
+
+func runConvert(a *app, args []string) error {
+	fs := a.flagSet("convert")
+	var rf readerFlags
+	rf.register(fs)
+	rf.registerOutput(fs)
+	from := fs.String("from", "csv", "input format: csv, ndjson or fixed")
+	widths := fs.String("widths", "guess", "fixed width columns, e.g. id:1:6:right:pad=0:int,name:7:20,joined:27:8:date=20060102, or guess")
+	to := fs.String("to", "json", "output format: csv, json, ndjson, tsv, markdown or table")
+	infer := fs.Bool("infer", false, "write numbers, booleans and empty fields as JSON numbers, booleans and null")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	path, err := singleInput(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	toFormat, err := convert.ParseFormat(*to)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+
+	if *from == "fixed" {
+		return a.convertFixedWidth(path, *widths, rf, func(r csv.RecordReader) error {
+			return convert.Convert(r, a.stdout, toFormat,
+				convert.WithInferTypes(*infer),
+				convert.WithCsvWriterOptions(rf.writerOptions()...))
+		})
+	}
+
+	fromFormat, err := convert.ParseFormat(*from)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+
+	if fromFormat == convert.FormatNDJSON {
+		if toFormat != convert.FormatCSV {
+			return usageErrorf("ndjson input can only be converted to csv")
+		}
+
+		name, r, closeFn, err := a.open(path)
+		if err != nil {
+			return err
+		}
+		defer closeFn()
+
+		if err := convert.FromNDJSON(r, csv.NewCsvWriter(a.stdout, rf.writerOptions()...)); err != nil {
+			return &inputError{name: name, err: err}
+		}
+		return nil
+	}
+
+	if fromFormat != convert.FormatCSV {
+		return usageErrorf("unsupported input format %s", fromFormat)
+	}
+
+	options, err := rf.options()
+	if err != nil {
+		return err
+	}
+
+	return a.withInput(path, options, func(name string, cr *csv.CsvReader) error {
+		return convert.Convert(cr, a.stdout, toFormat,
+			convert.WithInferTypes(*infer),
+			convert.WithCsvWriterOptions(rf.writerOptions()...))
+	})
+}
+
+// fixedWidthSampleLines are read to guess fixed width columns
+const fixedWidthSampleLines = 100
+
+// convertFixedWidth reads path as fixed width columns, guessing them from the first lines
+// when spec is guess. The -header flag says whether the first line names the columns.
+func (a *app) convertFixedWidth(path, spec string, rf readerFlags, fn func(r csv.RecordReader) error) error {
+	encoding, err := csv.ParseEncoding(rf.encoding)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+
+	var columns []csv.FixedWidthColumn
+	if spec != "guess" {
+		if columns, err = csv.ParseFixedWidthColumns(spec); err != nil {
+			return usageErrorf("%v", err)
+		}
+	}
+
+	options := []csv.FixedWidthOption{csv.WithFixedWidthEncoding(encoding)}
+	if rf.header {
+		options = append(options, csv.WithSkipLines(1))
+	}
+
+	name, r, closeFn, err := a.open(path)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	var reader *csv.FixedWidthReader
+	if columns == nil {
+		reader = csv.NewGuessingFixedWidthReader(r, fixedWidthSampleLines, rf.header, options...)
+	} else {
+		reader = csv.NewFixedWidthReader(r, columns, options...)
+	}
+
+	if err := fn(reader); err != nil {
+		return &inputError{name: name, err: err}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/jeremyseow/csv-parser/dedup"
+)
+
+func runDedup(a *app, args []string) error {
+	fs := a.flagSet("dedup")
+	var rf readerFlags
+	rf.register(fs)
+	rf.registerOutput(fs)
+	key := fs.String("key", "", "comma separated key columns, whole records are compared when empty")
+	keep := fs.String("keep", "first", "which of the duplicates to keep, first or last")
+	memory := fs.String("memory", "256M", "memory budget before spilling to disk, e.g. 512M or 2G")
+	tempDir := fs.String("tmp", "", "directory for spill files, the system temp dir by default")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	keepPolicy, err := dedup.ParseKeep(*keep)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+
+	memoryLimit, err := parseSize(*memory)
+	if err != nil {
+		return err
+	}
+
+	path, err := singleInput(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	options, err := rf.options()
+	if err != nil {
+		return err
+	}
+
+	return a.withInput(path, options, func(name string, cr *csv.CsvReader) error {
+		return dedup.Dedup(cr, csv.NewCsvWriter(a.stdout, rf.writerOptions()...), splitNames(*key), keepPolicy,
+			dedup.WithMemoryLimit(memoryLimit),
+			dedup.WithTempDir(*tempDir))
+	})
+}
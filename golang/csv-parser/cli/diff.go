@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"errors"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/jeremyseow/csv-parser/diff"
+)
+
+func runDiff(a *app, args []string) error {
+	fs := a.flagSet("diff")
+	var rf readerFlags
+	rf.register(fs)
+	key := fs.String("key", "", "comma separated key columns identifying a record in both files")
+	format := fs.String("format", "text", "text, csv or json")
+	sorted := fs.Bool("sorted", false, "both files are already sorted by key, so they are not sorted first")
+	memory := fs.String("memory", "256M", "memory budget for sorting, e.g. 512M or 2G")
+	tempDir := fs.String("tmp", "", "directory for the sorted files, the system temp dir by default")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	keys := splitNames(*key)
+	if len(keys) == 0 {
+		return usageErrorf("-key is required")
+	}
+
+	reportFormat, err := diff.ParseFormat(*format)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+
+	memoryLimit, err := parseSize(*memory)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() != 2 {
+		return usageErrorf("diff needs an old and a new file, got %d", fs.NArg())
+	}
+
+	options, err := rf.options()
+	if err != nil {
+		return err
+	}
+	options = append(options, csv.WithHeader(true))
+
+	reporter, err := diff.NewReporter(a.stdout, reportFormat)
+	if err != nil {
+		return err
+	}
+
+	var summary diff.Summary
+	err = a.withInput(fs.Arg(0), options, func(_ string, oldReader *csv.CsvReader) error {
+		return a.withInput(fs.Arg(1), options, func(_ string, newReader *csv.CsvReader) error {
+			summary, err = diff.Diff(oldReader, newReader, keys, reporter,
+				diff.WithSorted(*sorted),
+				diff.WithMemoryLimit(memoryLimit),
+				diff.WithTempDir(*tempDir))
+			return err
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// like diff(1), differences exit with 1
+	if summary.Differs() {
+		return errors.Join(errInvalid, errReported)
+	}
+	return nil
+}
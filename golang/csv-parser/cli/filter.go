@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/jeremyseow/csv-parser/filter"
+)
+
+func runFilter(a *app, args []string) error {
+	fs := a.flagSet("filter")
+	var rf readerFlags
+	rf.register(fs)
+	rf.registerOutput(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		return usageErrorf("missing filter expression, e.g. 'amount > 100 && country == \"SG\"'")
+	}
+	expr := fs.Arg(0)
+
+	path, err := singleInput(fs.Args()[1:])
+	if err != nil {
+		return err
+	}
+
+	options, err := rf.options()
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewCsvWriter(a.stdout, rf.writerOptions()...)
+	err = a.withInput(path, options, func(name string, cr *csv.CsvReader) error {
+		fr, err := filter.Filter(cr, expr)
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return err
+			}
+			return fmt.Errorf("%w: %w", errUsage, err)
+		}
+
+		if err := writeHeader(cr, cw); err != nil {
+			return err
+		}
+		return copyRecords(fr, cw, -1)
+	})
+
+	return flushWriter(cw, err)
+}
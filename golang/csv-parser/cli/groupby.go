@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/jeremyseow/csv-parser/aggregate"
+	"github.com/jeremyseow/csv-parser/csv"
+)
+
+func runGroupBy(a *app, args []string) error {
+	fs := a.flagSet("groupby")
+	var rf readerFlags
+	rf.register(fs)
+	rf.registerOutput(fs)
+	by := fs.String("by", "", "comma separated columns to group by, none for a single group")
+	aggs := fs.String("agg", "count", "aggregations, e.g. count,sum(amount),avg(amount),min(x),max(x),distinct(x),median(x),p95(x), name:alias to rename")
+	memory := fs.String("memory", "256M", "memory budget before spilling to disk, e.g. 512M or 2G")
+	tempDir := fs.String("tmp", "", "directory for spill files, the system temp dir by default")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	aggregations, err := aggregate.ParseAggregations(*aggs)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+
+	memoryLimit, err := parseSize(*memory)
+	if err != nil {
+		return err
+	}
+
+	groupBy := splitNames(*by)
+
+	path, err := singleInput(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	options, err := rf.options()
+	if err != nil {
+		return err
+	}
+	options = append(options, csv.WithHeader(true))
+
+	return a.withInput(path, options, func(name string, cr *csv.CsvReader) error {
+		return aggregate.Aggregate(cr, csv.NewCsvWriter(a.stdout, rf.writerOptions()...), groupBy, aggregations,
+			aggregate.WithMemoryLimit(memoryLimit),
+			aggregate.WithTempDir(*tempDir))
+	})
+}
+
+// parseSize reads a byte count with an optional K, M or G suffix
+func parseSize(value string) (int64, error) {
+	s := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(value)), "B")
+
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(s, "M"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(s, "G"):
+		multiplier = 1 << 30
+	}
+	if multiplier > 1 {
+		s = s[:len(s)-1]
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, usageErrorf("bad size %q, expected something like 512M", value)
+	}
+	return n * multiplier, nil
+}
@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/jeremyseow/csv-parser/csv"
+)
+
+const stdinName = "<stdin>"
+
+// readerFlags are the CsvReader options shared by every command that reads csv
+type readerFlags struct {
+	delimiter string
+	quote     string
+	header    bool
+	encoding  string
+	lenient   bool
+	// maxQuotedLines is how many line breaks a quoted field may hold before it is reported
+	// as unterminated
+	maxQuotedLines int
+	normalize      string
+
+	// output flags, only registered by the commands that write csv
+	dialect string
+	sepLine bool
+}
+
+func (rf *readerFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&rf.delimiter, "d", ",", `field delimiter, a single byte or "\t"`)
+	fs.StringVar(&rf.quote, "q", `"`, "quote (escape) char")
+	fs.BoolVar(&rf.header, "header", true, "treat the first record as a header")
+	fs.StringVar(&rf.encoding, "encoding", "utf-8", "input encoding: utf-8, latin1, utf-16le or utf-16be")
+	fs.BoolVar(&rf.lenient, "lenient", false, "allow a varying number of fields and stray quotes")
+	fs.StringVar(&rf.normalize, "normalize-header", "", "comma separated header normalizations applied in order: trim, fold, snake, punct or bom")
+	fs.IntVar(&rf.maxQuotedLines, "max-quoted-lines", 0, "line breaks a quoted field may hold before it is taken to be missing its closing quote, 0 for no limit")
+}
+
+func (rf *readerFlags) registerOutput(fs *flag.FlagSet) {
+	fs.StringVar(&rf.dialect, "dialect", "csv", "output dialect: csv, or excel for a BOM, CRLF and escaped formulas")
+	fs.BoolVar(&rf.sepLine, "sep-line", false, "start the output with a sep= line naming the delimiter")
+}
+
+func (rf *readerFlags) options() ([]csv.ReaderOption, error) {
+	delimiter, err := parseByteFlag("d", rf.delimiter)
+	if err != nil {
+		return nil, err
+	}
+
+	quote, err := parseByteFlag("q", rf.quote)
+	if err != nil {
+		return nil, err
+	}
+
+	encoding, err := csv.ParseEncoding(rf.encoding)
+	if err != nil {
+		return nil, usageErrorf("%v", err)
+	}
+
+	if _, err := csv.ParseDialect(rf.dialect); err != nil {
+		return nil, usageErrorf("%v", err)
+	}
+
+	var normalization []csv.Normalization
+	for _, name := range splitNames(rf.normalize) {
+		step, err := csv.ParseNormalization(name)
+		if err != nil {
+			return nil, usageErrorf("%v", err)
+		}
+		normalization = append(normalization, step)
+	}
+
+	return []csv.ReaderOption{
+		csv.WithDelimiter(delimiter),
+		csv.WithEscapeChar(quote),
+		csv.WithHeader(rf.header),
+		csv.WithEncoding(encoding),
+		csv.WithLenient(rf.lenient),
+		csv.WithSepLineDetection(true),
+		csv.WithMaxQuotedLines(rf.maxQuotedLines),
+		csv.WithHeaderNormalization(normalization...),
+	}, nil
+}
+
+// writerOptions makes the output use the same delimiter and quote as the input
+func (rf *readerFlags) writerOptions() []csv.WriterOption {
+	delimiter, _ := parseByteFlag("d", rf.delimiter)
+	quote, _ := parseByteFlag("q", rf.quote)
+	dialect, _ := csv.ParseDialect(rf.dialect)
+	return []csv.WriterOption{
+		csv.WithWriterDelimiter(delimiter),
+		csv.WithWriterEscapeChar(quote),
+		csv.WithDialect(dialect),
+		csv.WithSepLine(rf.sepLine),
+	}
+}
+
+func parseByteFlag(name, value string) (byte, error) {
+	switch value {
+	case `\t`, "tab":
+		return '\t', nil
+	}
+
+	if len(value) != 1 {
+		return 0, usageErrorf("-%s must be a single byte, got %q", name, value)
+	}
+	return value[0], nil
+}
+
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+
+	// the flag package has already printed the problem and the usage
+	return errors.Join(errUsage, errReported)
+}
+
+// eachInput opens every path in turn (stdin when there are none) and hands fn a reader over it
+func (a *app) eachInput(paths []string, options []csv.ReaderOption, fn func(name string, cr *csv.CsvReader) error) error {
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	for _, path := range paths {
+		if err := a.withInput(path, options, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *app) withInput(path string, options []csv.ReaderOption, fn func(name string, cr *csv.CsvReader) error) error {
+	name, r, closeFn, err := a.open(path)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	err = fn(name, csv.NewCsvReader(r, options...))
+	var inputErr *inputError
+	if err != nil && !errors.As(err, &inputErr) && !errors.Is(err, errReported) {
+		return &inputError{name: name, err: err}
+	}
+	return err
+}
+
+func (a *app) open(path string) (string, io.Reader, func() error, error) {
+	if path == "-" {
+		return stdinName, a.stdin, func() error { return nil }, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return path, nil, nil, &inputError{name: path, err: err}
+	}
+	return path, file, file.Close, nil
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/jeremyseow/csv-parser/join"
+)
+
+func runJoin(a *app, args []string) error {
+	fs := a.flagSet("join")
+	var rf readerFlags
+	rf.register(fs)
+	rf.registerOutput(fs)
+	on := fs.String("on", "", "comma separated key columns of the left file")
+	rightOn := fs.String("right-on", "", "key columns of the right file when they are named differently")
+	kind := fs.String("type", "inner", "inner, left, right or full")
+	suffixes := fs.String("suffixes", "_left,_right", "appended to non key columns found in both files")
+	memory := fs.String("memory", "256M", "memory budget for hashing the right file, or else the left one, before sorting both on disk, e.g. 512M or 2G")
+	tempDir := fs.String("tmp", "", "directory for the sorted files, the system temp dir by default")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	leftKeys := splitNames(*on)
+	if len(leftKeys) == 0 {
+		return usageErrorf("-on is required")
+	}
+	rightKeys := splitNames(*rightOn)
+	if rightKeys == nil {
+		rightKeys = leftKeys
+	}
+
+	joinKind, err := join.ParseKind(*kind)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+
+	leftSuffix, rightSuffix, ok := strings.Cut(*suffixes, ",")
+	if !ok {
+		return usageErrorf("-suffixes needs two comma separated suffixes, got %q", *suffixes)
+	}
+
+	memoryLimit, err := parseSize(*memory)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() != 2 {
+		return usageErrorf("join needs a left and a right file, got %d", fs.NArg())
+	}
+
+	options, err := rf.options()
+	if err != nil {
+		return err
+	}
+	options = append(options, csv.WithHeader(true))
+
+	return a.withInput(fs.Arg(0), options, func(_ string, left *csv.CsvReader) error {
+		return a.withInput(fs.Arg(1), options, func(_ string, right *csv.CsvReader) error {
+			return join.Join(left, right, csv.NewCsvWriter(a.stdout, rf.writerOptions()...), leftKeys, rightKeys, joinKind,
+				join.WithSuffixes(leftSuffix, rightSuffix),
+				join.WithMemoryLimit(memoryLimit),
+				join.WithTempDir(*tempDir))
+		})
+	})
+}
+
+// splitNames splits a comma separated list of column names, nil when there are none
+func splitNames(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
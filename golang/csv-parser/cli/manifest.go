@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/jeremyseow/csv-parser/manifest"
+)
+
+var errBadManifest = errors.New("not a manifest")
+
+func runManifest(a *app, args []string) error {
+	fs := a.flagSet("manifest")
+	var rf readerFlags
+	rf.register(fs)
+	chunk := fs.Int("chunk", 10000, "records per chunk")
+	verify := fs.String("verify", "", "manifest file to check the input against instead of printing its manifest")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if *chunk <= 0 {
+		return usageErrorf("-chunk must be positive, got %d", *chunk)
+	}
+
+	var expected *manifest.Manifest
+	if *verify != "" {
+		data, err := os.ReadFile(*verify)
+		if err != nil {
+			return &inputError{name: *verify, err: err}
+		}
+		if err := json.Unmarshal(data, &expected); err != nil {
+			return &inputError{name: *verify, err: err}
+		}
+		if expected == nil {
+			return &inputError{name: *verify, err: fmt.Errorf("%w: null", errBadManifest)}
+		}
+		if expected.ChunkSize <= 0 {
+			return &inputError{name: *verify, err: fmt.Errorf("%w: chunk_size %d", errBadManifest, expected.ChunkSize)}
+		}
+		// the input is cut into the same chunks as the manifest it is checked against
+		*chunk = expected.ChunkSize
+	}
+
+	path, err := singleInput(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	options, err := rf.options()
+	if err != nil {
+		return err
+	}
+
+	return a.withInput(path, options, func(name string, cr *csv.CsvReader) error {
+		actual, err := manifest.Build(cr, manifest.WithChunkSize(*chunk))
+		if err != nil {
+			return err
+		}
+
+		if expected == nil {
+			enc := json.NewEncoder(a.stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(actual)
+		}
+		return reportChanges(a, name, expected, actual)
+	})
+}
+
+func reportChanges(a *app, name string, expected, actual *manifest.Manifest) error {
+	changes, err := manifest.Compare(expected, actual)
+	if err != nil {
+		return err
+	}
+	if changes.Unchanged() {
+		fmt.Fprintf(a.stdout, "%s: ok, %d records\n", name, actual.Rows)
+		return nil
+	}
+
+	if changes.Columns {
+		fmt.Fprintf(a.stdout, "%s: columns differ\n", name)
+	}
+	for _, i := range changes.Chunks {
+		var chunk manifest.Chunk
+		problem := "differ"
+		switch {
+		case i >= len(actual.Chunks):
+			chunk, problem = expected.Chunks[i], "are missing"
+		case i >= len(expected.Chunks):
+			chunk, problem = actual.Chunks[i], "are extra"
+		default:
+			chunk = actual.Chunks[i]
+		}
+		fmt.Fprintf(a.stdout, "%s: records %d-%d %s\n", name, chunk.FirstRow, chunk.FirstRow+chunk.Rows-1, problem)
+	}
+	return errors.Join(errInvalid, errReported)
+}
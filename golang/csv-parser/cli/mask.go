@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"errors"
+	"os"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/jeremyseow/csv-parser/mask"
+)
+
+func runMask(a *app, args []string) error {
+	fs := a.flagSet("mask")
+	var rf readerFlags
+	rf.register(fs)
+	rf.registerOutput(fs)
+	specPath := fs.String("spec", "", "YAML or JSON file with the rule of every column to mask")
+	keyEnv := fs.String("key-env", "CSVTOOL_MASK_KEY", "environment variable holding the key of hash and shift by max_days")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if *specPath == "" {
+		return usageErrorf("mask needs -spec")
+	}
+	data, err := os.ReadFile(*specPath)
+	if err != nil {
+		return &inputError{name: *specPath, err: err}
+	}
+	spec, err := mask.ParseSpec(data)
+	if err != nil {
+		return usageErrorf("%s: %v", *specPath, err)
+	}
+
+	path, err := singleInput(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	options, err := rf.options()
+	if err != nil {
+		return err
+	}
+
+	// the key is read from the environment so that it stays out of the spec and the shell history
+	maskOptions := []mask.Option{mask.WithKey([]byte(os.Getenv(*keyEnv)))}
+	return a.withInput(path, options, func(name string, cr *csv.CsvReader) error {
+		err := mask.Mask(cr, csv.NewCsvWriter(a.stdout, rf.writerOptions()...), spec, maskOptions...)
+		if errors.Is(err, mask.ErrMissingKey) {
+			return usageErrorf("%v, set %s", err, *keyEnv)
+		}
+		return err
+	})
+}
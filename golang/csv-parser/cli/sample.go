@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"flag"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/jeremyseow/csv-parser/sample"
+)
+
+func runSample(a *app, args []string) error {
+	fs := a.flagSet("sample")
+	var rf readerFlags
+	rf.register(fs)
+	rf.registerOutput(fs)
+	n := fs.Int("n", 0, "number of records to pick, or to pick per key with -by")
+	fraction := fs.Float64("fraction", 0, "pick every record with this probability instead, e.g. 0.01")
+	by := fs.String("by", "", "comma separated key columns to pick -n records of each value of")
+	seed := fs.Int64("seed", 0, "seed making the sample reproducible, random when not set")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	switch {
+	case (*n != 0) == (*fraction != 0):
+		return usageErrorf("sample needs either -n or -fraction")
+	case *n < 0:
+		return usageErrorf("-n must be positive, got %d", *n)
+	case *fraction < 0 || *fraction > 1:
+		return usageErrorf("-fraction must be between 0 and 1, got %v", *fraction)
+	case *by != "" && *n == 0:
+		return usageErrorf("-by needs -n")
+	}
+
+	var options []sample.Option
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			options = append(options, sample.WithSeed(*seed))
+		}
+	})
+
+	path, err := singleInput(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	readerOptions, err := rf.options()
+	if err != nil {
+		return err
+	}
+
+	return a.withInput(path, readerOptions, func(name string, cr *csv.CsvReader) error {
+		cw := csv.NewCsvWriter(a.stdout, rf.writerOptions()...)
+		switch {
+		case *by != "":
+			return sample.Stratified(cr, cw, splitNames(*by), *n, options...)
+		case *n != 0:
+			return sample.Reservoir(cr, cw, *n, options...)
+		}
+		return sample.Bernoulli(cr, cw, *fraction, options...)
+	})
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/jeremyseow/csv-parser/csv"
+)
+
+func runSelect(a *app, args []string) error {
+	fs := a.flagSet("select")
+	var rf readerFlags
+	rf.register(fs)
+	rf.registerOutput(fs)
+	spec := fs.String("c", "", "columns to keep, in order: names or 1-based indexes, name:newname to rename")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	columns, err := parseColumns(*spec)
+	if err != nil {
+		return err
+	}
+
+	path, err := singleInput(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	options, err := rf.options()
+	if err != nil {
+		return err
+	}
+	options = append(options, csv.WithProjection(columns...))
+
+	cw := csv.NewCsvWriter(a.stdout, rf.writerOptions()...)
+	err = a.withInput(path, options, func(name string, cr *csv.CsvReader) error {
+		if err := writeHeader(cr, cw); err != nil {
+			return err
+		}
+		return copyRecords(cr, cw, -1)
+	})
+
+	return flushWriter(cw, err)
+}
+
+// parseColumns reads a comma separated column list such as "id,3,email:contact".
+// Whole numbers are 1-based column indexes, anything else is a header name.
+func parseColumns(spec string) ([]csv.Column, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, usageErrorf("no columns given")
+	}
+
+	var columns []csv.Column
+	for _, item := range strings.Split(spec, ",") {
+		var column csv.Column
+		name, as, _ := strings.Cut(strings.TrimSpace(item), ":")
+		column.As = as
+
+		if index, err := strconv.Atoi(name); err == nil {
+			if index < 1 {
+				return nil, usageErrorf("column indexes start at 1, got %d", index)
+			}
+			column.Index = index - 1
+		} else if name == "" {
+			return nil, usageErrorf("empty column in %q", spec)
+		} else {
+			column.Name = name
+		}
+
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
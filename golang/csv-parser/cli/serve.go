@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jeremyseow/csv-parser/server"
+)
+
+func runServe(a *app, args []string) error {
+	fs := a.flagSet("serve")
+	addr := fs.String("addr", ":8080", "address to listen on")
+	maxSize := fs.String("max-size", "32M", "largest upload, before and after decompression")
+	timeout := fs.Duration("timeout", time.Minute, "time limit of a request")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	size, err := parseSize(*maxSize)
+	if err != nil {
+		return err
+	}
+	if *timeout <= 0 {
+		return usageErrorf("-timeout must be positive, got %v", *timeout)
+	}
+	if fs.NArg() > 0 {
+		return usageErrorf("serve takes no files")
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           server.NewServer(server.WithMaxSize(size), server.WithTimeout(*timeout)),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 1)
+	go func() {
+		fmt.Fprintf(a.stderr, "listening on %s\n", *addr)
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	// let the requests in flight finish
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/jeremyseow/csv-parser/extsort"
+)
+
+func runSort(a *app, args []string) error {
+	fs := a.flagSet("sort")
+	var rf readerFlags
+	rf.register(fs)
+	rf.registerOutput(fs)
+	spec := fs.String("k", "", "sort keys, e.g. country,amount:number:desc,joined:date; types are string, number and date")
+	memory := fs.String("memory", "256M", "memory budget for each sorted run, e.g. 512M or 2G")
+	tempDir := fs.String("tmp", "", "directory for the sorted runs, the system temp dir by default")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	keys, err := extsort.ParseKeys(*spec)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+
+	memoryLimit, err := parseSize(*memory)
+	if err != nil {
+		return err
+	}
+
+	path, err := singleInput(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	options, err := rf.options()
+	if err != nil {
+		return err
+	}
+
+	return a.withInput(path, options, func(name string, cr *csv.CsvReader) error {
+		return extsort.Sort(cr, csv.NewCsvWriter(a.stdout, rf.writerOptions()...), keys,
+			extsort.WithMemoryLimit(memoryLimit),
+			extsort.WithTempDir(*tempDir))
+	})
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/jeremyseow/csv-parser/split"
+)
+
+func runSplit(a *app, args []string) error {
+	fs := a.flagSet("split")
+	var rf readerFlags
+	rf.register(fs)
+	rf.registerOutput(fs)
+	rows := fs.Int("rows", 0, "most records per file")
+	size := fs.String("bytes", "", "most bytes per file, header included, e.g. 100M")
+	by := fs.String("by", "", "column whose values each get their own files")
+	dir := fs.String("dir", ".", "directory to write the files to")
+	prefix := fs.String("prefix", "", "file name prefix, the input file name by default")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var options []split.Option
+	if *rows < 0 {
+		return usageErrorf("-rows must be positive, got %d", *rows)
+	} else if *rows > 0 {
+		options = append(options, split.WithMaxRows(*rows))
+	}
+	if *size != "" {
+		maxBytes, err := parseSize(*size)
+		if err != nil {
+			return err
+		}
+		options = append(options, split.WithMaxBytes(maxBytes))
+	}
+	if *by != "" {
+		options = append(options, split.WithColumn(*by))
+	}
+	if len(options) == 0 {
+		return usageErrorf("split needs -rows, -bytes or -by")
+	}
+	options = append(options, split.WithWriterOptions(rf.writerOptions()...))
+
+	path, err := singleInput(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	namePrefix := *prefix
+	if namePrefix == "" {
+		namePrefix = "part"
+		if path != "-" {
+			namePrefix = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+	}
+
+	readerOptions, err := rf.options()
+	if err != nil {
+		return err
+	}
+
+	return a.withInput(path, readerOptions, func(name string, cr *csv.CsvReader) error {
+		paths, err := split.Split(cr, *dir, namePrefix, options...)
+		for _, p := range paths {
+			fmt.Fprintln(a.stdout, p)
+		}
+		return err
+	})
+}
@@ -0,0 +1,169 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jeremyseow/csv-parser/csv"
+)
+
+var errUnsupportedFormat = errors.New("unsupported format")
+
+type Format string
+
+const (
+	FormatCSV      Format = "csv"
+	FormatJSON     Format = "json"
+	FormatNDJSON   Format = "ndjson"
+	FormatTSV      Format = "tsv"
+	FormatMarkdown Format = "markdown"
+	FormatTable    Format = "table"
+)
+
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(name)) {
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	case FormatNDJSON, "jsonl":
+		return FormatNDJSON, nil
+	case FormatTSV:
+		return FormatTSV, nil
+	case FormatMarkdown, "md":
+		return FormatMarkdown, nil
+	case FormatTable, "ascii":
+		return FormatTable, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", errUnsupportedFormat, name)
+}
+
+type converter struct {
+	inferTypes    bool
+	writerOptions []csv.WriterOption
+}
+
+type Option func(*converter)
+
+// WithInferTypes writes numbers, booleans and empty fields as JSON numbers, booleans and null
+func WithInferTypes(inferTypes bool) Option {
+	return func(c *converter) {
+		c.inferTypes = inferTypes
+	}
+}
+
+// WithCsvWriterOptions configures the writer used for FormatCSV output
+func WithCsvWriterOptions(writerOptions ...csv.WriterOption) Option {
+	return func(c *converter) {
+		c.writerOptions = writerOptions
+	}
+}
+
+// recordWriter is implemented by every output format
+type recordWriter interface {
+	writeHeader(header []string) error
+	writeRecord(record []string) error
+	close() error
+}
+
+// Convert streams every record of r to w in the given format. The header, when r has one,
+// names the JSON keys and table columns, otherwise they are named col1, col2 and so on.
+func Convert(r csv.RecordReader, w io.Writer, format Format, options ...Option) error {
+	c := &converter{}
+	for _, op := range options {
+		op(c)
+	}
+
+	rw, err := c.newRecordWriter(w, format)
+	if err != nil {
+		return err
+	}
+
+	header, err := r.Header()
+	if err != nil {
+		return err
+	}
+
+	headerWritten := false
+	for {
+		record, err := r.ReadRecord()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if !headerWritten {
+			headerWritten = true
+			// csv and tsv can do without a header, the other formats need column names
+			if header == nil && format != FormatCSV && format != FormatTSV {
+				header = columnNames(len(record))
+			}
+			if header != nil {
+				if err := rw.writeHeader(header); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := rw.writeRecord(record); err != nil {
+			return err
+		}
+	}
+
+	if !headerWritten && header != nil {
+		if err := rw.writeHeader(header); err != nil {
+			return err
+		}
+	}
+
+	return rw.close()
+}
+
+func (c *converter) newRecordWriter(w io.Writer, format Format) (recordWriter, error) {
+	switch format {
+	case FormatCSV:
+		return &csvRecordWriter{writer: csv.NewCsvWriter(w, c.writerOptions...)}, nil
+	case FormatJSON:
+		return newJSONWriter(w, false, c.inferTypes), nil
+	case FormatNDJSON:
+		return newJSONWriter(w, true, c.inferTypes), nil
+	case FormatTSV:
+		return newTSVWriter(w), nil
+	case FormatMarkdown:
+		return newMarkdownWriter(w), nil
+	case FormatTable:
+		return newTableWriter(w), nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", errUnsupportedFormat, format)
+}
+
+func columnNames(n int) []string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = "col" + strconv.Itoa(i+1)
+	}
+	return names
+}
+
+type csvRecordWriter struct {
+	writer *csv.CsvWriter
+}
+
+func (cw *csvRecordWriter) writeHeader(header []string) error {
+	return cw.writer.Write(header)
+}
+
+func (cw *csvRecordWriter) writeRecord(record []string) error {
+	return cw.writer.Write(record)
+}
+
+func (cw *csvRecordWriter) close() error {
+	return cw.writer.Flush()
+}
@@ -0,0 +1,143 @@
+package convert
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvert(t *testing.T) {
+	input := "id,name,active\n1,\"Tan, \"\"Ah\"\" Kow\",true\n02,Li|Mei,\n"
+
+	testCases := []struct {
+		name     string
+		input    string
+		noHeader bool
+		format   Format
+		options  []Option
+		expected string
+	}{
+		{
+			name:     "json",
+			input:    input,
+			format:   FormatJSON,
+			expected: "[\n{\"id\":\"1\",\"name\":\"Tan, \\\"Ah\\\" Kow\",\"active\":\"true\"},\n{\"id\":\"02\",\"name\":\"Li|Mei\",\"active\":\"\"}\n]\n",
+		},
+		{
+			name:     "json with inferred types",
+			input:    input,
+			format:   FormatJSON,
+			options:  []Option{WithInferTypes(true)},
+			expected: "[\n{\"id\":1,\"name\":\"Tan, \\\"Ah\\\" Kow\",\"active\":true},\n{\"id\":\"02\",\"name\":\"Li|Mei\",\"active\":null}\n]\n",
+		},
+		{
+			name:     "empty json",
+			input:    "id,name\n",
+			format:   FormatJSON,
+			expected: "[]\n",
+		},
+		{
+			name:     "ndjson without header",
+			input:    "a,1\nb,2\n",
+			noHeader: true,
+			format:   FormatNDJSON,
+			options:  []Option{WithInferTypes(true)},
+			expected: "{\"col1\":\"a\",\"col2\":1}\n{\"col1\":\"b\",\"col2\":2}\n",
+		},
+		{
+			name:     "tsv",
+			input:    "a,b\n\"x\ty\",\"1\n2\"\n",
+			format:   FormatTSV,
+			expected: "a\tb\nx\\ty\t1\\n2\n",
+		},
+		{
+			name:     "markdown",
+			input:    input,
+			format:   FormatMarkdown,
+			expected: "| id | name | active |\n| --- | --- | --- |\n| 1 | Tan, \"Ah\" Kow | true |\n| 02 | Li\\|Mei |  |\n",
+		},
+		{
+			name:     "ascii table",
+			input:    "id,name\n1,Zoë\n22,Al\n",
+			format:   FormatTable,
+			expected: "+----+------+\n| id | name |\n+----+------+\n| 1  | Zoë  |\n| 22 | Al   |\n+----+------+\n",
+		},
+		{
+			name:     "csv",
+			input:    input,
+			format:   FormatCSV,
+			options:  []Option{WithCsvWriterOptions(csv.WithWriterDelimiter(';'))},
+			expected: "id;name;active\n1;\"Tan, \"\"Ah\"\" Kow\";true\n02;Li|Mei;\n",
+		},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var sb strings.Builder
+			reader := csv.NewCsvReader(strings.NewReader(currTestCase.input), csv.WithHeader(!currTestCase.noHeader))
+			err := Convert(reader, &sb, currTestCase.format, currTestCase.options...)
+			assert.NoError(t, err)
+			assert.Equal(t, currTestCase.expected, sb.String())
+		})
+	}
+}
+
+func TestFromNDJSON(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+		err      error
+	}{
+		{
+			name:     "keys in first object order",
+			input:    "{\"b\":\"x,y\",\"a\":1}\n{\"a\":2.5,\"b\":null}\n{\"b\":true}\n",
+			expected: "b,a\n\"x,y\",1\n,2.5\ntrue,\n",
+		},
+		{
+			name:     "nested values stay json",
+			input:    "{\"a\":{\"x\": [1, 2]}}\n",
+			expected: "a\n\"{\"\"x\"\":[1,2]}\"\n",
+		},
+		{
+			name:  "unknown key",
+			input: "{\"a\":1}\n{\"b\":2}\n",
+			err:   errUnknownKey,
+		},
+		{
+			name:     "later objects may be empty",
+			input:    "{\"a\":1}\n{}\n",
+			expected: "a\n1\n\"\"\n",
+		},
+		{
+			name:  "empty first object",
+			input: "{}\n{\"a\":1}\n{\"a\":2}\n",
+			err:   errNoKeys,
+		},
+		{
+			name:  "not an object",
+			input: "[1,2]\n",
+			err:   errNotAnObject,
+		},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var sb strings.Builder
+			err := FromNDJSON(strings.NewReader(currTestCase.input), csv.NewCsvWriter(&sb))
+			assert.True(t, errors.Is(err, currTestCase.err))
+			if currTestCase.err == nil {
+				assert.Equal(t, currTestCase.expected, sb.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package convert
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jeremyseow/csv-parser/csv"
+)
+
+var (
+	errNotAnObject = errors.New("expected a json object")
+	errUnknownKey  = errors.New("key missing from the first object")
+	errNoKeys      = errors.New("the first object has no keys to make a header of")
+)
+
+var jsonNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+type jsonWriter struct {
+	writer     *bufio.Writer
+	ndjson     bool
+	inferTypes bool
+	keys       [][]byte
+	count      int
+}
+
+func newJSONWriter(w io.Writer, ndjson, inferTypes bool) *jsonWriter {
+	return &jsonWriter{
+		writer:     bufio.NewWriter(w),
+		ndjson:     ndjson,
+		inferTypes: inferTypes,
+	}
+}
+
+func (jw *jsonWriter) writeHeader(header []string) error {
+	jw.keys = make([][]byte, len(header))
+	for i, name := range header {
+		jw.keys[i] = AppendJSONString(nil, name)
+	}
+	return nil
+}
+
+func (jw *jsonWriter) writeRecord(record []string) error {
+	if !jw.ndjson {
+		if jw.count == 0 {
+			jw.writer.WriteString("[\n")
+		} else {
+			jw.writer.WriteString(",\n")
+		}
+	}
+	jw.count++
+
+	// records shorter than the header get nulls, longer ones get extra colN keys
+	numFields := len(record)
+	if len(jw.keys) > numFields {
+		numFields = len(jw.keys)
+	}
+
+	buf := []byte{'{'}
+	for i := 0; i < numFields; i++ {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+
+		if i < len(jw.keys) {
+			buf = append(buf, jw.keys[i]...)
+		} else {
+			buf = AppendJSONString(buf, fmt.Sprintf("col%d", i+1))
+		}
+		buf = append(buf, ':')
+
+		if i >= len(record) {
+			buf = append(buf, "null"...)
+		} else {
+			buf = jw.appendValue(buf, record[i])
+		}
+	}
+	buf = append(buf, '}')
+
+	if jw.ndjson {
+		buf = append(buf, '\n')
+	}
+	_, err := jw.writer.Write(buf)
+	return err
+}
+
+func (jw *jsonWriter) close() error {
+	if !jw.ndjson {
+		if jw.count == 0 {
+			jw.writer.WriteString("[]\n")
+		} else {
+			jw.writer.WriteString("\n]\n")
+		}
+	}
+	return jw.writer.Flush()
+}
+
+func (jw *jsonWriter) appendValue(buf []byte, value string) []byte {
+	if !jw.inferTypes {
+		return AppendJSONString(buf, value)
+	}
+
+	switch {
+	case value == "":
+		return append(buf, "null"...)
+	case strings.EqualFold(value, "true"):
+		return append(buf, "true"...)
+	case strings.EqualFold(value, "false"):
+		return append(buf, "false"...)
+	case jsonNumber.MatchString(value):
+		return append(buf, value...)
+	}
+
+	return AppendJSONString(buf, value)
+}
+
+// AppendJSONString appends s to buf as a quoted JSON string
+func AppendJSONString(buf []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+
+	buf = append(buf, '"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			buf = append(buf, '\\', byte(r))
+		case r == '\n':
+			buf = append(buf, '\\', 'n')
+		case r == '\r':
+			buf = append(buf, '\\', 'r')
+		case r == '\t':
+			buf = append(buf, '\\', 't')
+		case r < 0x20:
+			buf = append(buf, '\\', 'u', '0', '0', hex[r>>4], hex[r&0xF])
+		default:
+			// invalid utf-8 comes out of the range loop as utf8.RuneError
+			buf = utf8.AppendRune(buf, r)
+		}
+	}
+	return append(buf, '"')
+}
+
+// FromNDJSON converts newline delimited JSON objects to csv. The keys of the first object
+// become the header, in the order they appear, so it cannot be empty; a later object with
+// other keys is an error.
+// Strings are written as they are, null as an empty field and anything else as compact JSON.
+func FromNDJSON(r io.Reader, w *csv.CsvWriter) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	var header []string
+	var index map[string]int
+	for objectNum := 1; ; objectNum++ {
+		keys, values, err := decodeObject(dec)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("object %d: %w", objectNum, err)
+		}
+
+		if header == nil {
+			if len(keys) == 0 {
+				return fmt.Errorf("object %d: %w", objectNum, errNoKeys)
+			}
+			header = keys
+			index = make(map[string]int, len(keys))
+			for i, key := range keys {
+				index[key] = i
+			}
+			if err := w.Write(header); err != nil {
+				return err
+			}
+		}
+
+		record := make([]string, len(header))
+		for i, key := range keys {
+			pos, ok := index[key]
+			if !ok {
+				return fmt.Errorf("object %d: %w: %q", objectNum, errUnknownKey, key)
+			}
+			record[pos] = values[i]
+		}
+
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+func decodeObject(dec *json.Decoder) ([]string, []string, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return nil, nil, errNotAnObject
+	}
+
+	var keys, values []string
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, _ := token.(string)
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, nil, err
+		}
+
+		value, err := rawToField(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+
+	// closing brace
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+
+	return keys, values, nil
+}
+
+func rawToField(raw json.RawMessage) (string, error) {
+	switch {
+	case bytes.Equal(raw, []byte("null")):
+		return "", nil
+	case len(raw) > 0 && raw[0] == '"':
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return s, err
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return "", err
+	}
+	return compact.String(), nil
+}
@@ -0,0 +1,163 @@
+package convert
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// tsv has no quoting, so tabs, newlines and backslashes are escaped with a backslash instead
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+type tsvWriter struct {
+	writer *bufio.Writer
+}
+
+func newTSVWriter(w io.Writer) *tsvWriter {
+	return &tsvWriter{writer: bufio.NewWriter(w)}
+}
+
+func (tw *tsvWriter) writeHeader(header []string) error {
+	return tw.writeRecord(header)
+}
+
+func (tw *tsvWriter) writeRecord(record []string) error {
+	for i, field := range record {
+		if i > 0 {
+			tw.writer.WriteByte('\t')
+		}
+		tsvEscaper.WriteString(tw.writer, field)
+	}
+	return tw.writer.WriteByte('\n')
+}
+
+func (tw *tsvWriter) close() error {
+	return tw.writer.Flush()
+}
+
+var markdownEscaper = strings.NewReplacer(`|`, `\|`, "\r\n", "<br>", "\n", "<br>")
+
+// markdownWriter streams a pipe table, the columns are not padded so that nothing has to be buffered
+type markdownWriter struct {
+	writer    *bufio.Writer
+	numFields int
+}
+
+func newMarkdownWriter(w io.Writer) *markdownWriter {
+	return &markdownWriter{writer: bufio.NewWriter(w)}
+}
+
+func (mw *markdownWriter) writeHeader(header []string) error {
+	mw.numFields = len(header)
+	mw.writeRow(header)
+
+	mw.writer.WriteByte('|')
+	for range header {
+		mw.writer.WriteString(" --- |")
+	}
+	return mw.writer.WriteByte('\n')
+}
+
+func (mw *markdownWriter) writeRecord(record []string) error {
+	return mw.writeRow(record)
+}
+
+func (mw *markdownWriter) writeRow(row []string) error {
+	mw.writer.WriteByte('|')
+	for i := 0; i < len(row) || i < mw.numFields; i++ {
+		mw.writer.WriteByte(' ')
+		if i < len(row) {
+			markdownEscaper.WriteString(mw.writer, row[i])
+		}
+		mw.writer.WriteString(" |")
+	}
+	return mw.writer.WriteByte('\n')
+}
+
+func (mw *markdownWriter) close() error {
+	return mw.writer.Flush()
+}
+
+// keeps every table row on a single line
+var tableCellReplacer = strings.NewReplacer("\r\n", " ", "\n", " ", "\t", " ")
+
+// tableWriter draws an aligned ascii table. Column widths depend on every row,
+// so unlike the other formats it holds all the records until close.
+type tableWriter struct {
+	writer *bufio.Writer
+	header []string
+	rows   [][]string
+	widths []int
+}
+
+func newTableWriter(w io.Writer) *tableWriter {
+	return &tableWriter{writer: bufio.NewWriter(w)}
+}
+
+func (tw *tableWriter) writeHeader(header []string) error {
+	tw.header = tw.addRow(header)
+	return nil
+}
+
+func (tw *tableWriter) writeRecord(record []string) error {
+	tw.rows = append(tw.rows, tw.addRow(record))
+	return nil
+}
+
+func (tw *tableWriter) addRow(row []string) []string {
+	cells := make([]string, len(row))
+	for i, field := range row {
+		cells[i] = tableCellReplacer.Replace(field)
+
+		width := utf8.RuneCountInString(cells[i])
+		if i >= len(tw.widths) {
+			tw.widths = append(tw.widths, width)
+		} else if width > tw.widths[i] {
+			tw.widths[i] = width
+		}
+	}
+	return cells
+}
+
+func (tw *tableWriter) close() error {
+	if tw.header == nil && len(tw.rows) == 0 {
+		return nil
+	}
+
+	tw.writeBorder()
+	if tw.header != nil {
+		tw.writeRow(tw.header)
+		tw.writeBorder()
+	}
+	for _, row := range tw.rows {
+		tw.writeRow(row)
+	}
+	tw.writeBorder()
+
+	return tw.writer.Flush()
+}
+
+func (tw *tableWriter) writeBorder() {
+	tw.writer.WriteByte('+')
+	for _, width := range tw.widths {
+		tw.writer.WriteString(strings.Repeat("-", width+2))
+		tw.writer.WriteByte('+')
+	}
+	tw.writer.WriteByte('\n')
+}
+
+func (tw *tableWriter) writeRow(row []string) {
+	tw.writer.WriteByte('|')
+	for i, width := range tw.widths {
+		cell := ""
+		if i < len(row) {
+			cell = row[i]
+		}
+		tw.writer.WriteByte(' ')
+		tw.writer.WriteString(cell)
+		tw.writer.WriteString(strings.Repeat(" ", width-utf8.RuneCountInString(cell)+1))
+		tw.writer.WriteByte('|')
+	}
+	tw.writer.WriteByte('\n')
+}
@@ -0,0 +1,208 @@
+package csv
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errUnknownConverter = errors.New("unknown converter")
+	errConverterType    = errors.New("converter of another type")
+	errConvert          = errors.New("cannot convert")
+)
+
+// Converter turns a field into a value of type V
+type Converter[V any] func(field string) (V, error)
+
+// Converters is a registry of named converters, such as converters picked from a config file
+type Converters struct {
+	converters map[string]any
+}
+
+// NewConverters returns a registry holding the converters of this package under the names
+// string, int, float, bool, time, currency, percent and excel_date
+func NewConverters() *Converters {
+	c := &Converters{converters: map[string]any{}}
+	RegisterConverter(c, "string", ConvertString)
+	RegisterConverter(c, "int", ConvertInt)
+	RegisterConverter(c, "float", ConvertFloat)
+	RegisterConverter(c, "bool", ConvertBool)
+	RegisterConverter(c, "time", ConvertTime)
+	RegisterConverter(c, "currency", ConvertCurrency)
+	RegisterConverter(c, "percent", ConvertPercent)
+	RegisterConverter(c, "excel_date", ConvertExcelDate)
+	return c
+}
+
+// RegisterConverter adds convert to c under name, replacing any converter of that name
+func RegisterConverter[V any](c *Converters, name string, convert Converter[V]) {
+	c.converters[name] = convert
+}
+
+// LookupConverter returns the converter registered under name, which must convert to V
+func LookupConverter[V any](c *Converters, name string) (Converter[V], error) {
+	registered, ok := c.converters[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errUnknownConverter, name)
+	}
+
+	convert, ok := registered.(Converter[V])
+	if !ok {
+		var zero V
+		return nil, fmt.Errorf("%w: %s does not convert to %T", errConverterType, name, zero)
+	}
+	return convert, nil
+}
+
+// Optional makes convert turn empty fields into the zero value of V
+func Optional[V any](convert Converter[V]) Converter[V] {
+	return func(field string) (V, error) {
+		if field == "" {
+			var zero V
+			return zero, nil
+		}
+		return convert(field)
+	}
+}
+
+func ConvertString(field string) (string, error) {
+	return field, nil
+}
+
+func ConvertInt(field string) (int64, error) {
+	return strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+}
+
+func ConvertFloat(field string) (float64, error) {
+	return strconv.ParseFloat(strings.TrimSpace(field), 64)
+}
+
+// ConvertBool accepts true, false, yes, no, 1 and 0 in any case
+func ConvertBool(field string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(field)) {
+	case "true", "yes", "1":
+		return true, nil
+	case "false", "no", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("not a boolean: %q", field)
+}
+
+// ConvertTime accepts the dates and timestamps ParseTime does
+func ConvertTime(field string) (time.Time, error) {
+	t, ok := ParseTime(strings.TrimSpace(field))
+	if !ok {
+		return time.Time{}, fmt.Errorf("not a date: %q", field)
+	}
+	return t, nil
+}
+
+// ConvertCurrency reads an amount such as "$1,234.56", "-€12", "(45.10)" or "12.50 USD" in
+// hundredths, 123456 for the first one. A currency symbol or a three letter code may come
+// before or after the amount, commas group thousands and parentheses mean a negative amount.
+// More than two decimals are refused rather than rounded.
+func ConvertCurrency(field string) (int64, error) {
+	s := strings.TrimSpace(field)
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+	if strings.HasPrefix(s, "-") {
+		negative = !negative
+		s = strings.TrimSpace(s[1:])
+	}
+	s = strings.TrimSpace(trimCurrency(s))
+	if strings.HasPrefix(s, "-") {
+		negative = !negative
+		s = s[1:]
+	}
+
+	whole, fraction, hasFraction := strings.Cut(s, ".")
+	if whole == "" || (hasFraction && (fraction == "" || len(fraction) > 2)) {
+		return 0, fmt.Errorf("not an amount: %q", field)
+	}
+	if groups := strings.Split(whole, ","); len(groups) > 1 {
+		for i, group := range groups {
+			if (i == 0 && (len(group) == 0 || len(group) > 3)) || (i > 0 && len(group) != 3) {
+				return 0, fmt.Errorf("not an amount: %q", field)
+			}
+		}
+		whole = strings.Join(groups, "")
+	}
+
+	digits := whole + (fraction + "00")[:2]
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("not an amount: %q", field)
+		}
+	}
+	cents, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("not an amount: %q", field)
+	}
+	if negative {
+		cents = -cents
+	}
+	return cents, nil
+}
+
+// trimCurrency removes a leading or trailing currency symbol or code
+func trimCurrency(s string) string {
+	for _, symbol := range []string{"$", "€", "£", "¥", "₹"} {
+		if strings.HasPrefix(s, symbol) {
+			return s[len(symbol):]
+		}
+		if strings.HasSuffix(s, symbol) {
+			return s[:len(s)-len(symbol)]
+		}
+	}
+
+	isCode := func(code string) bool {
+		return len(code) == 3 && strings.ToUpper(code) == code && strings.Trim(code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") == ""
+	}
+	if len(s) > 3 && isCode(s[:3]) {
+		return s[3:]
+	}
+	if len(s) > 3 && isCode(s[len(s)-3:]) {
+		return s[:len(s)-3]
+	}
+	return s
+}
+
+// ConvertPercent reads a percentage such as "12.5%" as the fraction 0.125
+func ConvertPercent(field string) (float64, error) {
+	s := strings.TrimSpace(field)
+	if !strings.HasSuffix(s, "%") {
+		return 0, fmt.Errorf("not a percentage: %q", field)
+	}
+
+	f, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(s, "%")), 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("not a percentage: %q", field)
+	}
+	return f / 100, nil
+}
+
+// ConvertExcelDate reads the serial dates of Excel, days since the end of 1899 with the time of
+// day as the fraction, to the nearest millisecond. Excel counts a February 29th 1900 that
+// never was, so serial 60 is refused and the days after it are shifted back.
+func ConvertExcelDate(field string) (time.Time, error) {
+	serial, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+	// 2958465 is December 31st 9999, the last date Excel has
+	if err != nil || !(serial >= 1 && serial < 2958466) || math.Floor(serial) == 60 {
+		return time.Time{}, fmt.Errorf("not an excel date: %q", field)
+	}
+
+	epoch := time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+	if serial < 60 {
+		epoch = epoch.AddDate(0, 0, 1)
+	}
+	days := math.Floor(serial)
+	millis := math.Round((serial - days) * 24 * 60 * 60 * 1000)
+	return epoch.AddDate(0, 0, int(days)).Add(time.Duration(millis) * time.Millisecond), nil
+}
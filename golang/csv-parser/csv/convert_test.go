@@ -0,0 +1,146 @@
+package csv
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertCurrency(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected int64
+		fails    bool
+	}{
+		{name: "plain", input: "12", expected: 1200},
+		{name: "cents", input: "12.5", expected: 1250},
+		{name: "symbol and thousands", input: "$1,234.56", expected: 123456},
+		{name: "negative before symbol", input: "-€12.00", expected: -1200},
+		{name: "negative after symbol", input: "£-3.10", expected: -310},
+		{name: "parentheses", input: "(1,045.10)", expected: -104510},
+		{name: "trailing code", input: " 12.50 USD ", expected: 1250},
+		{name: "leading code", input: "EUR 7", expected: 700},
+		{name: "trailing symbol", input: "99,99€", fails: true},
+		{name: "three decimals", input: "1.234", fails: true},
+		{name: "bad grouping", input: "12,34", fails: true},
+		{name: "letters", input: "twelve", fails: true},
+		{name: "empty", input: "", fails: true},
+		{name: "only a symbol", input: "$", fails: true},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := ConvertCurrency(currTestCase.input)
+			if currTestCase.fails {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, currTestCase.expected, actual)
+		})
+	}
+}
+
+func TestConvertPercent(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected float64
+		fails    bool
+	}{
+		{name: "whole", input: "50%", expected: 0.5},
+		{name: "decimal", input: "12.5%", expected: 0.125},
+		{name: "negative with space", input: " -3 % ", expected: -0.03},
+		{name: "no sign", input: "12.5", fails: true},
+		{name: "not a number", input: "abc%", fails: true},
+		{name: "infinite", input: "inf%", fails: true},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := ConvertPercent(currTestCase.input)
+			if currTestCase.fails {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.InDelta(t, currTestCase.expected, actual, 1e-12)
+		})
+	}
+}
+
+func TestConvertExcelDate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected time.Time
+		fails    bool
+	}{
+		{name: "first day", input: "1", expected: time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "before the leap bug", input: "59", expected: time.Date(1900, time.February, 28, 0, 0, 0, 0, time.UTC)},
+		{name: "after the leap bug", input: "61", expected: time.Date(1900, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "modern date", input: "45292", expected: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "time of day", input: "45292.75", expected: time.Date(2024, time.January, 1, 18, 0, 0, 0, time.UTC)},
+		{name: "last day", input: "2958465", expected: time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)},
+		{name: "leap bug", input: "60", fails: true},
+		{name: "zero", input: "0", fails: true},
+		{name: "too late", input: "2958466", fails: true},
+		{name: "not a number", input: "2024-01-01", fails: true},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := ConvertExcelDate(currTestCase.input)
+			if currTestCase.fails {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, currTestCase.expected, actual)
+		})
+	}
+}
+
+func TestConverters(t *testing.T) {
+	t.Parallel()
+
+	c := NewConverters()
+	convert, err := LookupConverter[int64](c, "currency")
+	assert.NoError(t, err)
+	cents, err := convert("$2.50")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(250), cents)
+
+	_, err = LookupConverter[float64](c, "currency")
+	assert.True(t, errors.Is(err, errConverterType), err)
+
+	_, err = LookupConverter[string](c, "upper")
+	assert.True(t, errors.Is(err, errUnknownConverter), err)
+
+	RegisterConverter(c, "yes_no", func(field string) (bool, error) {
+		return field == "Y", nil
+	})
+	yesNo, err := LookupConverter[bool](c, "yes_no")
+	assert.NoError(t, err)
+	yes, _ := yesNo("Y")
+	assert.True(t, yes)
+
+	optional := Optional(ConvertInt)
+	zero, err := optional("")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), zero)
+	_, err = optional("x")
+	assert.Error(t, err)
+}
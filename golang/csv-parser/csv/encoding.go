@@ -0,0 +1,124 @@
+package csv
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var errUnsupportedEncoding = errors.New("unsupported encoding")
+
+type Encoding string
+
+const (
+	EncodingUTF8    Encoding = "utf-8"
+	EncodingLatin1  Encoding = "latin1"
+	EncodingUTF16LE Encoding = "utf-16le"
+	EncodingUTF16BE Encoding = "utf-16be"
+)
+
+// ParseEncoding maps an encoding name such as "UTF8" or "iso-8859-1" to an Encoding
+func ParseEncoding(name string) (Encoding, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "utf-8", "utf8":
+		return EncodingUTF8, nil
+	case "latin1", "latin-1", "iso-8859-1", "iso8859-1":
+		return EncodingLatin1, nil
+	case "utf-16le", "utf16le":
+		return EncodingUTF16LE, nil
+	case "utf-16be", "utf16be":
+		return EncodingUTF16BE, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", errUnsupportedEncoding, name)
+}
+
+// decodingReader turns runes decoded from src into UTF-8 bytes
+type decodingReader struct {
+	src    *bufio.Reader
+	decode func(*bufio.Reader) (rune, error)
+	buf    []byte
+}
+
+func newDecodingReader(r io.Reader, encoding Encoding) io.Reader {
+	switch encoding {
+	case EncodingLatin1:
+		return &decodingReader{src: bufio.NewReader(r), decode: decodeLatin1}
+	case EncodingUTF16LE:
+		return &decodingReader{src: bufio.NewReader(r), decode: decodeUTF16(littleEndian)}
+	case EncodingUTF16BE:
+		return &decodingReader{src: bufio.NewReader(r), decode: decodeUTF16(bigEndian)}
+	}
+
+	return r
+}
+
+func (d *decodingReader) Read(p []byte) (int, error) {
+	for len(d.buf) < len(p) {
+		r, err := d.decode(d.src)
+		if err != nil {
+			if len(d.buf) == 0 {
+				return 0, err
+			}
+			break
+		}
+		d.buf = utf8.AppendRune(d.buf, r)
+	}
+
+	n := copy(p, d.buf)
+	d.buf = append(d.buf[:0], d.buf[n:]...)
+	return n, nil
+}
+
+func decodeLatin1(src *bufio.Reader) (rune, error) {
+	b, err := src.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	return rune(b), nil
+}
+
+func littleEndian(b []byte) uint16 {
+	return uint16(b[0]) | uint16(b[1])<<8
+}
+
+func bigEndian(b []byte) uint16 {
+	return uint16(b[0])<<8 | uint16(b[1])
+}
+
+func decodeUTF16(order func([]byte) uint16) func(*bufio.Reader) (rune, error) {
+	readUnit := func(src *bufio.Reader) (uint16, error) {
+		var unit [2]byte
+		n, err := io.ReadFull(src, unit[:])
+		if err == io.ErrUnexpectedEOF || (err == io.EOF && n > 0) {
+			return 0, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return 0, err
+		}
+		return order(unit[:]), nil
+	}
+
+	return func(src *bufio.Reader) (rune, error) {
+		unit, err := readUnit(src)
+		if err != nil {
+			return 0, err
+		}
+
+		r := rune(unit)
+		if !utf16.IsSurrogate(r) {
+			return r, nil
+		}
+
+		low, err := readUnit(src)
+		if err != nil {
+			return utf8.RuneError, nil
+		}
+		return utf16.DecodeRune(r, rune(low)), nil
+	}
+}
@@ -0,0 +1,464 @@
+package csv
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	errBadFixedWidthSpec = errors.New("bad fixed width column")
+	errFieldType         = errors.New("field does not match its type")
+)
+
+type Alignment string
+
+const (
+	AlignLeft  Alignment = "left"
+	AlignRight Alignment = "right"
+)
+
+// FixedWidthColumn is a column of a fixed width file. Start is the 1-based position of its
+// first character and Width its length in characters. Pad, a space by default, is trimmed from
+// the side the value is not aligned to. Fields are checked against Type, a TypeDate with a
+// Layout against that layout and otherwise against the formats ParseTime accepts; empty fields
+// always pass.
+type FixedWidthColumn struct {
+	Name   string
+	Start  int
+	Width  int
+	Align  Alignment
+	Pad    byte
+	Type   FieldType
+	Layout string
+}
+
+// ParseFixedWidthColumns reads a comma separated list of columns such as
+// "id:1:6:right:pad=0:int,name:7:20,joined:27:8:date=20060102". Each column is a name, start
+// and width followed by any of left, right, pad=C, string, int, float, date and date=LAYOUT.
+func ParseFixedWidthColumns(spec string) ([]FixedWidthColumn, error) {
+	var columns []FixedWidthColumn
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.Split(item, ":")
+		if len(parts) < 3 {
+			return nil, fmt.Errorf("%w: %q needs a name, start and width", errBadFixedWidthSpec, item)
+		}
+
+		start, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad start in %q", errBadFixedWidthSpec, item)
+		}
+		width, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad width in %q", errBadFixedWidthSpec, item)
+		}
+
+		column := FixedWidthColumn{Name: parts[0], Start: start, Width: width}
+		for _, part := range parts[3:] {
+			name, value, _ := strings.Cut(part, "=")
+			switch strings.ToLower(name) {
+			case "left":
+				column.Align = AlignLeft
+			case "right":
+				column.Align = AlignRight
+			case "pad":
+				if len(value) != 1 {
+					return nil, fmt.Errorf("%w: pad must be a single byte in %q", errBadFixedWidthSpec, item)
+				}
+				column.Pad = value[0]
+			case "string", "int", "float":
+				column.Type = FieldType(strings.ToLower(name))
+			case "date":
+				column.Type, column.Layout = TypeDate, value
+			default:
+				return nil, fmt.Errorf("%w: %q in %q", errBadFixedWidthSpec, part, item)
+			}
+		}
+		columns = append(columns, column)
+	}
+
+	return columns, validateFixedWidthColumns(columns)
+}
+
+func validateFixedWidthColumns(columns []FixedWidthColumn) error {
+	if len(columns) == 0 {
+		return fmt.Errorf("%w: no columns given", errBadFixedWidthSpec)
+	}
+
+	for _, column := range columns {
+		if column.Start < 1 || column.Width < 1 {
+			return fmt.Errorf("%w: %s starts at %d with width %d", errBadFixedWidthSpec, column.Name, column.Start, column.Width)
+		}
+		switch column.Align {
+		case "", AlignLeft, AlignRight:
+		default:
+			return fmt.Errorf("%w: %s has alignment %q", errBadFixedWidthSpec, column.Name, column.Align)
+		}
+		switch column.Type {
+		case "", TypeString, TypeInt, TypeFloat, TypeDate:
+		default:
+			return fmt.Errorf("%w: %s has type %q", errBadFixedWidthSpec, column.Name, column.Type)
+		}
+	}
+	return nil
+}
+
+type FixedWidthOption func(*FixedWidthReader)
+
+// WithSkipLines skips the first n lines, such as a header or a banner
+func WithSkipLines(n int) FixedWidthOption {
+	return func(reader *FixedWidthReader) {
+		reader.skipLines = n
+	}
+}
+
+// WithFixedWidthEncoding decodes the input from the given encoding to UTF-8 before reading
+func WithFixedWidthEncoding(encoding Encoding) FixedWidthOption {
+	return func(reader *FixedWidthReader) {
+		reader.encoding = encoding
+	}
+}
+
+// FixedWidthReader reads lines of fixed width columns as records, so that it can be used
+// wherever a RecordReader is expected. Lines shorter than the columns have empty trailing
+// fields, characters past the last column are ignored and blank lines are skipped.
+type FixedWidthReader struct {
+	columns   []FixedWidthColumn
+	skipLines int
+	encoding  Encoding
+	specErr   error
+
+	reader     *bufio.Reader
+	started    bool
+	line       int
+	recordLine int
+	runes      []rune
+	// pending holds the lines read ahead to guess the columns
+	pending []string
+}
+
+func NewFixedWidthReader(inputReader io.Reader, columns []FixedWidthColumn, readerOptions ...FixedWidthOption) *FixedWidthReader {
+	fr := &FixedWidthReader{
+		columns:  columns,
+		encoding: EncodingUTF8,
+	}
+
+	for _, op := range readerOptions {
+		op(fr)
+	}
+
+	fr.specErr = validateFixedWidthColumns(columns)
+	fr.reader = bufio.NewReader(newDecodingReader(inputReader, fr.encoding))
+
+	return fr
+}
+
+// NewGuessingFixedWidthReader reads up to sampleLines lines ahead to guess the columns with
+// GuessFixedWidthColumns, then reads the whole input, sample included, with them. With
+// hasHeader the first line names the columns and is skipped. The last column takes the rest
+// of every line.
+func NewGuessingFixedWidthReader(inputReader io.Reader, sampleLines int, hasHeader bool, readerOptions ...FixedWidthOption) *FixedWidthReader {
+	fr := NewFixedWidthReader(inputReader, nil, readerOptions...)
+	if hasHeader {
+		fr.skipLines = max(fr.skipLines, 1)
+	}
+
+	for len(fr.pending) < sampleLines {
+		line, err := fr.nextLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fr.specErr = err
+			return fr
+		}
+		fr.pending = append(fr.pending, line)
+	}
+
+	fr.columns = GuessFixedWidthColumns(fr.pending, hasHeader)
+	fr.specErr = validateFixedWidthColumns(fr.columns)
+	if fr.specErr == nil {
+		// lines past the sample may be longer
+		last := &fr.columns[len(fr.columns)-1]
+		last.Width = math.MaxInt32 - last.Start
+	}
+	return fr
+}
+
+// Columns returns the columns the reader was created with or guessed
+func (fr *FixedWidthReader) Columns() []FixedWidthColumn {
+	return fr.columns
+}
+
+// Header returns the column names
+func (fr *FixedWidthReader) Header() ([]string, error) {
+	if fr.specErr != nil {
+		return nil, fr.specErr
+	}
+
+	header := make([]string, len(fr.columns))
+	for i, column := range fr.columns {
+		header[i] = column.Name
+	}
+	return header, nil
+}
+
+// ReadRecord reads the next record, returning io.EOF once the input is exhausted. A field
+// that does not match its type is a *ParseError, after which the next line can be read.
+func (fr *FixedWidthReader) ReadRecord() ([]string, error) {
+	if fr.specErr != nil {
+		return nil, fr.specErr
+	}
+
+	for {
+		line, err := fr.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if fr.line <= fr.skipLines || strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		fr.recordLine = fr.line
+		return fr.parseLine(line)
+	}
+}
+
+// Read reads all the remaining records
+func (fr *FixedWidthReader) Read() ([][]string, error) {
+	records := [][]string{}
+	for {
+		record, err := fr.ReadRecord()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+// Line returns the line the last record read started on
+func (fr *FixedWidthReader) Line() int {
+	return fr.recordLine
+}
+
+func (fr *FixedWidthReader) readLine() (string, error) {
+	var line string
+	if len(fr.pending) > 0 {
+		line, fr.pending = fr.pending[0], fr.pending[1:]
+	} else {
+		var err error
+		if line, err = fr.nextLine(); err != nil {
+			return "", err
+		}
+	}
+
+	fr.line++
+	return line, nil
+}
+
+func (fr *FixedWidthReader) nextLine() (string, error) {
+	line, err := fr.reader.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return "", io.EOF
+	}
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+
+	if !fr.started {
+		fr.started = true
+		line = strings.TrimPrefix(line, string(utf8BOM))
+	}
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r"), nil
+}
+
+func (fr *FixedWidthReader) parseLine(line string) ([]string, error) {
+	// positions are in characters, which are only bytes when the line is ascii
+	ascii := true
+	for i := 0; i < len(line); i++ {
+		if line[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	length := len(line)
+	if !ascii {
+		fr.runes = append(fr.runes[:0], []rune(line)...)
+		length = len(fr.runes)
+	}
+
+	record := make([]string, len(fr.columns))
+	for i, column := range fr.columns {
+		start := min(column.Start-1, length)
+		end := min(start+column.Width, length)
+
+		value := line[start:end]
+		if !ascii {
+			value = string(fr.runes[start:end])
+		}
+
+		value = trimPadding(value, column)
+		if !matchesType(value, column) {
+			return nil, &ParseError{
+				StartLine: fr.line,
+				Line:      fr.line,
+				Column:    column.Start,
+				Err:       fmt.Errorf("%w: %s is %s, got %q", errFieldType, column.Name, column.Type, value),
+			}
+		}
+		record[i] = value
+	}
+	return record, nil
+}
+
+func trimPadding(value string, column FixedWidthColumn) string {
+	pad := column.Pad
+	if pad == 0 {
+		pad = ' '
+	}
+
+	if column.Align == AlignRight {
+		trimmed := strings.TrimLeft(value, string(pad))
+		// zero padded numbers keep their last zero
+		if trimmed == "" && pad == '0' && value != "" {
+			return "0"
+		}
+		// the spaces of a value not quite filling its column are not part of it either
+		return strings.TrimRight(trimmed, " ")
+	}
+
+	return strings.TrimLeft(strings.TrimRight(value, string(pad)), " ")
+}
+
+func matchesType(value string, column FixedWidthColumn) bool {
+	if value == "" {
+		return true
+	}
+
+	switch column.Type {
+	case TypeInt:
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err == nil
+	case TypeFloat:
+		_, err := strconv.ParseFloat(value, 64)
+		return err == nil
+	case TypeDate:
+		if column.Layout != "" {
+			_, err := time.Parse(column.Layout, value)
+			return err == nil
+		}
+		_, ok := ParseTime(value)
+		return ok
+	}
+	return true
+}
+
+// GuessFixedWidthColumns guesses the columns of sample lines of a fixed width file. Columns
+// start where text starts after a position that is blank on every line, and are right aligned
+// when most of their values end at the same position. With hasHeader the first line names
+// the columns, otherwise they are named col1, col2 and so on.
+func GuessFixedWidthColumns(sample []string, hasHeader bool) []FixedWidthColumn {
+	var lines [][]rune
+	width := 0
+	for _, line := range sample {
+		runes := []rune(strings.TrimRight(line, "\r\n"))
+		if strings.TrimSpace(string(runes)) == "" {
+			continue
+		}
+		lines = append(lines, runes)
+		width = max(width, len(runes))
+	}
+
+	blank := make([]bool, width)
+	for i := range blank {
+		blank[i] = true
+		for _, line := range lines {
+			if i < len(line) && line[i] != ' ' {
+				blank[i] = false
+				break
+			}
+		}
+	}
+
+	var starts []int
+	for i := range blank {
+		if !blank[i] && (i == 0 || blank[i-1]) {
+			starts = append(starts, i)
+		}
+	}
+
+	columns := make([]FixedWidthColumn, len(starts))
+	for i, start := range starts {
+		end := width
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+
+		columns[i] = FixedWidthColumn{
+			Name:  fmt.Sprintf("col%d", i+1),
+			Start: start + 1,
+			Width: end - start,
+			Align: guessAlignment(lines, start, end, hasHeader),
+		}
+		if hasHeader && len(lines) > 0 {
+			if name := strings.TrimSpace(string(lines[0][min(start, len(lines[0])):min(end, len(lines[0]))])); name != "" {
+				columns[i].Name = name
+			}
+		}
+	}
+	return columns
+}
+
+// guessAlignment compares how many values start at the same position with how many end
+// at the same position
+func guessAlignment(lines [][]rune, start, end int, hasHeader bool) Alignment {
+	if hasHeader && len(lines) > 0 {
+		lines = lines[1:]
+	}
+
+	starts, ends := map[int]int{}, map[int]int{}
+	for _, line := range lines {
+		value := line[min(start, len(line)):min(end, len(line))]
+		first, last := -1, -1
+		for i, r := range value {
+			if r != ' ' {
+				if first < 0 {
+					first = i
+				}
+				last = i
+			}
+		}
+		if first >= 0 {
+			starts[first]++
+			ends[last]++
+		}
+	}
+
+	if mostCommon(ends) > mostCommon(starts) {
+		return AlignRight
+	}
+	return AlignLeft
+}
+
+func mostCommon(counts map[int]int) int {
+	most := 0
+	for _, count := range counts {
+		most = max(most, count)
+	}
+	return most
+}
@@ -0,0 +1,152 @@
+package csv
+
+import (
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixedWidthReader(t *testing.T) {
+	columns, err := ParseFixedWidthColumns("id:1:5:right:pad=0:int, name:6:8, amount:14:7:right:float, joined:21:8:date=20060102")
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		input    string
+		options  []FixedWidthOption
+		expected [][]string
+		err      error
+	}{
+		{
+			name:     "columns",
+			input:    "00001alice     12.5020240301\r\n00000bob          -320231231\n",
+			expected: [][]string{{"1", "alice", "12.50", "20240301"}, {"0", "bob", "-3", "20231231"}},
+		},
+		{
+			name:     "short lines and blank lines",
+			input:    "00042carol\n\n   \n00007dave       1\n",
+			expected: [][]string{{"42", "carol", "", ""}, {"7", "dave", "1", ""}},
+		},
+		{
+			name:     "characters, not bytes",
+			input:    "00003zoë          7\n",
+			expected: [][]string{{"3", "zoë", "7", ""}},
+		},
+		{
+			name:     "skip lines",
+			input:    "ID   NAME    AMOUNT JOINED\n00001alice\n",
+			options:  []FixedWidthOption{WithSkipLines(1)},
+			expected: [][]string{{"1", "alice", "", ""}},
+		},
+		{
+			name:     "latin1",
+			input:    "00001jos\xe9\n",
+			options:  []FixedWidthOption{WithFixedWidthEncoding(EncodingLatin1)},
+			expected: [][]string{{"1", "josé", "", ""}},
+		},
+		{name: "bad int", input: "0000xalice\n", err: errFieldType},
+		{name: "bad date", input: "00001alice        1.020241301\n", err: errFieldType},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			reader := NewFixedWidthReader(strings.NewReader(currTestCase.input), columns, currTestCase.options...)
+			header, err := reader.Header()
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"id", "name", "amount", "joined"}, header)
+
+			records, err := reader.Read()
+			if currTestCase.err != nil {
+				assert.True(t, errors.Is(err, currTestCase.err), err)
+				var parseErr *ParseError
+				assert.True(t, errors.As(err, &parseErr))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, currTestCase.expected, records)
+		})
+	}
+}
+
+func TestFixedWidthReaderContinues(t *testing.T) {
+	columns := []FixedWidthColumn{{Name: "n", Start: 1, Width: 3, Type: TypeInt}}
+	reader := NewFixedWidthReader(strings.NewReader("1\nx\n\n3\n"), columns)
+
+	var records [][]string
+	var lines []int
+	for {
+		record, err := reader.ReadRecord()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *ParseError
+			assert.True(t, errors.As(err, &parseErr))
+			assert.Equal(t, 2, parseErr.Line)
+			continue
+		}
+		records = append(records, record)
+		lines = append(lines, reader.Line())
+	}
+	assert.Equal(t, [][]string{{"1"}, {"3"}}, records)
+	assert.Equal(t, []int{1, 4}, lines)
+}
+
+func TestParseFixedWidthColumns(t *testing.T) {
+	for _, spec := range []string{"", "a:1", "a:0:3", "a:1:0", "a:1:3:middle", "a:1:3:pad=ab"} {
+		_, err := ParseFixedWidthColumns(spec)
+		assert.True(t, errors.Is(err, errBadFixedWidthSpec), spec)
+	}
+
+	_, err := NewFixedWidthReader(strings.NewReader("x\n"), nil).ReadRecord()
+	assert.True(t, errors.Is(err, errBadFixedWidthSpec))
+}
+
+func TestGuessFixedWidthColumns(t *testing.T) {
+	sample := []string{
+		"ID  NAME        AMOUNT  CITY",
+		"1   alice        12.50  Singapore",
+		"22  bob         100.00  Kuala Lumpur",
+		"333 carol         3.00  Jakarta",
+	}
+
+	assert.Equal(t, []FixedWidthColumn{
+		{Name: "ID", Start: 1, Width: 4, Align: AlignLeft},
+		{Name: "NAME", Start: 5, Width: 12, Align: AlignLeft},
+		{Name: "AMOUNT", Start: 17, Width: 8, Align: AlignRight},
+		{Name: "CITY", Start: 25, Width: 12, Align: AlignLeft},
+	}, GuessFixedWidthColumns(sample, true))
+
+	columns := GuessFixedWidthColumns(sample[1:], false)
+	assert.Equal(t, "col1", columns[0].Name)
+
+	records, err := NewFixedWidthReader(strings.NewReader(strings.Join(sample, "\n")), columns).Read()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"22", "bob", "100.00", "Kuala Lumpur"}, records[2])
+}
+
+func TestGuessingFixedWidthReader(t *testing.T) {
+	input := "\xef\xbb\xbfID NAME\n1  alice\n22 bob\n333 carol\n"
+
+	reader := NewGuessingFixedWidthReader(strings.NewReader(input), 2, true)
+	assert.Equal(t, []FixedWidthColumn{
+		{Name: "ID", Start: 1, Width: 3, Align: AlignLeft},
+		{Name: "NAME", Start: 4, Width: math.MaxInt32 - 4, Align: AlignLeft},
+	}, reader.Columns())
+
+	header, err := reader.Header()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ID", "NAME"}, header)
+
+	records, err := reader.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"1", "alice"}, {"22", "bob"}, {"333", "carol"}}, records)
+	assert.Equal(t, 4, reader.Line())
+}
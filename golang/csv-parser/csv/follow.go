@@ -0,0 +1,203 @@
+package csv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+var (
+	// ErrPartialRecord is sent by Follow when a file was truncated or rotated while the end
+	// of its last record was still to be written; that record is dropped
+	ErrPartialRecord = errors.New("partial record dropped")
+
+	errFileReplaced = errors.New("file truncated or rotated")
+)
+
+// FollowedRecord is a record read by Follow, or an error. Header is the header of the file
+// the record was read from, which can change when the file is rotated.
+type FollowedRecord struct {
+	Header []string
+	Record []string
+	Line   int
+	Err    error
+}
+
+type follower struct {
+	path          string
+	pollInterval  time.Duration
+	readerOptions []ReaderOption
+
+	file     *os.File
+	info     os.FileInfo
+	offset   int64
+	lastByte byte
+}
+
+type FollowOption func(*follower)
+
+// WithPollInterval sets how often the file is checked for new data at its end, 250ms by default
+func WithPollInterval(interval time.Duration) FollowOption {
+	return func(f *follower) {
+		f.pollInterval = interval
+	}
+}
+
+// WithFollowReaderOptions configures the CsvReader that parses the file
+func WithFollowReaderOptions(readerOptions ...ReaderOption) FollowOption {
+	return func(f *follower) {
+		f.readerOptions = readerOptions
+	}
+}
+
+// Follow reads the records of the file at path and, instead of stopping at its end, polls it
+// for records appended later, like tail -f. A record only partially written when the end was
+// reached is completed as the rest arrives. When the file shrinks it is read again from the
+// start, and when another file takes its path that file is read from its start, header
+// included. Parse errors are sent and reading carries on; any other error is sent last.
+// The channel is closed once ctx is cancelled or after such an error.
+func Follow(ctx context.Context, path string, options ...FollowOption) (<-chan FollowedRecord, error) {
+	f := &follower{path: path, pollInterval: 250 * time.Millisecond}
+	for _, op := range options {
+		op(f)
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	records := make(chan FollowedRecord)
+	go func() {
+		defer close(records)
+		defer f.file.Close()
+		f.run(ctx, records)
+	}()
+	return records, nil
+}
+
+func (f *follower) open() error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	if f.file != nil {
+		f.file.Close()
+	}
+	f.file, f.info, f.offset, f.lastByte = file, info, 0, 0
+	return nil
+}
+
+func (f *follower) run(ctx context.Context, records chan<- FollowedRecord) {
+	send := func(record FollowedRecord) bool {
+		select {
+		case records <- record:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for {
+		cr := NewCsvReader(&followReader{ctx: ctx, follower: f}, f.readerOptions...)
+		err := f.readAll(cr, send)
+
+		if ctx.Err() != nil {
+			return
+		}
+		if !errors.Is(err, errFileReplaced) {
+			send(FollowedRecord{Err: err})
+			return
+		}
+
+		if f.offset > 0 && f.lastByte != '\n' {
+			if !send(FollowedRecord{Err: fmt.Errorf("%w: %s", ErrPartialRecord, f.path)}) {
+				return
+			}
+		}
+		if err := f.reopen(); err != nil {
+			send(FollowedRecord{Err: err})
+			return
+		}
+	}
+}
+
+// readAll sends the records of cr until an error other than a parse error
+func (f *follower) readAll(cr *CsvReader, send func(FollowedRecord) bool) error {
+	header, err := cr.Header()
+	if err != nil {
+		return err
+	}
+
+	for {
+		record, err := cr.ReadRecord()
+		var parseErr *ParseError
+		if err != nil && !errors.As(err, &parseErr) {
+			return err
+		}
+
+		if !send(FollowedRecord{Header: header, Record: record, Line: cr.Line(), Err: err}) {
+			return context.Canceled
+		}
+	}
+}
+
+// reopen starts over on a truncated file, or opens the file that replaced it
+func (f *follower) reopen() error {
+	info, err := os.Stat(f.path)
+	if err == nil && os.SameFile(info, f.info) {
+		f.info, f.offset, f.lastByte = info, 0, 0
+		_, err = f.file.Seek(0, io.SeekStart)
+		return err
+	}
+	return f.open()
+}
+
+// replaced reports whether the file at path shrank or is now another file. A path that is
+// missing, say between a rotation and the new file being created, is not a change yet.
+func (f *follower) replaced() bool {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return false
+	}
+	return !os.SameFile(info, f.info) || info.Size() < f.offset
+}
+
+// followReader waits for more data at the end of the file instead of returning io.EOF
+type followReader struct {
+	ctx      context.Context
+	follower *follower
+}
+
+func (fr *followReader) Read(p []byte) (int, error) {
+	f := fr.follower
+	for {
+		n, err := f.file.Read(p)
+		if n > 0 {
+			f.offset += int64(n)
+			f.lastByte = p[n-1]
+			return n, nil
+		}
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+
+		if f.replaced() {
+			return 0, errFileReplaced
+		}
+
+		select {
+		case <-fr.ctx.Done():
+			return 0, fr.ctx.Err()
+		case <-time.After(f.pollInterval):
+		}
+	}
+}
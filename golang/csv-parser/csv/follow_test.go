@@ -0,0 +1,78 @@
+package csv
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFollow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.csv")
+	assert.NoError(t, os.WriteFile(path, []byte("id,msg\n1,a\n"), 0o644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	records, err := Follow(ctx, path, WithPollInterval(time.Millisecond), WithFollowReaderOptions(WithHeader(true)))
+	assert.NoError(t, err)
+
+	next := func() FollowedRecord {
+		select {
+		case record := <-records:
+			return record
+		case <-time.After(5 * time.Second):
+			t.Fatal("no record")
+		}
+		return FollowedRecord{}
+	}
+	appendTo := func(content string) {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+		assert.NoError(t, err)
+		_, err = file.WriteString(content)
+		assert.NoError(t, err)
+		assert.NoError(t, file.Close())
+	}
+
+	assert.Equal(t, FollowedRecord{Header: []string{"id", "msg"}, Record: []string{"1", "a"}, Line: 2}, next())
+
+	// a record written in pieces, even inside a quoted field, comes out whole
+	appendTo("2,\"multi")
+	time.Sleep(20 * time.Millisecond)
+	appendTo("\nline\"")
+	time.Sleep(20 * time.Millisecond)
+	appendTo("\n3,c\n")
+	assert.Equal(t, []string{"2", "multi\nline"}, next().Record)
+	assert.Equal(t, FollowedRecord{Header: []string{"id", "msg"}, Record: []string{"3", "c"}, Line: 5}, next())
+
+	// parse errors are sent and reading carries on
+	appendTo("4\n5,e\n")
+	assert.True(t, errors.As(next().Err, new(*ParseError)))
+	assert.Equal(t, []string{"5", "e"}, next().Record)
+
+	// a truncated file is read again from the start, dropping the partial record
+	appendTo("6,unfinish")
+	time.Sleep(20 * time.Millisecond)
+	assert.NoError(t, os.WriteFile(path, []byte("id,msg\n"), 0o644))
+	assert.True(t, errors.Is(next().Err, ErrPartialRecord))
+	appendTo("7,g\n")
+	assert.Equal(t, FollowedRecord{Header: []string{"id", "msg"}, Record: []string{"7", "g"}, Line: 2}, next())
+
+	// a rotated file is finished before the new one is read, with its own header
+	assert.NoError(t, os.Rename(path, path+".1"))
+	assert.NoError(t, os.WriteFile(path, []byte("id,message,level\n8,h,info\n"), 0o644))
+	assert.Equal(t, FollowedRecord{Header: []string{"id", "message", "level"}, Record: []string{"8", "h", "info"}, Line: 2}, next())
+
+	cancel()
+	for range records {
+	}
+}
+
+func TestFollowMissingFile(t *testing.T) {
+	_, err := Follow(context.Background(), filepath.Join(t.TempDir(), "nope.csv"))
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
@@ -0,0 +1,101 @@
+package csv
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+var errUnknownNormalization = errors.New("unknown header normalization")
+
+// Normalization is a step of NormalizeHeader
+type Normalization string
+
+const (
+	// NormalizeTrim removes leading and trailing white space
+	NormalizeTrim Normalization = "trim"
+	// NormalizeFold lower cases
+	NormalizeFold Normalization = "fold"
+	// NormalizeSnake turns "Customer ID", "CustomerID" and "customer-id" into customer_id
+	NormalizeSnake Normalization = "snake"
+	// NormalizePunct removes punctuation and symbols, underscores included
+	NormalizePunct Normalization = "punct"
+	// NormalizeBOM removes byte order marks and zero width spaces left in a name
+	NormalizeBOM Normalization = "bom"
+)
+
+func ParseNormalization(name string) (Normalization, error) {
+	switch n := Normalization(strings.ToLower(strings.TrimSpace(name))); n {
+	case NormalizeTrim, NormalizeFold, NormalizeSnake, NormalizePunct, NormalizeBOM:
+		return n, nil
+	}
+	return "", fmt.Errorf("%w: %s", errUnknownNormalization, name)
+}
+
+// WithHeaderNormalization normalizes the names of the header with NormalizeHeader, before
+// the columns of WithColumns or WithProjection are looked up in it
+func WithHeaderNormalization(steps ...Normalization) ReaderOption {
+	return func(reader *CsvReader) {
+		reader.headerNormalization = steps
+	}
+}
+
+// NormalizeHeader applies steps to a header name in the given order
+func NormalizeHeader(name string, steps ...Normalization) string {
+	for _, step := range steps {
+		switch step {
+		case NormalizeTrim:
+			name = strings.TrimSpace(name)
+		case NormalizeFold:
+			name = strings.ToLower(name)
+		case NormalizeSnake:
+			name = snakeCase(name)
+		case NormalizePunct:
+			name = strings.Map(func(r rune) rune {
+				if unicode.IsPunct(r) || unicode.IsSymbol(r) {
+					return -1
+				}
+				return r
+			}, name)
+		case NormalizeBOM:
+			name = strings.Map(func(r rune) rune {
+				if r == '\uFEFF' || r == '\u200B' {
+					return -1
+				}
+				return r
+			}, name)
+		}
+	}
+	return name
+}
+
+// snakeCase lower cases words and joins them with single underscores. Words are split at
+// anything but letters and digits, and where the case changes, keeping acronyms whole:
+// HTTPServer becomes http_server.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	separate := func() {
+		if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "_") {
+			sb.WriteByte('_')
+		}
+	}
+
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			separate()
+			continue
+		}
+
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				separate()
+			}
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return strings.TrimSuffix(sb.String(), "_")
+}
@@ -0,0 +1,56 @@
+package csv
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeHeader(t *testing.T) {
+	testCases := []struct {
+		name     string
+		steps    []Normalization
+		expected string
+	}{
+		{name: "  Customer ID ", steps: []Normalization{NormalizeTrim}, expected: "Customer ID"},
+		{name: "Customer ID", steps: []Normalization{NormalizeFold}, expected: "customer id"},
+		{name: "Customer ID", steps: []Normalization{NormalizeSnake}, expected: "customer_id"},
+		{name: "CustomerID", steps: []Normalization{NormalizeSnake}, expected: "customer_id"},
+		{name: "customerId", steps: []Normalization{NormalizeSnake}, expected: "customer_id"},
+		{name: "HTTPServer", steps: []Normalization{NormalizeSnake}, expected: "http_server"},
+		{name: "Line2Total", steps: []Normalization{NormalizeSnake}, expected: "line2_total"},
+		{name: " -- Unit Price (€) -- ", steps: []Normalization{NormalizeSnake}, expected: "unit_price"},
+		{name: "Ünit Größe", steps: []Normalization{NormalizeSnake}, expected: "ünit_größe"},
+		{name: "customer_id#", steps: []Normalization{NormalizePunct}, expected: "customerid"},
+		{name: "\ufeffid\u200b", steps: []Normalization{NormalizeBOM}, expected: "id"},
+		{name: " CUSTOMER-ID ", steps: []Normalization{NormalizeTrim, NormalizePunct, NormalizeFold}, expected: "customerid"},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, currTestCase.expected, NormalizeHeader(currTestCase.name, currTestCase.steps...))
+		})
+	}
+}
+
+func TestWithHeaderNormalization(t *testing.T) {
+	t.Parallel()
+
+	reader := NewCsvReader(strings.NewReader("Customer ID, Order Date \n1,2024-01-01\n"),
+		WithHeader(true), WithHeaderNormalization(NormalizeSnake), WithColumns("order_date"))
+	header, err := reader.Header()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"order_date"}, header)
+
+	records, err := reader.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"2024-01-01"}}, records)
+
+	_, err = ParseNormalization("kebab")
+	assert.True(t, errors.Is(err, errUnknownNormalization), err)
+}
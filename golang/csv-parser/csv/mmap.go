@@ -0,0 +1,94 @@
+package csv
+
+import (
+	"fmt"
+	"os"
+)
+
+type MmapOption func(*MmapReader)
+
+// WithReaderOptions configures the reader the way the same options configure a CsvReader.
+// Fields are only sub-slices of the mapped file for UTF-8 input, input in another encoding
+// is decoded into memory first.
+func WithReaderOptions(options ...ReaderOption) MmapOption {
+	return func(mr *MmapReader) {
+		mr.readerOptions = append(mr.readerOptions, options...)
+	}
+}
+
+// WithUnsafeStrings makes ReadRecord return strings pointing into the mapped file instead of
+// copies. They must not be used after Close, which unmaps the memory behind them.
+func WithUnsafeStrings(unsafeStrings bool) MmapOption {
+	return func(mr *MmapReader) {
+		mr.unsafeStrings = unsafeStrings
+	}
+}
+
+// MmapReader parses a memory mapped file with the same state machine CsvReader parses a
+// stream with. Fields are sub-slices of the mapped bytes, only the fields holding escaped
+// quotes or CRs, or otherwise changed by parsing, are copied.
+type MmapReader struct {
+	*CsvReader
+	readerOptions []ReaderOption
+	unsafeStrings bool
+
+	data  []byte
+	unmap func() error
+}
+
+// OpenMmap maps the file at path into memory for reading. The records read from it are only
+// valid until Close.
+func OpenMmap(path string, options ...MmapOption) (*MmapReader, error) {
+	mr := &MmapReader{}
+	for _, op := range options {
+		op(mr)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if int64(int(info.Size())) != info.Size() {
+		return nil, fmt.Errorf("%s is too large to map", path)
+	}
+
+	if mr.data, mr.unmap, err = mmapFile(file, int(info.Size())); err != nil {
+		return nil, err
+	}
+	if mr.CsvReader, err = newBytesReader(mr.data, mr.readerOptions...); err != nil {
+		mr.Close()
+		return nil, err
+	}
+	mr.CsvReader.unsafeStrings = mr.unsafeStrings
+	return mr, nil
+}
+
+// Close unmaps the file. The fields and unsafe strings read from it must not be used after.
+func (mr *MmapReader) Close() error {
+	if mr.unmap == nil {
+		return nil
+	}
+
+	err := mr.unmap()
+	mr.data, mr.unmap = nil, nil
+	if mr.CsvReader != nil {
+		mr.CsvReader.data = nil
+	}
+	return err
+}
+
+// ReadFields reads the next record without copying it, returning io.EOF once the file is
+// exhausted. The returned slice is reused by the next call, the fields in it stay valid until
+// Close. As with ReadRecord, reading can carry on after a *ParseError.
+func (mr *MmapReader) ReadFields() ([][]byte, error) {
+	if err := mr.readHeader(); err != nil {
+		return nil, err
+	}
+	return mr.readFields()
+}
@@ -0,0 +1,195 @@
+package csv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// readAll lists the records and the positioned errors of r in the order they come, up to an
+// error reading cannot get past
+func readAll(r RecordReader) []string {
+	var results []string
+	if header, err := r.Header(); err != nil {
+		results = append(results, "header error: "+err.Error())
+	} else if header != nil {
+		results = append(results, fmt.Sprintf("header %q", header))
+	}
+
+	var last error
+	for {
+		record, err := r.ReadRecord()
+		if err == io.EOF {
+			return results
+		}
+		if err != nil {
+			results = append(results, "error: "+err.Error())
+			var parseErr *ParseError
+			if err == last || !errors.As(err, &parseErr) {
+				return results
+			}
+			last = err
+			continue
+		}
+		results = append(results, fmt.Sprintf("%q", record))
+	}
+}
+
+// assertMmapAgrees checks that OpenMmap reads input the way NewCsvReader does
+func assertMmapAgrees(t *testing.T, input string, options ...ReaderOption) {
+	expected := readAll(NewCsvReader(strings.NewReader(input), options...))
+
+	mr, err := OpenMmap(writeTemp(t, input), WithReaderOptions(options...))
+	assert.NoError(t, err)
+	defer mr.Close()
+	assert.Equal(t, expected, readAll(mr), "%q", input)
+}
+
+func writeTemp(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "input.csv")
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestMmapReader(t *testing.T) {
+	inputs := []string{
+		"",
+		"a,b,c\n1,2,3\n",
+		"a,b,c\r\n1,2,3\r\n4,5,6",
+		"\xEF\xBB\xBFa;b\n1;2\n",
+		"sep=;\r\na;b\n1;2\n",
+		"sep=;",
+		"a,b\n\n\r\n1,2\n\n",
+		"a,\"b\r\nc\",\"\"\"d\"\"\"\n1,\"\",\"x\ny\"\n",
+		"a,b,\n1,2,",
+		"a\rb,c\n",
+		"a,b\n1,2\"3\n4,5\n",
+		"a,b\n\"1\"x,2\n3,4\n",
+		"a,b\n\"1\" ,\"2\"\"\" \r\n",
+		"a,b,c\n1,2\n3,4,5\n6,7,8,9\n",
+		"a,b\n1,\"two\n3,4\n5,6\n",
+		"a,b\n1,\"x\nc,\"\"\n",
+		"\"a,b\n",
+		"x\n\"",
+	}
+	options := [][]ReaderOption{
+		nil,
+		{WithHeader(true)},
+		{WithLenient(true)},
+		{WithHeader(true), WithLenient(true), WithDelimiter(';')},
+		{WithHeader(true), WithSepLineDetection(true)},
+		{WithHeader(true), WithColumns("b", "a")},
+		{WithStrict(true), WithFinalNewline(true)},
+		{WithMaxQuotedLines(1), WithEncoding(EncodingLatin1)},
+	}
+
+	for i, input := range inputs {
+		for j, readerOptions := range options {
+			currInput, currOptions := input, readerOptions
+			t.Run(fmt.Sprintf("input %d options %d", i, j), func(t *testing.T) {
+				t.Parallel()
+				assertMmapAgrees(t, currInput, currOptions...)
+			})
+		}
+	}
+}
+
+func TestMmapReaderZeroCopy(t *testing.T) {
+	t.Parallel()
+
+	mr, err := OpenMmap(writeTemp(t, "id,note\r\n1,plain\r\n2,\"quoted, \"\"escaped\"\"\"\r\n"),
+		WithReaderOptions(WithHeader(true)), WithUnsafeStrings(true))
+	assert.NoError(t, err)
+
+	mapped := func(field []byte) bool {
+		start := uintptr(unsafe.Pointer(unsafe.SliceData(mr.data)))
+		p := uintptr(unsafe.Pointer(unsafe.SliceData(field)))
+		return p >= start && p < start+uintptr(len(mr.data))
+	}
+
+	fields, err := mr.ReadFields()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("1"), []byte("plain")}, fields)
+	assert.True(t, mapped(fields[0]))
+	assert.True(t, mapped(fields[1]))
+
+	// unsafe strings point into the mapped file too, but the escaped field is a copy
+	record, err := mr.ReadRecord()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2", "quoted, \"escaped\""}, record)
+	assert.True(t, mapped(unsafe.Slice(unsafe.StringData(record[0]), len(record[0]))))
+	assert.False(t, mapped(unsafe.Slice(unsafe.StringData(record[1]), len(record[1]))))
+
+	_, err = mr.ReadRecord()
+	assert.Equal(t, io.EOF, err)
+	assert.NoError(t, mr.Close())
+
+	// the header is a copy, still there after Close
+	header, err := mr.Header()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id", "note"}, header)
+}
+
+func TestOpenMmapDecodes(t *testing.T) {
+	t.Parallel()
+
+	mr, err := OpenMmap(writeTemp(t, "caf\xE9,na\xEFve\n"), WithReaderOptions(WithEncoding(EncodingLatin1)))
+	assert.NoError(t, err)
+	defer mr.Close()
+
+	fields, err := mr.ReadFields()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("café"), []byte("naïve")}, fields)
+}
+
+func TestOpenMmapErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := OpenMmap(filepath.Join(t.TempDir(), "missing.csv"))
+	assert.True(t, errors.Is(err, os.ErrNotExist), err)
+}
+
+func BenchmarkReadMmap(b *testing.B) {
+	var sb strings.Builder
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(&sb, "%d,name %d,\"note, %d\",%d.5\n", i, i, i, i)
+	}
+	path := filepath.Join(b.TempDir(), "input.csv")
+	if err := os.WriteFile(path, []byte(sb.String()), 0o644); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("CsvReader", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			file, err := os.Open(path)
+			if err != nil {
+				b.Fatal(err)
+			}
+			cr := NewCsvReader(file)
+			for _, err := cr.ReadRecord(); err != io.EOF; _, err = cr.ReadRecord() {
+			}
+			file.Close()
+		}
+	})
+
+	b.Run("MmapReader", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			mr, err := OpenMmap(path)
+			if err != nil {
+				b.Fatal(err)
+			}
+			for _, err := mr.ReadFields(); err != io.EOF; _, err = mr.ReadFields() {
+			}
+			mr.Close()
+		}
+	})
+}
@@ -13,3 +13,68 @@ func WithEscapeChar(escapeChar byte) ReaderOption {
 		reader.escapeChar = escapeChar
 	}
 }
+
+// WithHeader treats the first record as a header, available from Header() instead of ReadRecord()
+func WithHeader(hasHeader bool) ReaderOption {
+	return func(reader *CsvReader) {
+		reader.hasHeader = hasHeader
+	}
+}
+
+// WithLenient accepts records with a varying number of fields and keeps stray escape chars as they are
+func WithLenient(lenient bool) ReaderOption {
+	return func(reader *CsvReader) {
+		reader.lenient = lenient
+	}
+}
+
+// WithSepLineDetection reads the delimiter from a leading sep= line, as written for Excel,
+// instead of returning that line as a record. Only input written by people or other tools
+// should be read this way, a record of the data can encode as a sep= line too.
+func WithSepLineDetection(detect bool) ReaderOption {
+	return func(reader *CsvReader) {
+		reader.sepLine = detect
+	}
+}
+
+// WithStrict rejects anything RFC 4180 does not allow: line breaks other than CRLF outside
+// of quoted fields, blank lines, records ending with a delimiter, and everything WithLenient
+// would accept, which it overrides. A sep= line is read as an ordinary record.
+func WithStrict(strict bool) ReaderOption {
+	return func(reader *CsvReader) {
+		reader.strict = strict
+	}
+}
+
+// WithFinalNewline rejects an input whose last record is not followed by a line break, which
+// RFC 4180 leaves optional
+func WithFinalNewline(required bool) ReaderOption {
+	return func(reader *CsvReader) {
+		reader.finalNewline = required
+	}
+}
+
+// WithMaxQuotedLines takes a quoted field holding more than lines line breaks to be missing
+// its closing quote, rather than letting it swallow the records that follow. The field is
+// reported as unterminated and reading carries on at the line after its opening quote, as
+// long as the field swallowed no more than a MiB, or WithMaxQuotedBytes, and after the field
+// otherwise. A quote left open at the end of the input is always reported that way.
+func WithMaxQuotedLines(lines int) ReaderOption {
+	return func(reader *CsvReader) {
+		reader.maxQuotedLines = lines
+	}
+}
+
+// WithMaxQuotedBytes is like WithMaxQuotedLines for a quoted field longer than bytes
+func WithMaxQuotedBytes(bytes int) ReaderOption {
+	return func(reader *CsvReader) {
+		reader.maxQuotedBytes = bytes
+	}
+}
+
+// WithEncoding decodes the input from the given encoding to UTF-8 before parsing
+func WithEncoding(encoding Encoding) ReaderOption {
+	return func(reader *CsvReader) {
+		reader.encoding = encoding
+	}
+}
@@ -0,0 +1,108 @@
+package csv
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownColumn is returned when a selected column is not in the input
+var ErrUnknownColumn = errors.New("unknown column")
+
+// ColumnPositions returns the 0-based position of each of the named columns in header, the
+// first one when several columns share a name
+func ColumnPositions(header, names []string) ([]int, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		if _, ok := index[name]; !ok {
+			index[name] = i
+		}
+	}
+
+	positions := make([]int, len(names))
+	for i, name := range names {
+		pos, ok := index[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownColumn, name)
+		}
+		positions[i] = pos
+	}
+	return positions, nil
+}
+
+// Column selects an input column by header name, or by 0-based index when Name is empty.
+// As renames the column in the header returned by the reader.
+type Column struct {
+	Name  string
+	Index int
+	As    string
+}
+
+// WithColumns only returns the named header columns, in the given order
+func WithColumns(names ...string) ReaderOption {
+	columns := make([]Column, len(names))
+	for i, name := range names {
+		columns[i] = Column{Name: name}
+	}
+	return WithProjection(columns...)
+}
+
+// WithProjection only returns the given columns, in the given order. The fields of the other
+// columns are still parsed but never turned into strings, which saves most of the allocations
+// when only a few columns of a wide file are needed.
+func WithProjection(columns ...Column) ReaderOption {
+	return func(reader *CsvReader) {
+		if len(columns) == 0 {
+			columns = nil
+		}
+		reader.columns = columns
+	}
+}
+
+// resolveProjection maps the selected columns to input positions and returns the projected header
+func (cr *CsvReader) resolveProjection(header []string) ([]string, error) {
+	var names []string
+	for _, column := range cr.columns {
+		if column.Name != "" {
+			names = append(names, column.Name)
+		}
+	}
+	named, err := ColumnPositions(header, names)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make([]int, len(cr.columns))
+	projectedHeader := make([]string, len(cr.columns))
+	numInputFields := 0
+	for i, column := range cr.columns {
+		pos := column.Index
+		if column.Name != "" {
+			pos, named = named[0], named[1:]
+		} else if pos < 0 || (header != nil && pos >= len(header)) {
+			return nil, fmt.Errorf("%w: index %d", ErrUnknownColumn, pos)
+		}
+
+		positions[i] = pos
+		if pos >= numInputFields {
+			numInputFields = pos + 1
+		}
+
+		switch {
+		case column.As != "":
+			projectedHeader[i] = column.As
+		case header != nil:
+			projectedHeader[i] = header[pos]
+		}
+	}
+
+	projection := make([][]int, numInputFields)
+	for i, pos := range positions {
+		projection[pos] = append(projection[pos], i)
+	}
+
+	cr.readerState.projection = projection
+	cr.readerState.projectedLen = len(cr.columns)
+	cr.resetRecord()
+
+	return projectedHeader, nil
+}
@@ -0,0 +1,205 @@
+package csv
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjection(t *testing.T) {
+	testCases := []struct {
+		name           string
+		input          string
+		options        []ReaderOption
+		expectedHeader []string
+		expected       [][]string
+		err            error
+	}{
+		{
+			name:           "by name",
+			input:          "a,b,c\n1,2,3\n4,5,6\n",
+			options:        []ReaderOption{WithHeader(true), WithColumns("c", "a")},
+			expectedHeader: []string{"c", "a"},
+			expected:       [][]string{{"3", "1"}, {"6", "4"}},
+		},
+		{
+			name:           "rename, index and duplicate",
+			input:          "a,b,c\n1,2,3\n",
+			options:        []ReaderOption{WithHeader(true), WithProjection(Column{Name: "b", As: "bee"}, Column{Index: 0}, Column{Name: "b"})},
+			expectedHeader: []string{"bee", "a", "b"},
+			expected:       [][]string{{"2", "1", "2"}},
+		},
+		{
+			name:     "index without header",
+			input:    "1,\"2\n2\",3\n4,5,6\n",
+			options:  []ReaderOption{WithProjection(Column{Index: 1})},
+			expected: [][]string{{"2\n2"}, {"5"}},
+		},
+		{
+			name:    "unknown name",
+			input:   "a,b\n1,2\n",
+			options: []ReaderOption{WithHeader(true), WithColumns("z")},
+			err:     ErrUnknownColumn,
+		},
+		{
+			name:    "name without header",
+			input:   "a,b\n1,2\n",
+			options: []ReaderOption{WithColumns("a")},
+			err:     ErrUnknownColumn,
+		},
+		{
+			name:    "index out of range",
+			input:   "a,b\n1,2\n",
+			options: []ReaderOption{WithProjection(Column{Index: 2})},
+			err:     ErrUnknownColumn,
+		},
+		{
+			name:    "wrong number of fields is still checked",
+			input:   "a,b,c\n1,2\n",
+			options: []ReaderOption{WithHeader(true), WithColumns("a")},
+			err:     errWrongNumFields,
+		},
+		{
+			name:    "malformed header",
+			input:   "a\"b,c\n1,2\n",
+			options: []ReaderOption{WithHeader(true), WithColumns("c")},
+			err:     errUnexpectedEscapeChar,
+		},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			assertMmapAgrees(t, currTestCase.input, currTestCase.options...)
+			csvReader := NewCsvReader(strings.NewReader(currTestCase.input), currTestCase.options...)
+			header, headerErr := csvReader.Header()
+			records, err := csvReader.Read()
+			if currTestCase.err != nil {
+				assert.True(t, errors.Is(err, currTestCase.err), err)
+				return
+			}
+
+			assert.NoError(t, headerErr)
+			assert.NoError(t, err)
+			assert.Equal(t, currTestCase.expectedHeader, header)
+			assert.Equal(t, currTestCase.expected, records)
+		})
+	}
+}
+
+func TestProjectionMalformedHeader(t *testing.T) {
+	t.Parallel()
+
+	csvReader := NewCsvReader(strings.NewReader("a\"b,c\n1,2\n"), WithHeader(true), WithColumns("c"))
+	for i := 0; i < 2; i++ {
+		header, err := csvReader.Header()
+		assert.Nil(t, header)
+		assert.True(t, errors.Is(err, errUnexpectedEscapeChar), err)
+
+		// the records cannot be projected, and are not returned whole instead
+		record, err := csvReader.ReadRecord()
+		assert.Nil(t, record)
+		assert.True(t, errors.Is(err, errUnexpectedEscapeChar), err)
+	}
+
+	// without a projection reading carries on after the header
+	csvReader = NewCsvReader(strings.NewReader("a\"b,c\n1,2\n"), WithHeader(true))
+	_, err := csvReader.Header()
+	assert.True(t, errors.Is(err, errUnexpectedEscapeChar), err)
+	record, err := csvReader.ReadRecord()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2"}, record)
+}
+
+func wideCsv(numRows, numCols int) string {
+	var sb strings.Builder
+	for row := 0; row <= numRows; row++ {
+		for col := 0; col < numCols; col++ {
+			if col > 0 {
+				sb.WriteByte(',')
+			}
+			if row == 0 {
+				fmt.Fprintf(&sb, "c%d", col)
+			} else {
+				fmt.Fprintf(&sb, "value-%d-%d", row, col)
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+func TestColumnPositions(t *testing.T) {
+	header := []string{"id", "name", "id", "city"}
+
+	testCases := []struct {
+		name     string
+		names    []string
+		expected []int
+		err      error
+	}{
+		{name: "in the given order", names: []string{"city", "id"}, expected: []int{3, 0}},
+		{name: "a name twice", names: []string{"name", "name"}, expected: []int{1, 1}},
+		{name: "no names", names: nil, expected: []int{}},
+		{name: "unknown column", names: []string{"id", "country"}, err: ErrUnknownColumn},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			positions, err := ColumnPositions(header, currTestCase.names)
+			if currTestCase.err != nil {
+				assert.True(t, errors.Is(err, currTestCase.err), err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, currTestCase.expected, positions)
+		})
+	}
+}
+
+func TestProjectionAllocations(t *testing.T) {
+	input := wideCsv(100, 200)
+
+	readAll := func(options ...ReaderOption) {
+		csvReader := NewCsvReader(strings.NewReader(input), options...)
+		if _, err := csvReader.Read(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	full := testing.AllocsPerRun(5, func() { readAll(WithHeader(true)) })
+	projected := testing.AllocsPerRun(5, func() { readAll(WithHeader(true), WithColumns("c3", "c150", "c7")) })
+	assert.Less(t, projected*10, full)
+}
+
+func BenchmarkReadWide(b *testing.B) {
+	input := wideCsv(1000, 200)
+
+	benchmarks := []struct {
+		name    string
+		options []ReaderOption
+	}{
+		{name: "all columns", options: []ReaderOption{WithHeader(true)}},
+		{name: "three columns", options: []ReaderOption{WithHeader(true), WithColumns("c3", "c150", "c7")}},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				csvReader := NewCsvReader(strings.NewReader(input), bm.options...)
+				if _, err := csvReader.Read(); err != nil {
+					b.Errorf("error %v", err)
+				}
+			}
+		})
+	}
+}
@@ -6,20 +6,78 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
+	"unsafe"
 )
 
 var (
 	errMismatchedEscapeChar = errors.New("mismatched escape char")
 	errUnexpectedEscapeChar = errors.New("unexpected escape char")
 	errWrongNumFields       = errors.New("wrong number of fields")
+	errUnterminatedQuote    = errors.New("unterminated quoted field")
+
+	// strict mode errors
+	errLineBreak         = errors.New("line break is not CRLF")
+	errBlankLine         = errors.New("blank line")
+	errTrailingDelimiter = errors.New("trailing delimiter")
+	errNoFinalNewline    = errors.New("no line break after the last record")
 )
 
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// defaultRewindWindow is how many bytes swallowed by an unterminated quoted field are kept to
+// be read again, unless WithMaxQuotedBytes allows for a longer field
+const defaultRewindWindow = 1 << 20
+
+// ParseError reports a malformed record together with its position in the input.
+// Line and Column are 1-based, Column counts bytes.
+type ParseError struct {
+	StartLine int
+	Line      int
+	Column    int
+	Err       error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%v at line: %d, column: %d", e.Err, e.Line, e.Column)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// RecordReader produces records one at a time, like CsvReader does
+type RecordReader interface {
+	Header() ([]string, error)
+	ReadRecord() ([]string, error)
+}
+
 type CsvReader struct {
 	delimiter  byte
 	escapeChar byte
+	hasHeader  bool
+	lenient    bool
+	strict     bool
+	// sepLine reads the delimiter from a leading sep= line
+	sepLine bool
+	// finalNewline requires a line break after the last record
+	finalNewline bool
+	// limits past which an open quoted field is taken to be unterminated, 0 for none
+	maxQuotedLines int
+	maxQuotedBytes int
+	encoding       Encoding
+	columns        []Column
+	// rewindWindow bounds the bytes kept to read again after an unterminated quoted field
+	rewindWindow int
+	// headerNormalization are the NormalizeHeader steps applied to the header
+	headerNormalization []Normalization
 
-	reader      *bufio.Reader
-	readerState *readerState
+	// a reader made from a byte slice parses data instead of reader, and returns fields as
+	// sub-slices of it where it can, as strings pointing into it when unsafeStrings is set
+	reader        *bufio.Reader
+	data          []byte
+	unsafeStrings bool
+	readerState   *readerState
 }
 
 // readerState keeps track of the current state of the reader between reads
@@ -31,14 +89,83 @@ type readerState struct {
 	field               bytes.Buffer
 	record              []string
 	records             [][]string
+
+	// position of the last byte read and the line the current record started on
+	line       int
+	column     int
+	recordLine int
+	inRecord   bool
+	// column of the last newline read, and whether a CR came right before it
+	newlineColumn int
+	afterCR       bool
+	// position of the last delimiter when nothing has been read after it on the record
+	delimiterLine   int
+	delimiterColumn int
+	// position of the quote opening the current field, the line breaks read in it, and the
+	// raw bytes read after its first line break, to read again should it never be closed,
+	// unless there were more of them than the rewind window holds
+	quoteLine      int
+	quoteColumn    int
+	quotedLines    int
+	quotedRaw      bytes.Buffer
+	quotedOverflow bool
+	// replay holds the bytes given back after an unterminated quoted field, read before the input
+	replay bytes.Buffer
+
+	// pos is the offset in data of the next byte. The field read from data is the span of
+	// spanLen bytes at spanStart, until a byte of it is dropped or unescaped and it is copied
+	// into field. quotedStart is where the open quoted field had its first line break.
+	pos         int
+	spanStart   int
+	spanLen     int
+	copied      bool
+	quotedStart int
+	// the record being read from data, and the last one read, which only ReadFields leaves
+	// as it is rather than making strings of it
+	rawRecord  [][]byte
+	rawFields  [][]byte
+	fieldsOnly bool
+
+	started    bool
+	eof        bool
+	headerRead bool
+	header     []string
+	headerErr  error
+
+	// fieldIndex counts the fields of the current record as they are read. When a projection
+	// is set, projection[i] lists the positions in the returned record of input field i.
+	fieldIndex   int
+	projection   [][]int
+	projectedLen int
 }
 
 func NewCsvReader(inputReader io.Reader, readerOptions ...ReaderOption) *CsvReader {
-	bufReader := bufio.NewReader(inputReader)
+	cr := newCsvReader(readerOptions)
+	cr.reader = bufio.NewReader(newDecodingReader(inputReader, cr.encoding))
+	return cr
+}
+
+// newBytesReader returns a reader parsing data, which must not change while it is read. Input
+// in an encoding other than UTF-8 is decoded into memory first.
+func newBytesReader(data []byte, readerOptions ...ReaderOption) (*CsvReader, error) {
+	cr := newCsvReader(readerOptions)
+	if cr.encoding != EncodingUTF8 {
+		decoded, err := io.ReadAll(newDecodingReader(bytes.NewReader(data), cr.encoding))
+		if err != nil {
+			return nil, err
+		}
+		data = decoded
+	}
+	cr.data = data
+	return cr, nil
+}
+
+func newCsvReader(readerOptions []ReaderOption) *CsvReader {
 	cr := &CsvReader{
-		delimiter:  ',',
-		escapeChar: '"',
-		reader:     bufReader,
+		delimiter:    ',',
+		escapeChar:   '"',
+		encoding:     EncodingUTF8,
+		rewindWindow: defaultRewindWindow,
 		readerState: &readerState{
 			lineNum:  1,
 			escaping: false,
@@ -46,33 +173,161 @@ func NewCsvReader(inputReader io.Reader, readerOptions ...ReaderOption) *CsvRead
 			field:    bytes.Buffer{},
 			record:   []string{},
 			records:  [][]string{},
+			line:     1,
 		},
 	}
 
 	for _, op := range readerOptions {
 		op(cr)
 	}
+	if cr.strict {
+		cr.lenient = false
+	}
 
 	return cr
 }
 
+// Read reads all the remaining records. If the reader was created WithHeader the header is not included.
 func (cr *CsvReader) Read() ([][]string, error) {
 	for {
-		ch, err := cr.reader.ReadByte()
+		record, err := cr.ReadRecord()
+		if err == io.EOF {
+			return cr.readerState.records, nil
+		}
 
-		// if end of file, append the last line and return
+		if err != nil {
+			return nil, err
+		}
+
+		cr.readerState.records = append(cr.readerState.records, record)
+	}
+}
+
+// Header returns the first record of the input when the reader was created WithHeader, and nil otherwise.
+func (cr *CsvReader) Header() ([]string, error) {
+	if !cr.hasHeader {
+		return nil, nil
+	}
+
+	if !cr.readerState.headerRead {
+		cr.readerState.headerRead = true
+		header, err := cr.readRecord()
+		if err != nil && err != io.EOF {
+			cr.readerState.headerErr = err
+			return nil, err
+		}
+
+		for i := range header {
+			// the header is kept, so it must not point into data that may go away
+			if cr.unsafeStrings {
+				header[i] = strings.Clone(header[i])
+			}
+			header[i] = NormalizeHeader(header[i], cr.headerNormalization...)
+		}
+
+		if cr.columns != nil && header != nil {
+			header, err = cr.resolveProjection(header)
+			cr.readerState.headerErr = err
+		}
+		cr.readerState.header = header
+	}
+
+	return cr.readerState.header, cr.readerState.headerErr
+}
+
+// ReadRecord reads the next record, returning io.EOF once the input is exhausted.
+// After a *ParseError the reader skips the rest of the offending line, so ReadRecord
+// can be called again to carry on with the next record. A malformed header is the
+// exception when columns are projected, as they cannot be found without it.
+func (cr *CsvReader) ReadRecord() ([]string, error) {
+	if err := cr.readHeader(); err != nil {
+		return nil, err
+	}
+	return cr.readRecord()
+}
+
+// readHeader reads the header, or resolves the projection without one, before the first record
+func (cr *CsvReader) readHeader() error {
+	if cr.hasHeader {
+		reported := cr.readerState.headerRead
+		if _, err := cr.Header(); err != nil && (!reported || cr.columns != nil) {
+			return err
+		}
+	} else if cr.columns != nil && cr.readerState.projection == nil {
+		if _, err := cr.resolveProjection(nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readFields reads the next record of a reader made from a byte slice without making strings
+// of it. The returned slice is reused by the next call.
+func (cr *CsvReader) readFields() ([][]byte, error) {
+	cr.readerState.fieldsOnly = true
+	defer func() {
+		cr.readerState.fieldsOnly = false
+	}()
+
+	if _, err := cr.readRecord(); err != nil {
+		return nil, err
+	}
+	return cr.readerState.rawFields, nil
+}
+
+// Line returns the line the last record read, or the last malformed one, started on
+func (cr *CsvReader) Line() int {
+	return cr.readerState.recordLine
+}
+
+func (cr *CsvReader) readRecord() ([]string, error) {
+	if cr.readerState.eof {
+		return nil, io.EOF
+	}
+
+	if !cr.readerState.started {
+		cr.readerState.started = true
+		cr.skipBOM()
+		if cr.sepLine && !cr.strict {
+			cr.skipSepLine()
+		}
+	}
+
+	for {
+		ch, err := cr.readByte()
+
+		// if end of file, return the last line if there is one
 		if err == io.EOF {
-			err := cr.appendLine()
-			if err != nil {
+			cr.readerState.eof = true
+			if !cr.readerState.inRecord {
+				return nil, io.EOF
+			}
+			if cr.readerState.escaping {
+				return nil, cr.unterminatedQuote()
+			}
+			if err := cr.checkRecordEnd(); err != nil {
 				return nil, err
 			}
-			return cr.readerState.records, nil
+			if cr.finalNewline {
+				return nil, cr.parseError(errNoFinalNewline)
+			}
+			return cr.appendLine()
 		}
 
 		if err != nil {
 			return nil, err
 		}
 
+		if !cr.readerState.inRecord && ch != '\r' && ch != '\n' {
+			cr.readerState.inRecord = true
+			cr.readerState.recordLine = cr.readerState.line
+		}
+
+		if cr.readerState.delimiterLine != 0 && ch != '\r' && ch != '\n' {
+			cr.readerState.delimiterLine = 0
+		}
+
+		endOfLine := false
 		switch ch {
 		case cr.delimiter:
 			err = cr.handleDelimiter()
@@ -80,24 +335,276 @@ func (cr *CsvReader) Read() ([][]string, error) {
 			err = cr.handleEscapeChar()
 		// in windows the newline is \r\n, so we can skip the \r and process the next byte which is the \n
 		case '\r':
+			err = cr.handleCR()
 		case '\n':
-			err = cr.handleNewLine()
+			endOfLine, err = cr.handleNewLine()
 		default:
 			err = cr.handleDefault(ch)
 		}
+		cr.readerState.afterCR = ch == '\r'
+
+		if cr.readerState.escaping && cr.runawayQuote() {
+			return nil, cr.unterminatedQuote()
+		}
 
 		if err != nil {
+			cr.skipLine()
 			return nil, err
 		}
+
+		if endOfLine {
+			return cr.appendLine()
+		}
+	}
+}
+
+func (cr *CsvReader) readByte() (byte, error) {
+	var ch byte
+	var err error
+	switch {
+	case cr.reader == nil:
+		if cr.readerState.pos >= len(cr.data) {
+			return 0, io.EOF
+		}
+		ch = cr.data[cr.readerState.pos]
+		cr.readerState.pos++
+	case cr.readerState.replay.Len() > 0:
+		ch, err = cr.readerState.replay.ReadByte()
+	default:
+		ch, err = cr.reader.ReadByte()
+	}
+	if err != nil {
+		return ch, err
+	}
+
+	// data can be read again from any offset, a stream only from what was kept of it
+	if cr.reader != nil && cr.readerState.escaping && cr.readerState.quotedLines > 0 {
+		if cr.readerState.quotedRaw.Len() < max(cr.rewindWindow, cr.maxQuotedBytes) {
+			cr.readerState.quotedRaw.WriteByte(ch)
+		} else {
+			cr.readerState.quotedOverflow = true
+		}
+	}
+
+	if ch == '\n' {
+		cr.readerState.newlineColumn = cr.readerState.column + 1
+		cr.readerState.line++
+		cr.readerState.column = 0
+	} else {
+		cr.readerState.column++
+	}
+
+	return ch, nil
+}
+
+func (cr *CsvReader) skipBOM() {
+	// only looking further once the first byte could start a BOM keeps a short input that is
+	// still being written, as followed by Follow, from blocking here
+	if first, err := cr.peek(1); err != nil || first[0] != utf8BOM[0] {
+		return
+	}
+	if bom, err := cr.peek(len(utf8BOM)); err == nil && bytes.Equal(bom, utf8BOM) {
+		cr.discard(len(utf8BOM))
+	}
+}
+
+// peek returns the next n bytes without reading them, or fewer and an error at the end of
+// the input. The bytes given back after an unterminated quoted field are not looked at.
+func (cr *CsvReader) peek(n int) ([]byte, error) {
+	if cr.reader != nil {
+		return cr.reader.Peek(n)
+	}
+
+	rest := cr.data[cr.readerState.pos:]
+	if len(rest) < n {
+		return rest, io.EOF
+	}
+	return rest[:n], nil
+}
+
+// discard skips n bytes without counting them as read
+func (cr *CsvReader) discard(n int) {
+	if cr.reader != nil {
+		cr.reader.Discard(n)
+		return
+	}
+	cr.readerState.pos += n
+}
+
+// peekByte returns the next byte without reading it
+func (cr *CsvReader) peekByte() (byte, error) {
+	if replay := cr.readerState.replay.Bytes(); len(replay) > 0 {
+		return replay[0], nil
+	}
+
+	next, err := cr.peek(1)
+	if err != nil {
+		return 0, err
+	}
+	return next[0], nil
+}
+
+// skipSepLine consumes a leading sep= line, as written for Excel, and reads the delimiter
+// from it. Bytes are only peeked at while they match, for the same reason as in skipBOM.
+func (cr *CsvReader) skipSepLine() {
+	const prefix = "sep="
+	for i := 0; i < len(prefix); i++ {
+		if next, err := cr.peek(i + 1); err != nil || next[i] != prefix[i] {
+			return
+		}
+	}
+
+	line, err := cr.peek(len(prefix) + 1)
+	if err != nil || line[len(prefix)] == '\r' || line[len(prefix)] == '\n' {
+		return
+	}
+	delimiter := line[len(prefix)]
+
+	// anything but the end of the line after the delimiter makes it an ordinary record
+	length := len(prefix) + 1
+scan:
+	for {
+		rest, err := cr.peek(length + 1)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return
+		}
+
+		switch rest[length] {
+		case '\r':
+			length++
+		case '\n':
+			length++
+			break scan
+		default:
+			return
+		}
+	}
+
+	for i := 0; i < length; i++ {
+		cr.readByte()
+	}
+	cr.delimiter = delimiter
+}
+
+// skipLine throws away the rest of the current line and the partially read record
+func (cr *CsvReader) skipLine() {
+	for cr.readerState.column != 0 {
+		if _, err := cr.readByte(); err != nil {
+			cr.readerState.eof = true
+			break
+		}
+	}
+
+	cr.discardRecord()
+}
+
+// runawayQuote reports whether the open quoted field has gone past the limits
+func (cr *CsvReader) runawayQuote() bool {
+	state := cr.readerState
+	return (cr.maxQuotedLines > 0 && state.quotedLines > cr.maxQuotedLines) ||
+		(cr.maxQuotedBytes > 0 && cr.fieldLen() > cr.maxQuotedBytes)
+}
+
+// unterminatedQuote reports the open quoted field of the current record at its opening quote.
+// Reading carries on from the line after that quote, the first place a record can plausibly
+// start, so that the records swallowed by the field are still read. When the field swallowed
+// more than the rewind window, reading carries on from the next line instead.
+func (cr *CsvReader) unterminatedQuote() error {
+	state := cr.readerState
+	err := &ParseError{
+		StartLine: state.recordLine,
+		Line:      state.quoteLine,
+		Column:    state.quoteColumn,
+		Err:       errUnterminatedQuote,
+	}
+
+	// still on the line of the quote, nothing was swallowed
+	if state.quotedLines == 0 || state.quotedOverflow {
+		cr.skipLine()
+		return err
+	}
+
+	if cr.reader == nil {
+		state.pos = state.quotedStart
+	} else {
+		// the swallowed bytes go back in front of those given back before and not read again yet
+		rest := make([]byte, 0, state.quotedRaw.Len()+state.replay.Len())
+		rest = append(append(rest, state.quotedRaw.Bytes()...), state.replay.Bytes()...)
+		state.replay.Reset()
+		state.replay.Write(rest)
+	}
+	state.line = state.quoteLine + 1
+	state.column = 0
+	state.afterCR = false
+	state.eof = false
+	cr.discardRecord()
+	return err
+}
+
+// discardRecord drops the partially read record after an error
+func (cr *CsvReader) discardRecord() {
+	cr.resetRecord()
+	// the first good record still decides the expected number of fields
+	if cr.readerState.lineNum > 1 {
+		cr.readerState.lineNum++
+	}
+}
+
+func (cr *CsvReader) parseError(err error) error {
+	return &ParseError{
+		StartLine: cr.readerState.recordLine,
+		Line:      cr.readerState.line,
+		Column:    cr.readerState.column,
+		Err:       err,
+	}
+}
+
+// newlineError reports a problem with the newline just read, at its position rather than
+// at the start of the next line
+func (cr *CsvReader) newlineError(err error) error {
+	line := cr.readerState.line - 1
+	startLine := line
+	if cr.readerState.inRecord {
+		startLine = cr.readerState.recordLine
+	}
+
+	return &ParseError{
+		StartLine: startLine,
+		Line:      line,
+		Column:    cr.readerState.newlineColumn,
+		Err:       err,
+	}
+}
+
+// checkRecordEnd rejects a record ending with a delimiter in strict mode
+func (cr *CsvReader) checkRecordEnd() error {
+	state := cr.readerState
+	if !cr.strict || state.delimiterLine == 0 {
+		return nil
+	}
+
+	return &ParseError{
+		StartLine: state.recordLine,
+		Line:      state.delimiterLine,
+		Column:    state.delimiterColumn,
+		Err:       errTrailingDelimiter,
 	}
 }
 
 func (cr *CsvReader) handleDelimiter() error {
 	if cr.readerState.escaping {
-		cr.readerState.field.WriteByte(cr.delimiter)
+		cr.writeField(cr.delimiter)
 		return nil
 	}
 
+	if cr.strict {
+		cr.readerState.delimiterLine = cr.readerState.line
+		cr.readerState.delimiterColumn = cr.readerState.column
+	}
+
 	err := cr.appendField()
 	if err != nil {
 		return err
@@ -108,48 +615,145 @@ func (cr *CsvReader) handleDelimiter() error {
 
 func (cr *CsvReader) handleEscapeChar() error {
 	if cr.readerState.escaping {
-		nextCh, peakErr := cr.reader.Peek(1)
-		if peakErr == nil && nextCh[0] == cr.escapeChar {
-			cr.readerState.field.WriteByte(cr.escapeChar)
-			cr.reader.ReadByte()
+		if next, err := cr.peekByte(); err == nil && next == cr.escapeChar {
+			cr.writeField(cr.escapeChar)
+			cr.readByte()
 		} else {
 			cr.readerState.escaping = false
 			cr.readerState.escaped = true
 		}
-	} else if cr.readerState.field.Len() == 0 {
+	} else if cr.fieldLen() == 0 && !cr.readerState.escaped {
 		cr.readerState.escaping = true
 		cr.readerState.escaped = false
+		cr.readerState.quoteLine = cr.readerState.line
+		cr.readerState.quoteColumn = cr.readerState.column
+		cr.readerState.quotedLines = 0
+		cr.readerState.quotedRaw.Reset()
+		cr.readerState.quotedOverflow = false
+	} else if cr.lenient {
+		cr.writeField(cr.escapeChar)
 	} else {
-		return fmt.Errorf("%w at line: %d", errUnexpectedEscapeChar, cr.readerState.lineNum)
+		return cr.parseError(errUnexpectedEscapeChar)
 	}
 
 	return nil
 }
 
-func (cr *CsvReader) handleNewLine() error {
-	if cr.readerState.escaping {
-		cr.readerState.field.WriteByte('\n')
+// handleCR drops a CR, which in strict mode must start a CRLF outside of quotes
+func (cr *CsvReader) handleCR() error {
+	if !cr.strict || cr.readerState.escaping {
 		return nil
 	}
-	err := cr.appendLine()
-	if err != nil {
-		return err
-	}
 
+	if next, err := cr.peekByte(); err != nil || next != '\n' {
+		return cr.parseError(errLineBreak)
+	}
 	return nil
 }
 
+// handleNewLine returns true when the newline ends the current record
+func (cr *CsvReader) handleNewLine() (bool, error) {
+	if cr.readerState.escaping {
+		cr.writeField('\n')
+		cr.readerState.quotedLines++
+		if cr.readerState.quotedLines == 1 {
+			cr.readerState.quotedStart = cr.readerState.pos
+		}
+		return false, nil
+	}
+
+	if cr.strict {
+		if !cr.readerState.afterCR {
+			return false, cr.newlineError(errLineBreak)
+		}
+		if !cr.readerState.inRecord {
+			return false, cr.newlineError(errBlankLine)
+		}
+		if err := cr.checkRecordEnd(); err != nil {
+			return false, err
+		}
+	}
+
+	// blank lines are skipped
+	return cr.readerState.inRecord, nil
+}
+
 func (cr *CsvReader) handleDefault(ch byte) error {
-	if cr.readerState.escaped {
-		return fmt.Errorf("%w at line: %d", errMismatchedEscapeChar, cr.readerState.lineNum)
+	if cr.readerState.escaped && !cr.lenient {
+		return cr.parseError(errMismatchedEscapeChar)
 	}
 
-	return cr.readerState.field.WriteByte(ch)
+	cr.writeField(ch)
+	return nil
+}
+
+// writeField adds ch, the byte just read, to the current field. Read from data, the field
+// stays a span of it for as long as its bytes are the ones read, and is copied otherwise.
+func (cr *CsvReader) writeField(ch byte) {
+	state := cr.readerState
+	if cr.reader == nil && !state.copied {
+		at := state.pos - 1
+		if state.spanLen == 0 {
+			state.spanStart = at
+		}
+		if at == state.spanStart+state.spanLen && cr.data[at] == ch {
+			state.spanLen++
+			return
+		}
+
+		state.field.Write(cr.data[state.spanStart : state.spanStart+state.spanLen])
+		state.copied = true
+	}
+	state.field.WriteByte(ch)
+}
+
+func (cr *CsvReader) fieldLen() int {
+	if cr.reader == nil && !cr.readerState.copied {
+		return cr.readerState.spanLen
+	}
+	return cr.readerState.field.Len()
+}
+
+// fieldBytes returns the field read from data, as a span of it unless it had to be copied
+func (cr *CsvReader) fieldBytes() []byte {
+	state := cr.readerState
+	switch {
+	case state.copied:
+		return bytes.Clone(state.field.Bytes())
+	case state.spanLen == 0:
+		return nil
+	}
+	// the capacity is cut so that appending to the field copies it rather than writing to data
+	end := state.spanStart + state.spanLen
+	return cr.data[state.spanStart:end:end]
 }
 
 func (cr *CsvReader) appendField() error {
-	cr.readerState.record = append(cr.readerState.record, cr.readerState.field.String())
+	state := cr.readerState
+	selected := state.projection == nil ||
+		(state.fieldIndex < len(state.projection) && state.projection[state.fieldIndex] != nil)
+	switch {
+	case !selected:
+		// only the selected fields are turned into strings
+	case cr.reader == nil && state.projection == nil:
+		state.rawRecord = append(state.rawRecord, cr.fieldBytes())
+	case cr.reader == nil:
+		field := cr.fieldBytes()
+		for _, pos := range state.projection[state.fieldIndex] {
+			state.rawRecord[pos] = field
+		}
+	case state.projection == nil:
+		state.record = append(state.record, state.field.String())
+	default:
+		value := state.field.String()
+		for _, pos := range state.projection[state.fieldIndex] {
+			state.record[pos] = value
+		}
+	}
+	state.fieldIndex++
 	cr.readerState.field.Reset()
+	cr.readerState.spanLen = 0
+	cr.readerState.copied = false
 
 	cr.readerState.escaping = false
 	cr.readerState.escaped = false
@@ -157,19 +761,77 @@ func (cr *CsvReader) appendField() error {
 	return nil
 }
 
-func (cr *CsvReader) appendLine() error {
+func (cr *CsvReader) appendLine() ([]string, error) {
 	cr.appendField()
+	record := cr.readerState.record
+	if cr.reader == nil {
+		state := cr.readerState
+		state.rawFields, state.rawRecord = state.rawRecord, state.rawFields
+		if !state.fieldsOnly {
+			record = cr.rawStrings(state.rawFields)
+		}
+	}
+
+	var err error
 	if cr.readerState.lineNum == 1 {
-		cr.readerState.expectedNumOfFields = len(cr.readerState.record)
-	} else if len(cr.readerState.record) != cr.readerState.expectedNumOfFields {
-		return fmt.Errorf("%w at line: %d", errWrongNumFields, cr.readerState.lineNum)
+		cr.readerState.expectedNumOfFields = cr.readerState.fieldIndex
+		if len(cr.readerState.projection) > cr.readerState.fieldIndex {
+			err = fmt.Errorf("%w: index %d", ErrUnknownColumn, len(cr.readerState.projection)-1)
+		}
+	} else if cr.readerState.fieldIndex != cr.readerState.expectedNumOfFields && !cr.lenient {
+		err = &ParseError{
+			StartLine: cr.readerState.recordLine,
+			Line:      cr.readerState.recordLine,
+			Column:    1,
+			Err:       errWrongNumFields,
+		}
 	}
 
-	cr.readerState.records = append(cr.readerState.records, cr.readerState.record)
-	cr.readerState.record = []string{}
+	cr.resetRecord()
 	cr.readerState.lineNum++
 
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// rawStrings turns the fields read from data into strings
+func (cr *CsvReader) rawStrings(fields [][]byte) []string {
+	record := make([]string, len(fields))
+	for i, field := range fields {
+		if cr.unsafeStrings && len(field) > 0 {
+			record[i] = unsafe.String(&field[0], len(field))
+		} else {
+			record[i] = string(field)
+		}
+	}
+	return record
+}
+
+func (cr *CsvReader) resetRecord() {
+	cr.readerState.field.Reset()
+	cr.readerState.spanLen = 0
+	cr.readerState.copied = false
+	switch {
+	case cr.reader == nil:
+		// the raw record is reused, sized to take the projected fields by position
+		cr.readerState.rawRecord = cr.readerState.rawRecord[:0]
+		for i := 0; i < cr.readerState.projectedLen; i++ {
+			cr.readerState.rawRecord = append(cr.readerState.rawRecord, nil)
+		}
+	case cr.readerState.projection == nil:
+		cr.readerState.record = []string{}
+	default:
+		cr.readerState.record = make([]string, cr.readerState.projectedLen)
+	}
+	cr.readerState.fieldIndex = 0
+	cr.readerState.inRecord = false
+	cr.readerState.delimiterLine = 0
+	cr.readerState.quotedLines = 0
+	cr.readerState.quotedRaw.Reset()
+	cr.readerState.quotedOverflow = false
+
 	cr.readerState.escaping = false
 	cr.readerState.escaped = false
-	return nil
 }
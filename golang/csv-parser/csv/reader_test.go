@@ -190,3 +190,355 @@ func BenchmarkRead(b *testing.B) {
 		}
 	}
 }
+
+func TestReadRecord(t *testing.T) {
+	testCases := []struct {
+		name           string
+		input          string
+		options        []ReaderOption
+		expectedHeader []string
+		expected       [][]string
+		errs           []error
+	}{
+		{
+			name:     "trailing newline",
+			input:    "a,b\r\nc,d\r\n",
+			expected: [][]string{{"a", "b"}, {"c", "d"}},
+		},
+		{
+			name:     "blank lines are skipped",
+			input:    "a,b\n\nc,d\n\n",
+			expected: [][]string{{"a", "b"}, {"c", "d"}},
+		},
+		{
+			name:           "header",
+			input:          "id,name\n1,x\n2,y\n",
+			options:        []ReaderOption{WithHeader(true)},
+			expectedHeader: []string{"id", "name"},
+			expected:       [][]string{{"1", "x"}, {"2", "y"}},
+		},
+		{
+			name:     "bom is stripped",
+			input:    "\xEF\xBB\xBFa,b\n",
+			expected: [][]string{{"a", "b"}},
+		},
+		{
+			name:     "continue after errors",
+			input:    "a,b\nc\n\"d\"e,f\ng,h\n",
+			expected: [][]string{{"a", "b"}, {"g", "h"}},
+			errs:     []error{errWrongNumFields, errMismatchedEscapeChar},
+		},
+		{
+			name:     "lenient",
+			input:    "a,b\nc\nd\"e,\"f\"g\n",
+			options:  []ReaderOption{WithLenient(true)},
+			expected: [][]string{{"a", "b"}, {"c"}, {"d\"e", "fg"}},
+		},
+		{
+			name:     "latin1",
+			input:    "caf\xE9,na\xEFve\n",
+			options:  []ReaderOption{WithEncoding(EncodingLatin1)},
+			expected: [][]string{{"café", "naïve"}},
+		},
+		{
+			name:     "utf-16le with bom",
+			input:    "\xFF\xFEa\x00,\x00\xE9\x00\n\x00",
+			options:  []ReaderOption{WithEncoding(EncodingUTF16LE)},
+			expected: [][]string{{"a", "é"}},
+		},
+		{
+			name:           "sep line sets the delimiter",
+			input:          "\xEF\xBB\xBFsep=;\r\nid;name\r\n1;x,y\r\n",
+			options:        []ReaderOption{WithHeader(true), WithSepLineDetection(true)},
+			expectedHeader: []string{"id", "name"},
+			expected:       [][]string{{"1", "x,y"}},
+		},
+		{
+			name:     "sep line only",
+			input:    "sep=|",
+			options:  []ReaderOption{WithSepLineDetection(true)},
+			expected: [][]string{},
+		},
+		{
+			name:     "not a sep line",
+			input:    "sep=;x\nsep=;\n",
+			options:  []ReaderOption{WithSepLineDetection(true)},
+			expected: [][]string{{"sep=;x"}, {"sep=;"}},
+		},
+		{
+			name:     "sep line without detection",
+			input:    "sep=;\na;b\n",
+			expected: [][]string{{"sep=;"}, {"a;b"}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			assertMmapAgrees(t, currTestCase.input, currTestCase.options...)
+			csvReader := NewCsvReader(strings.NewReader(currTestCase.input), currTestCase.options...)
+			header, err := csvReader.Header()
+			assert.NoError(t, err)
+			assert.Equal(t, currTestCase.expectedHeader, header)
+
+			records := [][]string{}
+			var errs []error
+			for {
+				record, err := csvReader.ReadRecord()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				records = append(records, record)
+			}
+
+			assert.Equal(t, currTestCase.expected, records)
+			assert.Equal(t, len(currTestCase.errs), len(errs))
+			for i := range errs {
+				if i < len(currTestCase.errs) {
+					assert.True(t, errors.Is(errs[i], currTestCase.errs[i]))
+				}
+			}
+		})
+	}
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	csvReader := NewCsvReader(strings.NewReader("a,b\n\"c\nd\",e\nf,g\"h\n"))
+	_, err := csvReader.Read()
+
+	var parseErr *ParseError
+	assert.True(t, errors.As(err, &parseErr))
+	assert.Equal(t, 4, parseErr.StartLine)
+	assert.Equal(t, 4, parseErr.Line)
+	assert.Equal(t, 4, parseErr.Column)
+	assert.True(t, errors.Is(err, errUnexpectedEscapeChar))
+}
+
+func TestLine(t *testing.T) {
+	csvReader := NewCsvReader(strings.NewReader("id,note\n1,\"two\nlines\"\n\n2,x\n"), WithHeader(true))
+
+	lines := []int{}
+	for {
+		_, err := csvReader.ReadRecord()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		lines = append(lines, csvReader.Line())
+	}
+	assert.Equal(t, []int{2, 5}, lines)
+}
+
+func TestStrict(t *testing.T) {
+	// a deviation is its error and position, as line:column
+	type deviation struct {
+		err      error
+		position string
+	}
+
+	testCases := []struct {
+		name       string
+		input      string
+		options    []ReaderOption
+		expected   [][]string
+		deviations []deviation
+	}{
+		{
+			name:     "quoted line breaks and trailing delimiter",
+			input:    "a,\"b\r\nc\",\"\"\"\"\r\n1,2,\r\n",
+			expected: [][]string{{"a", "b\nc", "\""}},
+			deviations: []deviation{
+				{errTrailingDelimiter, "3:4"},
+			},
+		},
+		{
+			name:     "bare LF and CR",
+			input:    "a,b\n1,2\r\n3\r4,5\r\n",
+			expected: [][]string{{"1", "2"}},
+			deviations: []deviation{
+				{errLineBreak, "1:4"},
+				{errLineBreak, "3:2"},
+			},
+		},
+		{
+			name:       "blank line",
+			input:      "a,b\r\n\r\n1,2\r\n",
+			expected:   [][]string{{"a", "b"}, {"1", "2"}},
+			deviations: []deviation{{errBlankLine, "2:2"}},
+		},
+		{
+			name:     "quotes in unquoted fields even when lenient",
+			input:    "a,b\"c\r\n\"d\"e,f\r\n1,2\r\n",
+			options:  []ReaderOption{WithLenient(true)},
+			expected: [][]string{{"1", "2"}},
+			deviations: []deviation{
+				{errUnexpectedEscapeChar, "1:4"},
+				{errMismatchedEscapeChar, "2:4"},
+			},
+		},
+		{
+			name:     "missing final newline is allowed",
+			input:    "a,b\r\n1,2",
+			expected: [][]string{{"a", "b"}, {"1", "2"}},
+		},
+		{
+			name:       "missing final newline is rejected",
+			input:      "a,b\r\n1,2",
+			options:    []ReaderOption{WithFinalNewline(true)},
+			expected:   [][]string{{"a", "b"}},
+			deviations: []deviation{{errNoFinalNewline, "2:3"}},
+		},
+		{
+			name:       "trailing delimiter at the end of the input",
+			input:      "a,b\r\n1,",
+			expected:   [][]string{{"a", "b"}},
+			deviations: []deviation{{errTrailingDelimiter, "2:2"}},
+		},
+		{
+			name:     "sep line is a record",
+			input:    "sep=;\r\na;b\r\n",
+			options:  []ReaderOption{WithSepLineDetection(true)},
+			expected: [][]string{{"sep=;"}, {"a;b"}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			options := append([]ReaderOption{WithStrict(true)}, currTestCase.options...)
+			assertMmapAgrees(t, currTestCase.input, options...)
+			csvReader := NewCsvReader(strings.NewReader(currTestCase.input), options...)
+
+			var records [][]string
+			var deviations []deviation
+			for {
+				record, err := csvReader.ReadRecord()
+				if err == io.EOF {
+					break
+				}
+				var parseErr *ParseError
+				if errors.As(err, &parseErr) {
+					deviations = append(deviations, deviation{parseErr.Err, fmt.Sprintf("%d:%d", parseErr.Line, parseErr.Column)})
+					continue
+				}
+				assert.NoError(t, err)
+				records = append(records, record)
+			}
+
+			assert.Equal(t, currTestCase.expected, records)
+			assert.Equal(t, currTestCase.deviations, deviations)
+		})
+	}
+}
+
+func TestUnterminatedQuote(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		options  []ReaderOption
+		expected [][]string
+		// positions of the opening quotes reported, as line:column
+		errs []string
+		// rewind window, the default when 0
+		window int
+	}{
+		{
+			name:     "open at the end of the input",
+			input:    "a,b\n1,\"two\n3,4\n5,6\n",
+			expected: [][]string{{"a", "b"}, {"3", "4"}, {"5", "6"}},
+			errs:     []string{"2:3"},
+		},
+		{
+			name:     "closed by a later stray quote",
+			input:    "a,b\n1,\"x\n2,3\n4,\"y\"\n5,6\n",
+			options:  []ReaderOption{WithMaxQuotedLines(1)},
+			expected: [][]string{{"a", "b"}, {"2", "3"}, {"4", "y"}, {"5", "6"}},
+			errs:     []string{"2:3"},
+		},
+		{
+			name:     "line breaks within the limit",
+			input:    "a,b\n1,\"x\ny\"\n",
+			options:  []ReaderOption{WithMaxQuotedLines(1)},
+			expected: [][]string{{"a", "b"}, {"1", "x\ny"}},
+		},
+		{
+			name:     "too long on its own line",
+			input:    "a,b\n1,\"xxxxxxxx\n2,3\n",
+			options:  []ReaderOption{WithMaxQuotedBytes(4)},
+			expected: [][]string{{"a", "b"}, {"2", "3"}},
+			errs:     []string{"2:3"},
+		},
+		{
+			name:     "swallowed escaped quotes are read again",
+			input:    "a,b\n1,\"x\nc,\"\"\n",
+			expected: [][]string{{"a", "b"}, {"c", ""}},
+			errs:     []string{"2:3"},
+		},
+		{
+			name:     "another runaway in the swallowed records",
+			input:    "a,b\n1,\"x\n2,3\n4,\"y\n5,6",
+			options:  []ReaderOption{WithMaxQuotedLines(1)},
+			expected: [][]string{{"a", "b"}, {"2", "3"}, {"5", "6"}},
+			errs:     []string{"2:3", "4:3"},
+		},
+		{
+			name:     "swallowed more than the rewind window at the end of the input",
+			input:    "a,b\n1,\"x\n2,3\n4,5\n6,7\n",
+			expected: [][]string{{"a", "b"}},
+			errs:     []string{"2:3"},
+			window:   4,
+		},
+		{
+			name:     "swallowed more than the rewind window carries on where it stopped",
+			input:    "a,b\n1,\"x\n2,3\n4,5\n6,7\n8,9\n",
+			options:  []ReaderOption{WithMaxQuotedLines(2)},
+			expected: [][]string{{"a", "b"}, {"6", "7"}, {"8", "9"}},
+			errs:     []string{"2:3"},
+			window:   4,
+		},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			csvReader := NewCsvReader(strings.NewReader(currTestCase.input), currTestCase.options...)
+			if currTestCase.window > 0 {
+				csvReader.rewindWindow = currTestCase.window
+			} else {
+				// the whole of a mapped file can be read again, there is no window to go past
+				assertMmapAgrees(t, currTestCase.input, currTestCase.options...)
+			}
+
+			var records [][]string
+			var errs []string
+			for {
+				record, err := csvReader.ReadRecord()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					assert.True(t, errors.Is(err, errUnterminatedQuote), err)
+					var parseErr *ParseError
+					if errors.As(err, &parseErr) {
+						errs = append(errs, fmt.Sprintf("%d:%d", parseErr.Line, parseErr.Column))
+					}
+					continue
+				}
+				records = append(records, record)
+			}
+
+			assert.Equal(t, currTestCase.expected, records)
+			assert.Equal(t, currTestCase.errs, errs)
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package csv
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// StreamRecord is a record flowing through a RecordStream, or the error that ended it
+type StreamRecord struct {
+	Record []string
+	Err    error
+}
+
+// RecordStream is a stage of a pipeline of goroutines started by Stream. Stages hand records
+// to each other over unbuffered channels, so a slow consumer holds back the reader. The first
+// error, from the reader or a stage, is the last item delivered, after every record before it,
+// and stops every stage. Cancelling the context or calling Close stops them all as well.
+type RecordStream struct {
+	parent  context.Context
+	stop    func()
+	records <-chan StreamRecord
+}
+
+// Stream reads the records of r in a goroutine, stopping at the first error, including a
+// *ParseError. Add stages with Map, Filter and ParallelMap and read the results from Records.
+func Stream(ctx context.Context, r RecordReader) *RecordStream {
+	stageCtx, cancel := context.WithCancel(ctx)
+	out := make(chan StreamRecord)
+
+	go func() {
+		defer close(out)
+		defer cancel()
+
+		for {
+			record, err := r.ReadRecord()
+			if err == io.EOF {
+				return
+			}
+			if !send(stageCtx, out, StreamRecord{Record: record, Err: err}) || err != nil {
+				return
+			}
+		}
+	}()
+
+	return &RecordStream{parent: ctx, stop: cancel, records: out}
+}
+
+// Records returns the channel the records, and any error, of the last stage come out of.
+// It is closed once the stream is done.
+func (s *RecordStream) Records() <-chan StreamRecord {
+	return s.records
+}
+
+// Close stops every stage. Records still in flight are dropped and the channel of Records
+// is closed shortly after.
+func (s *RecordStream) Close() {
+	s.stop()
+}
+
+// Map replaces every record with what fn returns for it
+func (s *RecordStream) Map(fn func(record []string) ([]string, error)) *RecordStream {
+	return s.next(func(ctx context.Context, stop func(), out chan<- StreamRecord) {
+		for item := range s.records {
+			if item.Err == nil {
+				item.Record, item.Err = fn(item.Record)
+			}
+			if !send(ctx, out, item) || item.Err != nil {
+				stop()
+				return
+			}
+		}
+	})
+}
+
+// Filter keeps the records fn returns true for
+func (s *RecordStream) Filter(fn func(record []string) (bool, error)) *RecordStream {
+	return s.next(func(ctx context.Context, stop func(), out chan<- StreamRecord) {
+		for item := range s.records {
+			if item.Err == nil {
+				var keep bool
+				if keep, item.Err = fn(item.Record); item.Err == nil && !keep {
+					continue
+				}
+			}
+			if !send(ctx, out, item) || item.Err != nil {
+				stop()
+				return
+			}
+		}
+	})
+}
+
+// ParallelMap is Map calling fn from several goroutines at once. With inOrder the records come
+// out in the order they came in, at most workers records being worked on ahead of the
+// slowest one; otherwise they come out as soon as they are done.
+func (s *RecordStream) ParallelMap(workers int, inOrder bool, fn func(record []string) ([]string, error)) *RecordStream {
+	workers = max(workers, 1)
+	if inOrder {
+		return s.next(func(ctx context.Context, stop func(), out chan<- StreamRecord) {
+			s.orderedMap(ctx, stop, out, workers, fn)
+		})
+	}
+
+	return s.next(func(ctx context.Context, stop func(), out chan<- StreamRecord) {
+		// sends are serialized so that nothing follows an error
+		var mu sync.Mutex
+		failed := false
+
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for item := range s.records {
+					if item.Err == nil {
+						item.Record, item.Err = fn(item.Record)
+					}
+
+					mu.Lock()
+					if !failed && (!send(ctx, out, item) || item.Err != nil) {
+						failed = true
+						stop()
+					}
+					done := failed
+					mu.Unlock()
+
+					if done {
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+type job struct {
+	record []string
+	result chan<- StreamRecord
+}
+
+// orderedMap queues a result channel per record in input order, which the workers fill in
+// whatever order they finish
+func (s *RecordStream) orderedMap(ctx context.Context, stop func(), out chan<- StreamRecord, workers int, fn func([]string) ([]string, error)) {
+	jobs := make(chan job)
+	pending := make(chan chan StreamRecord, workers)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(pending)
+		defer close(jobs)
+
+		for item := range s.records {
+			result := make(chan StreamRecord, 1)
+			select {
+			case pending <- result:
+			case <-ctx.Done():
+				return
+			}
+
+			if item.Err != nil {
+				result <- item
+				return
+			}
+
+			select {
+			case jobs <- job{record: item.Record, result: result}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				record, err := fn(j.record)
+				j.result <- StreamRecord{Record: record, Err: err}
+			}
+		}()
+	}
+
+	for result := range pending {
+		var item StreamRecord
+		select {
+		case item = <-result:
+		case <-ctx.Done():
+			stop()
+			return
+		}
+
+		if !send(ctx, out, item) || item.Err != nil {
+			stop()
+			return
+		}
+	}
+}
+
+// next starts a stage reading from s. The stage's stop cancels it and every stage before it,
+// but not the ones after it, which still deliver what they have.
+func (s *RecordStream) next(run func(ctx context.Context, stop func(), out chan<- StreamRecord)) *RecordStream {
+	ctx, cancel := context.WithCancel(s.parent)
+	stop := func() {
+		cancel()
+		s.stop()
+	}
+	out := make(chan StreamRecord)
+
+	go func() {
+		defer close(out)
+		defer cancel()
+		run(ctx, stop, out)
+	}()
+
+	return &RecordStream{parent: s.parent, stop: stop, records: out}
+}
+
+func send(ctx context.Context, out chan<- StreamRecord, item StreamRecord) bool {
+	// a stopped stage must not send, even when the receiver happens to be ready
+	if ctx.Err() != nil {
+		return false
+	}
+
+	select {
+	case out <- item:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,196 @@
+package csv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func collect(s *RecordStream) ([]string, error) {
+	var values []string
+	var err error
+	for item := range s.Records() {
+		if item.Err != nil {
+			err = item.Err
+			continue
+		}
+		values = append(values, strings.Join(item.Record, ","))
+	}
+	return values, err
+}
+
+// doubled returns 2, 4, ... 2n
+func doubled(n int) []string {
+	var values []string
+	for i := 1; i <= n; i++ {
+		values = append(values, strconv.Itoa(i*2))
+	}
+	return values
+}
+
+func numbers(n int) string {
+	var sb strings.Builder
+	sb.WriteString("n\n")
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&sb, "%d\n", i)
+	}
+	return sb.String()
+}
+
+var errOdd = errors.New("odd")
+
+func TestStream(t *testing.T) {
+	double := func(record []string) ([]string, error) {
+		n, _ := strconv.Atoi(record[0])
+		return []string{strconv.Itoa(n * 2)}, nil
+	}
+	even := func(record []string) (bool, error) {
+		n, _ := strconv.Atoi(record[0])
+		return n%2 == 0, nil
+	}
+	slowDouble := func(record []string) ([]string, error) {
+		time.Sleep(time.Duration(rand.Intn(200)) * time.Microsecond)
+		return double(record)
+	}
+	failOnSeven := func(record []string) ([]string, error) {
+		if record[0] == "7" {
+			return nil, errOdd
+		}
+		return record, nil
+	}
+
+	testCases := []struct {
+		name     string
+		input    string
+		build    func(s *RecordStream) *RecordStream
+		expected []string
+		sorted   bool
+		err      error
+	}{
+		{
+			name:     "map and filter",
+			input:    numbers(6),
+			build:    func(s *RecordStream) *RecordStream { return s.Filter(even).Map(double) },
+			expected: []string{"4", "8", "12"},
+		},
+		{
+			name:     "parallel map in order",
+			input:    numbers(200),
+			build:    func(s *RecordStream) *RecordStream { return s.ParallelMap(8, true, slowDouble).Filter(even) },
+			expected: doubled(200),
+		},
+		{
+			name:     "parallel map out of order",
+			input:    numbers(200),
+			build:    func(s *RecordStream) *RecordStream { return s.ParallelMap(8, false, slowDouble) },
+			expected: doubled(200),
+			sorted:   true,
+		},
+		{
+			name:     "error after the records before it",
+			input:    numbers(20),
+			build:    func(s *RecordStream) *RecordStream { return s.Map(failOnSeven).Map(double) },
+			expected: []string{"2", "4", "6", "8", "10", "12"},
+			err:      errOdd,
+		},
+		{
+			name:     "error in order",
+			input:    numbers(20),
+			build:    func(s *RecordStream) *RecordStream { return s.ParallelMap(4, true, failOnSeven) },
+			expected: []string{"1", "2", "3", "4", "5", "6"},
+			err:      errOdd,
+		},
+		{
+			name:  "parse error",
+			input: "a,b\n1,2\n3\n4,5\n",
+			build: func(s *RecordStream) *RecordStream {
+				return s.ParallelMap(2, true, func(r []string) ([]string, error) { return r, nil })
+			},
+			expected: []string{"1,2"},
+			err:      errWrongNumFields,
+		},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			reader := NewCsvReader(strings.NewReader(currTestCase.input), WithHeader(true))
+			values, err := collect(currTestCase.build(Stream(context.Background(), reader)))
+			if currTestCase.err != nil {
+				assert.True(t, errors.Is(err, currTestCase.err), err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if currTestCase.sorted {
+				sort.Slice(values, func(i, j int) bool {
+					a, _ := strconv.Atoi(values[i])
+					b, _ := strconv.Atoi(values[j])
+					return a < b
+				})
+			}
+			assert.Equal(t, currTestCase.expected, values)
+		})
+	}
+}
+
+// endlessReader counts how many records were read from it
+type endlessReader struct {
+	reads atomic.Int64
+}
+
+func (er *endlessReader) Header() ([]string, error) {
+	return nil, nil
+}
+
+func (er *endlessReader) ReadRecord() ([]string, error) {
+	return []string{strconv.FormatInt(er.reads.Add(1), 10)}, nil
+}
+
+func TestStreamBackpressure(t *testing.T) {
+	reader := &endlessReader{}
+	s := Stream(context.Background(), reader).ParallelMap(4, true, func(r []string) ([]string, error) { return r, nil })
+
+	first := <-s.Records()
+	assert.Equal(t, []string{"1"}, first.Record)
+
+	time.Sleep(20 * time.Millisecond)
+	// the reader is held back by the consumer, only the records in flight were read
+	assert.Less(t, reader.reads.Load(), int64(12))
+
+	s.Close()
+	for range s.Records() {
+	}
+}
+
+func TestStreamCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := Stream(ctx, &endlessReader{}).Map(func(r []string) ([]string, error) { return r, nil })
+
+	<-s.Records()
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		for range s.Records() {
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream not closed after cancel")
+	}
+}
@@ -0,0 +1,682 @@
+package csv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errUnknownFieldType = errors.New("unknown field type")
+
+// Table holds records column by column, each column typed, so that a file of numbers takes
+// 8 bytes a value instead of a string each. Empty fields are nulls, whatever the type.
+type Table struct {
+	columns []*TableColumn
+	index   map[string]int
+	numRows int
+}
+
+type tableLoader struct {
+	types map[string]FieldType
+}
+
+type TableOption func(*tableLoader)
+
+// WithColumnTypes fixes the type of the named columns instead of inferring it. Loading fails
+// with a field of such a column that does not parse as its type.
+func WithColumnTypes(types map[string]FieldType) TableOption {
+	return func(loader *tableLoader) {
+		loader.types = types
+	}
+}
+
+// LoadTable reads every record of r into a Table. Columns are named after the header, or col1,
+// col2 and so on without one, and records longer than the header add columns. The type of a
+// column is the narrowest of TypeInt, TypeFloat, TypeBool, TypeDate and TypeString that all of
+// its values have; an integer column turns into a float column when a fraction comes along,
+// and any column turns into a string column on a value that does not fit, which then holds
+// the earlier fields as they were read. Numbers are only inferred when they are written back
+// as they were read, so that 1.50 or an ID too long for a float64 stays a string.
+func LoadTable(r RecordReader, options ...TableOption) (*Table, error) {
+	loader := &tableLoader{}
+	for _, op := range options {
+		op(loader)
+	}
+
+	header, err := r.Header()
+	if err != nil {
+		return nil, err
+	}
+
+	for name, typ := range loader.types {
+		switch typ {
+		case TypeString, TypeInt, TypeFloat, TypeBool, TypeDate:
+		default:
+			return nil, fmt.Errorf("%w: %q for column %s", errUnknownFieldType, typ, name)
+		}
+	}
+
+	t := &Table{index: map[string]int{}}
+	for _, name := range header {
+		t.addColumn(name, loader.types[name])
+	}
+
+	for {
+		record, err := r.ReadRecord()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for len(t.columns) < len(record) {
+			name := fmt.Sprintf("col%d", len(t.columns)+1)
+			t.addColumn(name, loader.types[name])
+		}
+		for i, column := range t.columns {
+			field := ""
+			if i < len(record) {
+				field = record[i]
+			}
+			if err := column.append(field); err != nil {
+				return nil, fmt.Errorf("%w: column %s, record %d", err, column.name, t.numRows+1)
+			}
+		}
+		t.numRows++
+	}
+
+	for _, column := range t.columns {
+		column.finish()
+	}
+	return t, nil
+}
+
+func (t *Table) addColumn(name string, fixedType FieldType) {
+	column := &TableColumn{name: name, typ: fixedType, fixed: fixedType != ""}
+	// a column added by a long record is null in the records before it
+	for i := 0; i < t.numRows; i++ {
+		column.appendNull()
+	}
+
+	if _, ok := t.index[name]; !ok {
+		t.index[name] = len(t.columns)
+	}
+	t.columns = append(t.columns, column)
+}
+
+func (t *Table) NumRows() int {
+	return t.numRows
+}
+
+func (t *Table) NumColumns() int {
+	return len(t.columns)
+}
+
+// Names returns the column names in order
+func (t *Table) Names() []string {
+	names := make([]string, len(t.columns))
+	for i, column := range t.columns {
+		names[i] = column.name
+	}
+	return names
+}
+
+// Column returns the column with the given name, the first one when several share it
+func (t *Table) Column(name string) (*TableColumn, error) {
+	i, ok := t.index[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownColumn, name)
+	}
+	return t.columns[i], nil
+}
+
+// ColumnAt returns the column at the 0-based position i
+func (t *Table) ColumnAt(i int) *TableColumn {
+	return t.columns[i]
+}
+
+// Select returns a table of the named columns, in the order given. The columns are shared.
+func (t *Table) Select(names ...string) (*Table, error) {
+	selected := &Table{index: map[string]int{}, numRows: t.numRows}
+	for _, name := range names {
+		column, err := t.Column(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := selected.index[name]; !ok {
+			selected.index[name] = len(selected.columns)
+		}
+		selected.columns = append(selected.columns, column)
+	}
+	return selected, nil
+}
+
+// Slice returns a copy of the rows from start up to but not including end
+func (t *Table) Slice(start, end int) *Table {
+	start = min(max(start, 0), t.numRows)
+	end = min(max(end, start), t.numRows)
+
+	rows := make([]int, end-start)
+	for i := range rows {
+		rows[i] = start + i
+	}
+	return t.take(rows)
+}
+
+// Filter returns a copy of the rows keep returns true for
+func (t *Table) Filter(keep func(row int) bool) *Table {
+	var rows []int
+	for row := 0; row < t.numRows; row++ {
+		if keep(row) {
+			rows = append(rows, row)
+		}
+	}
+	return t.take(rows)
+}
+
+// SortKey orders a table by a column, nulls last either way
+type SortKey struct {
+	Column     string
+	Descending bool
+}
+
+// Sort returns a copy of the table sorted by the keys, keeping the order of rows that compare
+// equal
+func (t *Table) Sort(keys ...SortKey) (*Table, error) {
+	columns := make([]*TableColumn, len(keys))
+	for i, key := range keys {
+		column, err := t.Column(key.Column)
+		if err != nil {
+			return nil, err
+		}
+		columns[i] = column
+	}
+
+	rows := make([]int, t.numRows)
+	for i := range rows {
+		rows[i] = i
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		for k, column := range columns {
+			c := column.compare(rows[i], rows[j])
+			if c == 0 {
+				continue
+			}
+			// nulls stay last when descending
+			if keys[k].Descending && !column.IsNull(rows[i]) && !column.IsNull(rows[j]) {
+				c = -c
+			}
+			return c < 0
+		}
+		return false
+	})
+	return t.take(rows), nil
+}
+
+// Write writes the header and every row to cw, formatting the values with TableColumn.Text,
+// and flushes it
+func (t *Table) Write(cw *CsvWriter) error {
+	if err := cw.Write(t.Names()); err != nil {
+		return err
+	}
+
+	record := make([]string, len(t.columns))
+	for row := 0; row < t.numRows; row++ {
+		for i, column := range t.columns {
+			record[i] = column.Text(row)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	return cw.Flush()
+}
+
+func (t *Table) take(rows []int) *Table {
+	taken := &Table{columns: make([]*TableColumn, len(t.columns)), index: t.index, numRows: len(rows)}
+	for i, column := range t.columns {
+		taken.columns[i] = column.take(rows)
+	}
+	return taken
+}
+
+// TableColumn is a typed column of a Table. Only the accessors of its type return values; a
+// null reads as the zero value.
+type TableColumn struct {
+	name  string
+	typ   FieldType
+	fixed bool
+	// every time is at midnight UTC, so it is written back as a date
+	dateOnly bool
+
+	length  int
+	nulls   bitmap
+	ints    []int64
+	floats  []float64
+	strings []string
+	bools   bitmap
+	// times are kept as nanoseconds since the epoch, in UTC
+	times []int64
+	// fields the bools and times were read from that are formatted differently, while loading
+	fields map[int]string
+}
+
+func (c *TableColumn) Name() string {
+	return c.name
+}
+
+// Type returns the type of the column, TypeString for a column of nulls only
+func (c *TableColumn) Type() FieldType {
+	return c.typ
+}
+
+func (c *TableColumn) Len() int {
+	return c.length
+}
+
+func (c *TableColumn) IsNull(row int) bool {
+	return c.nulls.get(row)
+}
+
+// NullCount returns the number of nulls in the column
+func (c *TableColumn) NullCount() int {
+	return c.nulls.count()
+}
+
+func (c *TableColumn) Int(row int) int64 {
+	if c.typ != TypeInt {
+		return 0
+	}
+	return c.ints[row]
+}
+
+func (c *TableColumn) Float(row int) float64 {
+	switch c.typ {
+	case TypeFloat:
+		return c.floats[row]
+	case TypeInt:
+		return float64(c.ints[row])
+	}
+	return 0
+}
+
+func (c *TableColumn) String(row int) string {
+	if c.typ != TypeString {
+		return ""
+	}
+	return c.strings[row]
+}
+
+func (c *TableColumn) Bool(row int) bool {
+	return c.typ == TypeBool && c.bools.get(row)
+}
+
+func (c *TableColumn) Time(row int) time.Time {
+	if c.typ != TypeDate || c.nulls.get(row) {
+		return time.Time{}
+	}
+	return time.Unix(0, c.times[row]).UTC()
+}
+
+// Ints returns the values of a TypeInt column, nil for any other type. The slice is shared.
+func (c *TableColumn) Ints() []int64 {
+	if c.typ != TypeInt {
+		return nil
+	}
+	return c.ints
+}
+
+// Floats returns the values of a TypeFloat column, nil for any other type. The slice is shared.
+func (c *TableColumn) Floats() []float64 {
+	if c.typ != TypeFloat {
+		return nil
+	}
+	return c.floats
+}
+
+// Text formats the value at row as it is written back to csv: an empty string for a null,
+// floats in their shortest exact form and times in RFC 3339, or as a date when every time of
+// the column is at midnight UTC
+func (c *TableColumn) Text(row int) string {
+	if c.nulls.get(row) {
+		return ""
+	}
+
+	switch c.typ {
+	case TypeInt:
+		return strconv.FormatInt(c.ints[row], 10)
+	case TypeFloat:
+		return strconv.FormatFloat(c.floats[row], 'f', -1, 64)
+	case TypeBool:
+		return strconv.FormatBool(c.bools.get(row))
+	case TypeDate:
+		if c.dateOnly {
+			return c.Time(row).Format(time.DateOnly)
+		}
+		return c.Time(row).Format(time.RFC3339Nano)
+	}
+	return c.strings[row]
+}
+
+// compare orders two rows of the column, nulls after every value
+func (c *TableColumn) compare(i, j int) int {
+	iNull, jNull := c.nulls.get(i), c.nulls.get(j)
+	switch {
+	case iNull && jNull:
+		return 0
+	case iNull:
+		return 1
+	case jNull:
+		return -1
+	}
+
+	switch c.typ {
+	case TypeInt:
+		return compareOrdered(c.ints[i], c.ints[j])
+	case TypeFloat:
+		return compareOrdered(c.floats[i], c.floats[j])
+	case TypeBool:
+		return compareOrdered(boolToInt(c.bools.get(i)), boolToInt(c.bools.get(j)))
+	case TypeDate:
+		return compareOrdered(c.times[i], c.times[j])
+	}
+	return strings.Compare(c.strings[i], c.strings[j])
+}
+
+func compareOrdered[T int64 | float64 | int](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (c *TableColumn) take(rows []int) *TableColumn {
+	taken := &TableColumn{name: c.name, typ: c.typ, fixed: c.fixed, dateOnly: c.dateOnly, length: len(rows)}
+	for i, row := range rows {
+		if c.nulls.get(row) {
+			taken.nulls.set(i)
+		}
+		switch c.typ {
+		case TypeInt:
+			taken.ints = append(taken.ints, c.ints[row])
+		case TypeFloat:
+			taken.floats = append(taken.floats, c.floats[row])
+		case TypeBool:
+			if c.bools.get(row) {
+				taken.bools.set(i)
+			}
+		case TypeDate:
+			taken.times = append(taken.times, c.times[row])
+		default:
+			taken.strings = append(taken.strings, c.strings[row])
+		}
+	}
+	return taken
+}
+
+// append adds a field while loading, working out or widening the type as it goes
+func (c *TableColumn) append(field string) error {
+	if field == "" {
+		c.appendNull()
+		return nil
+	}
+
+	if c.typ == "" {
+		// the nulls so far were kept as strings until the type was known
+		c.typ = inferFieldType(field)
+		c.strings = nil
+		for i := 0; i < c.length; i++ {
+			c.appendZero()
+		}
+	}
+
+	if c.appendTyped(field) {
+		c.keepField(field)
+		c.length++
+		return nil
+	}
+
+	if c.fixed {
+		return fmt.Errorf("%w: %q is not %s", errFieldType, field, c.typ)
+	}
+	if _, ok := exactTableFloat(field); c.typ == TypeInt && ok && c.intsFitFloat() {
+		c.widen(TypeFloat)
+	} else {
+		c.widen(TypeString)
+	}
+	c.appendTyped(field)
+	c.length++
+	return nil
+}
+
+func (c *TableColumn) appendNull() {
+	c.nulls.set(c.length)
+	if c.typ == "" {
+		c.strings = append(c.strings, "")
+	} else {
+		c.appendZero()
+	}
+	c.length++
+}
+
+func (c *TableColumn) appendZero() {
+	switch c.typ {
+	case TypeInt:
+		c.ints = append(c.ints, 0)
+	case TypeFloat:
+		c.floats = append(c.floats, 0)
+	case TypeBool:
+	case TypeDate:
+		c.times = append(c.times, 0)
+	default:
+		c.strings = append(c.strings, "")
+	}
+}
+
+// appendTyped adds a non-empty field at c.length if it parses as the column's type
+func (c *TableColumn) appendTyped(field string) bool {
+	switch c.typ {
+	case TypeInt:
+		n, ok := parseTableInt(field)
+		if ok {
+			c.ints = append(c.ints, n)
+		}
+		return ok
+	case TypeFloat:
+		f, ok := exactTableFloat(field)
+		if c.fixed {
+			f, ok = parseTableFloat(field)
+		}
+		if ok {
+			c.floats = append(c.floats, f)
+		}
+		return ok
+	case TypeBool:
+		b, ok := parseTableBool(field)
+		if ok && b {
+			c.bools.set(c.length)
+		}
+		return ok
+	case TypeDate:
+		t, ok := parseTableTime(field)
+		if ok {
+			c.times = append(c.times, t.UnixNano())
+		}
+		return ok
+	}
+	c.strings = append(c.strings, field)
+	return true
+}
+
+// keepField remembers the field of the value at c.length when it would be formatted otherwise,
+// so that widening the column to string gets it back
+func (c *TableColumn) keepField(field string) {
+	if c.fixed || (c.typ != TypeBool && c.typ != TypeDate) || c.loadedText(c.length) == field {
+		return
+	}
+	if c.fields == nil {
+		c.fields = map[int]string{}
+	}
+	c.fields[c.length] = field
+}
+
+// loadedText returns the field the value at row was read from
+func (c *TableColumn) loadedText(row int) string {
+	if field, ok := c.fields[row]; ok {
+		return field
+	}
+	// the column is not finished, so Text formats every time in full
+	if c.typ == TypeDate && !c.nulls.get(row) && c.times[row]%int64(24*time.Hour) == 0 {
+		return c.Time(row).Format(time.DateOnly)
+	}
+	return c.Text(row)
+}
+
+// intsFitFloat tells whether every int of the column is a float64 exactly
+func (c *TableColumn) intsFitFloat() bool {
+	for _, n := range c.ints {
+		if strconv.FormatFloat(float64(n), 'f', -1, 64) != strconv.FormatInt(n, 10) {
+			return false
+		}
+	}
+	return true
+}
+
+// widen converts the values so far to typ, an int column to float or any column to string
+func (c *TableColumn) widen(typ FieldType) {
+	if typ == TypeFloat {
+		c.floats = make([]float64, len(c.ints))
+		for i, n := range c.ints {
+			c.floats[i] = float64(n)
+		}
+		c.ints = nil
+		c.typ = TypeFloat
+		return
+	}
+
+	values := make([]string, c.length)
+	for i := range values {
+		values[i] = c.loadedText(i)
+	}
+	*c = TableColumn{name: c.name, typ: TypeString, length: c.length, nulls: c.nulls, strings: values}
+}
+
+// finish settles what can only be known once every value is in
+func (c *TableColumn) finish() {
+	if c.typ == "" {
+		c.typ = TypeString
+	}
+	c.fields = nil
+
+	if c.typ == TypeDate {
+		c.dateOnly = true
+		for i, t := range c.times {
+			if !c.nulls.get(i) && t%int64(24*time.Hour) != 0 {
+				c.dateOnly = false
+				break
+			}
+		}
+	}
+}
+
+func inferFieldType(field string) FieldType {
+	if _, ok := parseTableInt(field); ok {
+		return TypeInt
+	}
+	if _, ok := exactTableFloat(field); ok {
+		return TypeFloat
+	}
+	if _, ok := parseTableBool(field); ok {
+		return TypeBool
+	}
+	if _, ok := parseTableTime(field); ok {
+		return TypeDate
+	}
+	return TypeString
+}
+
+// parseTableInt only accepts integers written the way they are written back, so that 007
+// stays a string
+func parseTableInt(field string) (int64, bool) {
+	n, err := strconv.ParseInt(field, 10, 64)
+	return n, err == nil && strconv.FormatInt(n, 10) == field
+}
+
+// parseTableFloat only accepts plain decimal numbers, without leading zeros, so that codes
+// such as 0042 and words such as NaN stay strings
+func parseTableFloat(field string) (float64, bool) {
+	digits := strings.TrimPrefix(field, "-")
+	if digits == "" || digits[0] < '0' || digits[0] > '9' || (len(digits) > 1 && digits[0] == '0' && digits[1] != '.') {
+		return 0, false
+	}
+	if strings.Trim(digits, "0123456789.eE+-") != "" {
+		return 0, false
+	}
+
+	f, err := strconv.ParseFloat(field, 64)
+	return f, err == nil
+}
+
+// exactTableFloat only accepts floats written the way they are written back, so that 1.50 or
+// an integer too long for a float64 stays a string
+func exactTableFloat(field string) (float64, bool) {
+	f, ok := parseTableFloat(field)
+	return f, ok && strconv.FormatFloat(f, 'f', -1, 64) == field
+}
+
+func parseTableBool(field string) (bool, bool) {
+	switch {
+	case strings.EqualFold(field, "true"):
+		return true, true
+	case strings.EqualFold(field, "false"):
+		return false, true
+	}
+	return false, false
+}
+
+// parseTableTime only accepts times that fit in nanoseconds since the epoch
+func parseTableTime(field string) (time.Time, bool) {
+	t, ok := ParseTime(field)
+	if !ok || t.Year() < 1678 || t.Year() > 2261 {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// bitmap is a set of row numbers, one bit each
+type bitmap []uint64
+
+func (b *bitmap) set(i int) {
+	for len(*b) <= i/64 {
+		*b = append(*b, 0)
+	}
+	(*b)[i/64] |= 1 << (i % 64)
+}
+
+func (b bitmap) get(i int) bool {
+	return i/64 < len(b) && b[i/64]&(1<<(i%64)) != 0
+}
+
+func (b bitmap) count() int {
+	n := 0
+	for _, word := range b {
+		n += bits.OnesCount64(word)
+	}
+	return n
+}
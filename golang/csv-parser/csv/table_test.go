@@ -0,0 +1,224 @@
+package csv
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const tableInput = "id,price,active,day,name,code,mixed,ts\n" +
+	"3,1,true,2024-03-01,c,007,1,2024-03-01T10:00:00+08:00\n" +
+	"1,2.5,FALSE,,a,,2,\n" +
+	"2,,false,2023-12-31,,010,n/a,2024-01-01 00:00:00\n" +
+	"4,-1,,2024-01-15,b,1,,\n"
+
+func loadTestTable(t *testing.T, options ...TableOption) *Table {
+	table, err := LoadTable(NewCsvReader(strings.NewReader(tableInput), WithHeader(true)), options...)
+	assert.NoError(t, err)
+	return table
+}
+
+func writeTable(t *testing.T, table *Table) string {
+	var sb strings.Builder
+	assert.NoError(t, table.Write(NewCsvWriter(&sb)))
+	return sb.String()
+}
+
+func TestLoadTable(t *testing.T) {
+	t.Parallel()
+
+	table := loadTestTable(t)
+	assert.Equal(t, 4, table.NumRows())
+	assert.Equal(t, []string{"id", "price", "active", "day", "name", "code", "mixed", "ts"}, table.Names())
+
+	types := map[string]FieldType{}
+	for i := 0; i < table.NumColumns(); i++ {
+		types[table.ColumnAt(i).Name()] = table.ColumnAt(i).Type()
+	}
+	assert.Equal(t, map[string]FieldType{
+		"id": TypeInt, "price": TypeFloat, "active": TypeBool, "day": TypeDate,
+		"name": TypeString, "code": TypeString, "mixed": TypeString, "ts": TypeDate,
+	}, types)
+
+	id, err := table.Column("id")
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3, 1, 2, 4}, id.Ints())
+
+	price, _ := table.Column("price")
+	assert.Equal(t, 2.5, price.Float(1))
+	assert.True(t, price.IsNull(2))
+	assert.Equal(t, 1, price.NullCount())
+
+	active, _ := table.Column("active")
+	assert.True(t, active.Bool(0))
+	assert.False(t, active.Bool(1))
+	assert.True(t, active.IsNull(3))
+
+	day, _ := table.Column("day")
+	assert.Equal(t, "2023-12-31", day.Time(2).Format("2006-01-02"))
+	assert.True(t, day.Time(1).IsZero())
+
+	_, err = table.Column("nope")
+	assert.True(t, errors.Is(err, ErrUnknownColumn))
+
+	// values are written back in their canonical form, times in UTC
+	assert.Equal(t, "id,price,active,day,name,code,mixed,ts\n"+
+		"3,1,true,2024-03-01,c,007,1,2024-03-01T02:00:00Z\n"+
+		"1,2.5,false,,a,,2,\n"+
+		"2,,false,2023-12-31,,010,n/a,2024-01-01T00:00:00Z\n"+
+		"4,-1,,2024-01-15,b,1,,\n", writeTable(t, table))
+}
+
+func TestTableOperations(t *testing.T) {
+	table := loadTestTable(t)
+	selected, err := table.Select("id", "price", "name")
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		apply    func(t *Table) (*Table, error)
+		expected string
+		err      error
+	}{
+		{
+			name:     "slice",
+			apply:    func(t *Table) (*Table, error) { return t.Slice(1, 3), nil },
+			expected: "id,price,name\n1,2.5,a\n2,,\n",
+		},
+		{
+			name:     "slice out of range",
+			apply:    func(t *Table) (*Table, error) { return t.Slice(3, 10), nil },
+			expected: "id,price,name\n4,-1,b\n",
+		},
+		{
+			name: "filter",
+			apply: func(t *Table) (*Table, error) {
+				price, _ := t.Column("price")
+				return t.Filter(func(row int) bool { return !price.IsNull(row) && price.Float(row) > 0 }), nil
+			},
+			expected: "id,price,name\n3,1,c\n1,2.5,a\n",
+		},
+		{
+			name:     "sort",
+			apply:    func(t *Table) (*Table, error) { return t.Sort(SortKey{Column: "price"}) },
+			expected: "id,price,name\n4,-1,b\n3,1,c\n1,2.5,a\n2,,\n",
+		},
+		{
+			name:     "sort descending keeps nulls last",
+			apply:    func(t *Table) (*Table, error) { return t.Sort(SortKey{Column: "name", Descending: true}) },
+			expected: "id,price,name\n3,1,c\n4,-1,b\n1,2.5,a\n2,,\n",
+		},
+		{
+			name:  "sort by unknown column",
+			apply: func(t *Table) (*Table, error) { return t.Sort(SortKey{Column: "nope"}) },
+			err:   ErrUnknownColumn,
+		},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := currTestCase.apply(selected)
+			if currTestCase.err != nil {
+				assert.True(t, errors.Is(err, currTestCase.err), err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, currTestCase.expected, writeTable(t, result))
+		})
+	}
+
+	// the operations copy, the table they were applied to is unchanged
+	assert.Equal(t, "id,price,name\n3,1,c\n1,2.5,a\n2,,\n4,-1,b\n", writeTable(t, selected))
+}
+
+func TestLoadTableColumnTypes(t *testing.T) {
+	t.Parallel()
+
+	table := loadTestTable(t, WithColumnTypes(map[string]FieldType{"code": TypeString, "id": TypeFloat}))
+	id, _ := table.Column("id")
+	assert.Equal(t, TypeFloat, id.Type())
+
+	_, err := LoadTable(NewCsvReader(strings.NewReader(tableInput), WithHeader(true)),
+		WithColumnTypes(map[string]FieldType{"mixed": TypeInt}))
+	assert.True(t, errors.Is(err, errFieldType), err)
+
+	_, err = LoadTable(NewCsvReader(strings.NewReader(tableInput), WithHeader(true)),
+		WithColumnTypes(map[string]FieldType{"id": "decimal"}))
+	assert.True(t, errors.Is(err, errUnknownFieldType), err)
+}
+
+func TestLoadTableRoundTrip(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected FieldType
+	}{
+		{name: "ids too long for int64", input: "id\n12345678901234567890\n98765432109876543210\n", expected: TypeString},
+		{name: "trailing zero", input: "price\n2.5\n1.50\n", expected: TypeString},
+		{name: "exponent", input: "n\n1e3\n2\n", expected: TypeString},
+		{name: "widened after floats", input: "price,id\n2.5,1\n-0.125,2\n,3\n3,4\nn/a,5\n", expected: TypeString},
+		{name: "ints past float precision", input: "id\n9007199254740993\n1.5\n", expected: TypeString},
+		{name: "ints within float precision", input: "id\n9007199254740992\n1.5\n", expected: TypeFloat},
+		{name: "widened after bools", input: "flag\nTRUE\nFalse\nmaybe\n", expected: TypeString},
+		{name: "widened after dates", input: "day\n2024-01-15 10:00:00\n2024-01-16\nsoon\n", expected: TypeString},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			table, err := LoadTable(NewCsvReader(strings.NewReader(currTestCase.input), WithHeader(true)))
+			assert.NoError(t, err)
+			assert.Equal(t, currTestCase.expected, table.ColumnAt(0).Type())
+			assert.Equal(t, currTestCase.input, writeTable(t, table))
+		})
+	}
+}
+
+func TestLoadTableRaggedRecords(t *testing.T) {
+	t.Parallel()
+
+	reader := NewCsvReader(strings.NewReader("1\n2,x\n\n3\n"), WithLenient(true))
+	table, err := LoadTable(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"col1", "col2"}, table.Names())
+	assert.Equal(t, "col1,col2\n1,\n2,x\n3,\n", writeTable(t, table))
+}
+
+func TestTableMemory(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("a,b,c,d\n")
+	for i := 0; i < 50000; i++ {
+		fmt.Fprintf(&sb, "%d,%d.25,%d,%d\n", i*7919, i, i%1000, -i)
+	}
+	input := sb.String()
+
+	retained := func(load func() any) uint64 {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		value := load()
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		runtime.KeepAlive(value)
+		return after.HeapAlloc - before.HeapAlloc
+	}
+
+	rows := retained(func() any {
+		records, _ := NewCsvReader(strings.NewReader(input), WithHeader(true)).Read()
+		return records
+	})
+	columns := retained(func() any {
+		table, _ := LoadTable(NewCsvReader(strings.NewReader(input), WithHeader(true)))
+		return table
+	})
+	assert.Less(t, columns*3, rows)
+}
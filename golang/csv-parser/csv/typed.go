@@ -0,0 +1,108 @@
+package csv
+
+import (
+	"errors"
+	"fmt"
+)
+
+var errTypedHeader = errors.New("mapping fields by column name needs a header")
+
+// Mapper says how the columns of a record are converted into the fields of a T
+type Mapper[T any] struct {
+	fields []mappedField[T]
+}
+
+type mappedField[T any] struct {
+	column string
+	set    func(value *T, field string) error
+}
+
+func NewMapper[T any]() *Mapper[T] {
+	return &Mapper[T]{}
+}
+
+// MapField converts the named column with convert and hands the result to set. The types of
+// convert and set must agree, which the compiler checks. It returns m, so that fields can be
+// mapped in a chain.
+func MapField[T, V any](m *Mapper[T], column string, convert Converter[V], set func(value *T, field V)) *Mapper[T] {
+	m.fields = append(m.fields, mappedField[T]{
+		column: column,
+		set: func(value *T, field string) error {
+			converted, err := convert(field)
+			if err != nil {
+				return err
+			}
+			set(value, converted)
+			return nil
+		},
+	})
+	return m
+}
+
+// TypedReader reads the records of a RecordReader as values of type T
+type TypedReader[T any] struct {
+	r         RecordReader
+	mapper    *Mapper[T]
+	positions []int
+	started   bool
+	err       error
+}
+
+func NewTypedReader[T any](r RecordReader, mapper *Mapper[T]) *TypedReader[T] {
+	return &TypedReader[T]{r: r, mapper: mapper}
+}
+
+// Read returns the value built from the next record, and io.EOF once the input is exhausted.
+// A field that does not convert fails that record only, reading can carry on with the next.
+func (tr *TypedReader[T]) Read() (T, error) {
+	var value T
+	if !tr.started {
+		tr.started = true
+		tr.err = tr.resolve()
+	}
+	if tr.err != nil {
+		return value, tr.err
+	}
+
+	record, err := tr.r.ReadRecord()
+	if err != nil {
+		return value, err
+	}
+
+	for i, field := range tr.mapper.fields {
+		var s string
+		if pos := tr.positions[i]; pos < len(record) {
+			s = record[pos]
+		}
+		if err := field.set(&value, s); err != nil {
+			var zero T
+			return zero, tr.convertError(field.column, err)
+		}
+	}
+	return value, nil
+}
+
+func (tr *TypedReader[T]) resolve() error {
+	header, err := tr.r.Header()
+	if err != nil {
+		return err
+	}
+	if header == nil && len(tr.mapper.fields) > 0 {
+		return errTypedHeader
+	}
+
+	columns := make([]string, len(tr.mapper.fields))
+	for i, field := range tr.mapper.fields {
+		columns[i] = field.column
+	}
+	tr.positions, err = ColumnPositions(header, columns)
+	return err
+}
+
+func (tr *TypedReader[T]) convertError(column string, err error) error {
+	// readers that know where their records start, like CsvReader, say so
+	if lines, ok := tr.r.(interface{ Line() int }); ok {
+		return fmt.Errorf("%w: column %s at line %d: %v", errConvert, column, lines.Line(), err)
+	}
+	return fmt.Errorf("%w: column %s: %v", errConvert, column, err)
+}
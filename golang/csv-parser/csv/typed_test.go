@@ -0,0 +1,109 @@
+package csv
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type order struct {
+	ID       int64
+	Amount   int64
+	Discount float64
+	Placed   time.Time
+	Note     string
+}
+
+func orderMapper() *Mapper[order] {
+	m := NewMapper[order]()
+	MapField(m, "id", ConvertInt, func(o *order, id int64) { o.ID = id })
+	MapField(m, "amount", ConvertCurrency, func(o *order, amount int64) { o.Amount = amount })
+	MapField(m, "discount", Optional(ConvertPercent), func(o *order, discount float64) { o.Discount = discount })
+	MapField(m, "placed", ConvertExcelDate, func(o *order, placed time.Time) { o.Placed = placed })
+	return MapField(m, "note", ConvertString, func(o *order, note string) { o.Note = note })
+}
+
+func TestTypedReader(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		options  []ReaderOption
+		expected []order
+		errors   []string
+	}{
+		{
+			name:  "columns in any order",
+			input: "note,placed,amount,id,discount\nfirst,45292,\"$1,200.50\",1,10%\nsecond,45293.5,(3.00),2,\n",
+			expected: []order{
+				{ID: 1, Amount: 120050, Discount: 0.1, Placed: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), Note: "first"},
+				{ID: 2, Amount: -300, Placed: time.Date(2024, time.January, 2, 12, 0, 0, 0, time.UTC), Note: "second"},
+			},
+		},
+		{
+			name:  "a bad field fails its record only",
+			input: "id,amount,discount,placed,note\n1,12.345,,45292,x\n2,1,,45292,y\n",
+			expected: []order{
+				{ID: 2, Amount: 100, Placed: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), Note: "y"},
+			},
+			errors: []string{`cannot convert: column amount at line 2: not an amount: "12.345"`},
+		},
+		{
+			name:    "short records leave fields empty",
+			input:   "id,amount,placed,discount,note\n3,0,1\n",
+			options: []ReaderOption{WithLenient(true)},
+			expected: []order{
+				{ID: 3, Placed: time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:   "missing column",
+			input:  "id,amount,placed,note\n1,1,1,x\n",
+			errors: []string{`unknown column: "discount"`},
+		},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			options := append([]ReaderOption{WithHeader(true)}, currTestCase.options...)
+			tr := NewTypedReader(NewCsvReader(strings.NewReader(currTestCase.input), options...), orderMapper())
+
+			var actual []order
+			var errs []string
+			for {
+				o, err := tr.Read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					errs = append(errs, err.Error())
+					if errors.Is(err, ErrUnknownColumn) {
+						break
+					}
+					continue
+				}
+				actual = append(actual, o)
+			}
+			assert.Equal(t, currTestCase.expected, actual)
+			assert.Equal(t, currTestCase.errors, errs)
+		})
+	}
+}
+
+func TestTypedReaderNeedsHeader(t *testing.T) {
+	t.Parallel()
+
+	tr := NewTypedReader(NewCsvReader(strings.NewReader("1,2\n")), orderMapper())
+	_, err := tr.Read()
+	assert.True(t, errors.Is(err, errTypedHeader), err)
+
+	// the error sticks
+	_, err = tr.Read()
+	assert.True(t, errors.Is(err, errTypedHeader), err)
+}
@@ -0,0 +1,36 @@
+package csv
+
+import "time"
+
+type FieldType string
+
+const (
+	TypeString FieldType = "string"
+	TypeInt    FieldType = "int"
+	TypeFloat  FieldType = "float"
+	TypeBool   FieldType = "bool"
+	TypeDate   FieldType = "date"
+)
+
+var timeLayouts = []string{
+	"2006-01-02",
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+}
+
+// ParseTime parses the ISO 8601 style dates and timestamps commonly found in csv files
+func ParseTime(s string) (time.Time, bool) {
+	// every layout starts with yyyy-mm-dd, so most strings are ruled out straight away
+	if len(s) < 10 || s[4] != '-' || s[7] != '-' {
+		return time.Time{}, false
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,208 @@
+package csv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	errUnionHeader    = errors.New("aligning inputs by column name needs a header")
+	errNoCommonColumn = errors.New("no column is in every input")
+	errSourceColumn   = errors.New("source column is already in the header")
+)
+
+// Source is one input of a UnionReader. Name is what the source column says for its records.
+type Source struct {
+	Name   string
+	Reader RecordReader
+}
+
+type UnionOption func(*UnionReader)
+
+// WithIntersection only keeps the columns every input has, instead of the columns any input has
+func WithIntersection(intersect bool) UnionOption {
+	return func(ur *UnionReader) {
+		ur.intersect = intersect
+	}
+}
+
+// WithFill sets the value of the columns an input does not have, empty by default
+func WithFill(fill string) UnionOption {
+	return func(ur *UnionReader) {
+		ur.fill = fill
+	}
+}
+
+// WithSourceColumn adds a last column of the given name holding the name of the input each
+// record comes from
+func WithSourceColumn(name string) UnionOption {
+	return func(ur *UnionReader) {
+		ur.sourceColumn = name
+	}
+}
+
+// UnionReader reads the records of several inputs one after the other, aligning their columns
+// by header name. The header is made of the columns of the first input followed by the columns
+// the later inputs add, in the order they are first seen. A name repeated in a header is matched
+// by occurrence, the second "x" of one input with the second "x" of another.
+//
+// Inputs with a nil header, such as empty ones, add no columns.
+type UnionReader struct {
+	sources      []Source
+	intersect    bool
+	fill         string
+	sourceColumn string
+
+	header []string
+	// positions[i][j] is the field of source i in column j of the header, -1 when it has none.
+	// It is nil for sources without a header.
+	positions [][]int
+	current   int
+	started   bool
+	err       error
+}
+
+func NewUnionReader(sources []Source, options ...UnionOption) *UnionReader {
+	ur := &UnionReader{sources: sources}
+	for _, op := range options {
+		op(ur)
+	}
+	return ur
+}
+
+// Header reads the header of every input and returns the aligned one
+func (ur *UnionReader) Header() ([]string, error) {
+	if !ur.started {
+		ur.started = true
+		ur.header, ur.err = ur.align()
+	}
+	return ur.header, ur.err
+}
+
+// ReadRecord returns the next record in the columns of the header
+func (ur *UnionReader) ReadRecord() ([]string, error) {
+	if _, err := ur.Header(); err != nil {
+		return nil, err
+	}
+
+	for ur.current < len(ur.sources) {
+		record, err := ur.sources[ur.current].Reader.ReadRecord()
+		if err == io.EOF {
+			ur.current++
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		positions := ur.positions[ur.current]
+		if positions == nil {
+			return nil, errUnionHeader
+		}
+
+		aligned := make([]string, len(ur.header))
+		for i, pos := range positions {
+			if pos >= 0 && pos < len(record) {
+				aligned[i] = record[pos]
+			} else {
+				aligned[i] = ur.fill
+			}
+		}
+		if ur.sourceColumn != "" {
+			aligned[len(aligned)-1] = ur.sources[ur.current].Name
+		}
+		return aligned, nil
+	}
+
+	return nil, io.EOF
+}
+
+// Source returns the name of the input the last record or error came from
+func (ur *UnionReader) Source() string {
+	if ur.current >= len(ur.sources) {
+		return ""
+	}
+	return ur.sources[ur.current].Name
+}
+
+func (ur *UnionReader) align() ([]string, error) {
+	// a column is its name and the occurrence of the name in its header
+	type column struct {
+		name string
+		nth  int
+	}
+
+	var order []column
+	inputs := map[column]int{}
+	headers := make([][]column, len(ur.sources))
+	withHeader := 0
+	for i, source := range ur.sources {
+		ur.current = i
+		header, err := source.Reader.Header()
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			continue
+		}
+
+		withHeader++
+		seen := map[string]int{}
+		headers[i] = make([]column, len(header))
+		for j, name := range header {
+			c := column{name: name, nth: seen[name]}
+			seen[name]++
+			if inputs[c] == 0 {
+				order = append(order, c)
+			}
+			inputs[c]++
+			headers[i][j] = c
+		}
+	}
+	// the errors below are about every input rather than one of them
+	ur.current = len(ur.sources)
+
+	var header []string
+	index := map[column]int{}
+	for _, c := range order {
+		if ur.intersect && inputs[c] < withHeader {
+			continue
+		}
+		index[c] = len(header)
+		header = append(header, c.name)
+	}
+	if ur.intersect && withHeader > 0 && len(header) == 0 {
+		return nil, errNoCommonColumn
+	}
+
+	if ur.sourceColumn != "" {
+		if _, ok := index[column{name: ur.sourceColumn}]; ok {
+			return nil, fmt.Errorf("%w: %q", errSourceColumn, ur.sourceColumn)
+		}
+		header = append(header, ur.sourceColumn)
+	}
+
+	ur.positions = make([][]int, len(ur.sources))
+	for i, columns := range headers {
+		if columns == nil {
+			continue
+		}
+		positions := make([]int, len(header))
+		for j := range positions {
+			positions[j] = -1
+		}
+		for pos, c := range columns {
+			if j, ok := index[c]; ok {
+				positions[j] = pos
+			}
+		}
+		if ur.sourceColumn != "" {
+			positions = positions[:len(positions)-1]
+		}
+		ur.positions[i] = positions
+	}
+
+	ur.current = 0
+	return header, nil
+}
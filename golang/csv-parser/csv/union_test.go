@@ -0,0 +1,126 @@
+package csv
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnionReader(t *testing.T) {
+	inputs := []string{
+		"id,name,amount\n1,ann,10\n2,bob,20\n",
+		"amount,id,region\n30,3,eu\n",
+		"",
+		"id,name,x,x\n4,dee,a,b\n",
+	}
+
+	testCases := []struct {
+		name     string
+		options  []UnionOption
+		expected [][]string
+		err      error
+	}{
+		{
+			name: "union",
+			expected: [][]string{
+				{"id", "name", "amount", "region", "x", "x"},
+				{"1", "ann", "10", "", "", ""},
+				{"2", "bob", "20", "", "", ""},
+				{"3", "", "30", "eu", "", ""},
+				{"4", "dee", "", "", "a", "b"},
+			},
+		},
+		{
+			name:    "intersection with fill and source",
+			options: []UnionOption{WithIntersection(true), WithFill("NULL"), WithSourceColumn("file")},
+			expected: [][]string{
+				{"id", "file"},
+				{"1", "in0"},
+				{"2", "in0"},
+				{"3", "in1"},
+				{"4", "in3"},
+			},
+		},
+		{
+			name:    "fill",
+			options: []UnionOption{WithFill("NULL")},
+			expected: [][]string{
+				{"id", "name", "amount", "region", "x", "x"},
+				{"1", "ann", "10", "NULL", "NULL", "NULL"},
+				{"2", "bob", "20", "NULL", "NULL", "NULL"},
+				{"3", "NULL", "30", "eu", "NULL", "NULL"},
+				{"4", "dee", "NULL", "NULL", "a", "b"},
+			},
+		},
+		{
+			name:    "source column clash",
+			options: []UnionOption{WithSourceColumn("region")},
+			err:     errSourceColumn,
+		},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			sources := make([]Source, len(inputs))
+			for i, input := range inputs {
+				sources[i] = Source{Name: "in" + string(rune('0'+i)), Reader: NewCsvReader(strings.NewReader(input), WithHeader(true))}
+			}
+			ur := NewUnionReader(sources, currTestCase.options...)
+
+			header, err := ur.Header()
+			if currTestCase.err != nil {
+				assert.True(t, errors.Is(err, currTestCase.err), err)
+				return
+			}
+			assert.NoError(t, err)
+
+			records := [][]string{header}
+			for {
+				record, err := ur.ReadRecord()
+				if err == io.EOF {
+					break
+				}
+				assert.NoError(t, err)
+				records = append(records, record)
+			}
+			assert.Equal(t, currTestCase.expected, records)
+		})
+	}
+}
+
+func TestUnionReaderErrors(t *testing.T) {
+	t.Parallel()
+
+	ur := NewUnionReader([]Source{
+		{Name: "a", Reader: NewCsvReader(strings.NewReader("x,y\n1,2\n"), WithHeader(true))},
+		{Name: "b", Reader: NewCsvReader(strings.NewReader("z\n3\n"), WithHeader(true))},
+	}, WithIntersection(true))
+	_, err := ur.Header()
+	assert.True(t, errors.Is(err, errNoCommonColumn), err)
+
+	// a parse error names its input, and reading carries on after it
+	ur = NewUnionReader([]Source{
+		{Name: "a", Reader: NewCsvReader(strings.NewReader("x\n1\n"), WithHeader(true))},
+		{Name: "b", Reader: NewCsvReader(strings.NewReader("x,y\n1\n2,3\n"), WithHeader(true))},
+	})
+	_, err = ur.ReadRecord()
+	assert.NoError(t, err)
+	_, err = ur.ReadRecord()
+	var parseErr *ParseError
+	assert.True(t, errors.As(err, &parseErr), err)
+	assert.Equal(t, "b", ur.Source())
+	record, err := ur.ReadRecord()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2", "3"}, record)
+
+	// a headerless input with records cannot be aligned
+	ur = NewUnionReader([]Source{{Name: "a", Reader: NewCsvReader(strings.NewReader("1,2\n"))}})
+	_, err = ur.ReadRecord()
+	assert.True(t, errors.Is(err, errUnionHeader), err)
+}
@@ -0,0 +1,260 @@
+package csv
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var errUnsupportedDialect = errors.New("unsupported dialect")
+
+// Dialect is a preset of writer options for the program the output is meant for
+type Dialect string
+
+const (
+	DialectCSV   Dialect = "csv"
+	DialectExcel Dialect = "excel"
+)
+
+// ParseDialect maps a dialect name such as "Excel" to a Dialect
+func ParseDialect(name string) (Dialect, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "csv":
+		return DialectCSV, nil
+	case "excel":
+		return DialectExcel, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", errUnsupportedDialect, name)
+}
+
+type CsvWriter struct {
+	delimiter      byte
+	escapeChar     byte
+	useCRLF        bool
+	writeBOM       bool
+	writeSepLine   bool
+	escapeFormulas bool
+
+	writer  *bufio.Writer
+	started bool
+}
+
+type WriterOption func(*CsvWriter)
+
+func WithWriterDelimiter(delimiter byte) WriterOption {
+	return func(writer *CsvWriter) {
+		writer.delimiter = delimiter
+	}
+}
+
+func WithWriterEscapeChar(escapeChar byte) WriterOption {
+	return func(writer *CsvWriter) {
+		writer.escapeChar = escapeChar
+	}
+}
+
+// WithCRLF ends records with \r\n instead of \n
+func WithCRLF(useCRLF bool) WriterOption {
+	return func(writer *CsvWriter) {
+		writer.useCRLF = useCRLF
+	}
+}
+
+// WithBOM starts the output with a UTF-8 byte order mark
+func WithBOM(writeBOM bool) WriterOption {
+	return func(writer *CsvWriter) {
+		writer.writeBOM = writeBOM
+	}
+}
+
+// WithSepLine starts the output with a sep= line naming the delimiter, which tells Excel how to
+// split the records whatever the locale. CsvReader reads the delimiter from it as well.
+func WithSepLine(writeSepLine bool) WriterOption {
+	return func(writer *CsvWriter) {
+		writer.writeSepLine = writeSepLine
+	}
+}
+
+// WithFormulaEscape prefixes fields that a spreadsheet would run as a formula, those starting
+// with =, +, -, @, a tab or a carriage return, with a single quote. Numbers such as -5 are
+// written as they are.
+func WithFormulaEscape(escapeFormulas bool) WriterOption {
+	return func(writer *CsvWriter) {
+		writer.escapeFormulas = escapeFormulas
+	}
+}
+
+// WithDialect applies the options of a dialect. DialectExcel writes a BOM so that Excel reads
+// the output as UTF-8, ends records with \r\n and escapes formulas.
+func WithDialect(dialect Dialect) WriterOption {
+	return func(writer *CsvWriter) {
+		if dialect == DialectExcel {
+			writer.writeBOM = true
+			writer.useCRLF = true
+			writer.escapeFormulas = true
+		}
+	}
+}
+
+func NewCsvWriter(outputWriter io.Writer, writerOptions ...WriterOption) *CsvWriter {
+	cw := &CsvWriter{
+		delimiter:  ',',
+		escapeChar: '"',
+		writer:     bufio.NewWriter(outputWriter),
+	}
+
+	for _, op := range writerOptions {
+		op(cw)
+	}
+
+	return cw
+}
+
+// Write writes a single record, quoting the fields that need it. Call Flush once done.
+func (cw *CsvWriter) Write(record []string) error {
+	if !cw.started {
+		cw.started = true
+		if err := cw.writePreamble(); err != nil {
+			return err
+		}
+	}
+
+	// a lone empty field is quoted, otherwise it reads back as a blank line
+	if len(record) == 1 && record[0] == "" {
+		record = nil
+		cw.writer.WriteByte(cw.escapeChar)
+		cw.writer.WriteByte(cw.escapeChar)
+	}
+
+	for i, field := range record {
+		if i > 0 {
+			if err := cw.writer.WriteByte(cw.delimiter); err != nil {
+				return err
+			}
+		}
+
+		if cw.escapeFormulas && isFormula(field) {
+			field = "'" + field
+		}
+		if err := cw.writeField(field); err != nil {
+			return err
+		}
+	}
+
+	return cw.writeLineEnd()
+}
+
+func (cw *CsvWriter) WriteAll(records [][]string) error {
+	for _, record := range records {
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	return cw.Flush()
+}
+
+func (cw *CsvWriter) Flush() error {
+	return cw.writer.Flush()
+}
+
+func (cw *CsvWriter) writePreamble() error {
+	if cw.writeBOM {
+		if _, err := cw.writer.Write(utf8BOM); err != nil {
+			return err
+		}
+	}
+
+	if cw.writeSepLine {
+		cw.writer.WriteString("sep=")
+		cw.writer.WriteByte(cw.delimiter)
+		return cw.writeLineEnd()
+	}
+	return nil
+}
+
+func (cw *CsvWriter) writeLineEnd() error {
+	if cw.useCRLF {
+		_, err := cw.writer.WriteString("\r\n")
+		return err
+	}
+	return cw.writer.WriteByte('\n')
+}
+
+func (cw *CsvWriter) writeField(field string) error {
+	if !cw.needsEscaping(field) {
+		_, err := cw.writer.WriteString(field)
+		return err
+	}
+
+	cw.writer.WriteByte(cw.escapeChar)
+	for i := 0; i < len(field); i++ {
+		if field[i] == cw.escapeChar {
+			cw.writer.WriteByte(cw.escapeChar)
+		}
+		cw.writer.WriteByte(field[i])
+	}
+	return cw.writer.WriteByte(cw.escapeChar)
+}
+
+func (cw *CsvWriter) needsEscaping(field string) bool {
+	for i := 0; i < len(field); i++ {
+		switch field[i] {
+		case cw.delimiter, cw.escapeChar, '\r', '\n':
+			return true
+		}
+	}
+
+	return false
+}
+
+// isFormula reports whether a spreadsheet would evaluate the field instead of showing it
+func isFormula(field string) bool {
+	if field == "" {
+		return false
+	}
+
+	switch field[0] {
+	case '=', '@', '\t', '\r':
+		return true
+	case '+', '-':
+		return !isPlainNumber(field[1:])
+	}
+	return false
+}
+
+// isPlainNumber reports whether s is digits with an optional fraction and exponent, leaving
+// out what strconv.ParseFloat also accepts, such as inf, nan and hex floats
+func isPlainNumber(s string) bool {
+	digits := func() int {
+		n := 0
+		for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+			n++
+		}
+		s = s[n:]
+		return n
+	}
+
+	n := digits()
+	if s != "" && s[0] == '.' {
+		s = s[1:]
+		n += digits()
+	}
+	if n == 0 {
+		return false
+	}
+
+	if s != "" && (s[0] == 'e' || s[0] == 'E') {
+		s = s[1:]
+		if s != "" && (s[0] == '+' || s[0] == '-') {
+			s = s[1:]
+		}
+		if digits() == 0 {
+			return false
+		}
+	}
+	return s == ""
+}
@@ -0,0 +1,92 @@
+package csv
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite(t *testing.T) {
+	testCases := []struct {
+		name     string
+		records  [][]string
+		options  []WriterOption
+		readWith []ReaderOption
+		expected string
+		readBack [][]string
+	}{
+		{
+			name:     "plain",
+			records:  [][]string{{"a", "b"}, {"c", "d"}},
+			expected: "a,b\nc,d\n",
+		},
+		{
+			name:     "quoting",
+			records:  [][]string{{"a,b", "c\"d", "e\nf", "g"}},
+			expected: "\"a,b\",\"c\"\"d\",\"e\nf\",g\n",
+		},
+		{
+			name:     "lone empty field",
+			records:  [][]string{{""}, {"", ""}},
+			expected: "\"\"\n,\n",
+		},
+		{
+			name:     "delimiter and crlf",
+			records:  [][]string{{"a;b", "c,d"}},
+			options:  []WriterOption{WithWriterDelimiter(';'), WithCRLF(true)},
+			readWith: []ReaderOption{WithDelimiter(';')},
+			expected: "\"a;b\";c,d\r\n",
+		},
+		{
+			name:     "formula escape",
+			records:  [][]string{{"=1+1", "+SUM(A1)", "-5", "-2+3", "@cmd", "\tx", "a=b", ""}},
+			options:  []WriterOption{WithFormulaEscape(true)},
+			expected: "'=1+1,'+SUM(A1),-5,'-2+3,'@cmd,'\tx,a=b,\n",
+			readBack: [][]string{{"'=1+1", "'+SUM(A1)", "-5", "'-2+3", "'@cmd", "'\tx", "a=b", ""}},
+		},
+		{
+			name:     "formula escape only leaves plain numbers",
+			records:  [][]string{{"-inf", "+Inf", "-nan", "-0x1p3", "+1_0", "-1e", "-", "+1.5e3", "-.5", "-2.", "-3E-2"}},
+			options:  []WriterOption{WithFormulaEscape(true)},
+			expected: "'-inf,'+Inf,'-nan,'-0x1p3,'+1_0,'-1e,'-,+1.5e3,-.5,-2.,-3E-2\n",
+			readBack: [][]string{{"'-inf", "'+Inf", "'-nan", "'-0x1p3", "'+1_0", "'-1e", "'-", "+1.5e3", "-.5", "-2.", "-3E-2"}},
+		},
+		{
+			name:     "excel dialect",
+			records:  [][]string{{"id", "note"}, {"1", "=HYPERLINK(\"x\")"}},
+			options:  []WriterOption{WithDialect(DialectExcel)},
+			expected: "\xEF\xBB\xBFid,note\r\n1,\"'=HYPERLINK(\"\"x\"\")\"\r\n",
+			readBack: [][]string{{"id", "note"}, {"1", "'=HYPERLINK(\"x\")"}},
+		},
+		{
+			name:     "sep line",
+			records:  [][]string{{"a", "b,c"}},
+			options:  []WriterOption{WithDialect(DialectExcel), WithWriterDelimiter(';'), WithSepLine(true)},
+			expected: "\xEF\xBB\xBFsep=;\r\na;b,c\r\n",
+			readWith: []ReaderOption{WithSepLineDetection(true)},
+		},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var sb strings.Builder
+			csvWriter := NewCsvWriter(&sb, currTestCase.options...)
+			assert.NoError(t, csvWriter.WriteAll(currTestCase.records))
+			assert.Equal(t, currTestCase.expected, sb.String())
+
+			// what is written must read back the same
+			readOptions := append([]ReaderOption{WithLenient(true)}, currTestCase.readWith...)
+			records, err := NewCsvReader(strings.NewReader(sb.String()), readOptions...).Read()
+			assert.NoError(t, err)
+			expected := currTestCase.records
+			if currTestCase.readBack != nil {
+				expected = currTestCase.readBack
+			}
+			assert.Equal(t, expected, records)
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package dedup
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jeremyseow/csv-parser/csv"
+)
+
+var (
+	errNoHeader    = errors.New("deduplicating by column name needs a header")
+	errUnknownKeep = errors.New("unknown keep policy")
+)
+
+type Keep string
+
+const (
+	First Keep = "first"
+	Last  Keep = "last"
+)
+
+func ParseKeep(name string) (Keep, error) {
+	switch Keep(strings.ToLower(name)) {
+	case First:
+		return First, nil
+	case Last:
+		return Last, nil
+	}
+	return "", fmt.Errorf("%w: %s", errUnknownKeep, name)
+}
+
+// Duplicate is a key found on more than one record, with the line each record started on
+type Duplicate struct {
+	Key   []string
+	Lines []int
+}
+
+type deduper struct {
+	memoryLimit int64
+	tempDir     string
+}
+
+type Option func(*deduper)
+
+// WithMemoryLimit sets roughly how many bytes of records or keys are kept in memory before
+// they are partitioned to disk by hash. It defaults to 256MB.
+func WithMemoryLimit(bytes int64) Option {
+	return func(d *deduper) {
+		d.memoryLimit = bytes
+	}
+}
+
+// WithTempDir sets where spill files are created, the system temp dir by default
+func WithTempDir(dir string) Option {
+	return func(d *deduper) {
+		d.tempDir = dir
+	}
+}
+
+// lineReader is implemented by readers that know where their records start, like CsvReader
+type lineReader interface {
+	Line() int
+}
+
+// Dedup writes the header and the records of r to w, dropping every record whose key columns
+// match an earlier one, or a later one when keep is Last. Without keys whole records are
+// compared. The records that are kept stay in their input order.
+func Dedup(r csv.RecordReader, w *csv.CsvWriter, keys []string, keep Keep, options ...Option) error {
+	if keep != First && keep != Last {
+		return fmt.Errorf("%w: %s", errUnknownKeep, keep)
+	}
+
+	d := &deduper{memoryLimit: 256 << 20}
+	for _, op := range options {
+		op(d)
+	}
+
+	header, err := r.Header()
+	if err != nil {
+		return err
+	}
+	positions, err := resolve(header, keys)
+	if err != nil {
+		return err
+	}
+
+	if header != nil {
+		if err := w.Write(header); err != nil {
+			return err
+		}
+	}
+
+	// rows are the records behind their sequence number
+	seq := 0
+	source := func() ([]string, error) {
+		record, err := r.ReadRecord()
+		if err != nil {
+			return nil, err
+		}
+		seq++
+		return append([]string{strconv.Itoa(seq)}, record...), nil
+	}
+
+	s := &spiller{
+		deduper: d,
+		key: func(row []string) string {
+			return key(row[1:], positions)
+		},
+		keepLast: keep == Last,
+	}
+	defer s.cleanup()
+
+	return s.run(source, func(row []string) error {
+		return w.Write(row[1:])
+	}, w.Flush)
+}
+
+// Duplicates calls fn for every key of r found on more than one record, in the order
+// the keys first appear. Without keys whole records are compared. Only the keys and line
+// numbers are kept, so files with many more records than fit in memory can be checked.
+func Duplicates(r csv.RecordReader, keys []string, fn func(Duplicate) error, options ...Option) error {
+	d := &deduper{memoryLimit: 256 << 20}
+	for _, op := range options {
+		op(d)
+	}
+
+	header, err := r.Header()
+	if err != nil {
+		return err
+	}
+	positions, err := resolve(header, keys)
+	if err != nil {
+		return err
+	}
+
+	lines, hasLines := r.(lineReader)
+	recordNum := 0
+	if header != nil {
+		recordNum++
+	}
+
+	// rows are the line followed by the key fields
+	source := func() ([]string, error) {
+		record, err := r.ReadRecord()
+		if err != nil {
+			return nil, err
+		}
+
+		recordNum++
+		line := recordNum
+		if hasLines {
+			line = lines.Line()
+		}
+
+		row := []string{strconv.Itoa(line)}
+		if positions == nil {
+			return append(row, record...), nil
+		}
+		for _, pos := range positions {
+			row = append(row, field(record, pos))
+		}
+		return row, nil
+	}
+
+	s := &spiller{
+		deduper: d,
+		key: func(row []string) string {
+			return key(row[1:], nil)
+		},
+		collectLines: true,
+	}
+	defer s.cleanup()
+
+	// duplicates come out as the first line, the space separated lines and the key
+	return s.run(source, func(row []string) error {
+		var dup Duplicate
+		for _, line := range strings.Fields(row[1]) {
+			n, err := strconv.Atoi(line)
+			if err != nil {
+				return err
+			}
+			dup.Lines = append(dup.Lines, n)
+		}
+		dup.Key = row[2:]
+		return fn(dup)
+	}, nil)
+}
+
+// resolve finds the key columns, nil meaning the whole record
+func resolve(header, keys []string) ([]int, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	if header == nil {
+		return nil, errNoHeader
+	}
+	return csv.ColumnPositions(header, keys)
+}
+
+// key joins the fields at positions, or every field when positions is nil. The field count
+// is part of the key so that records read leniently with trailing empty fields stay apart.
+func key(record []string, positions []int) string {
+	if positions == nil {
+		return strconv.Itoa(len(record)) + "\x00" + strings.Join(record, "\x00")
+	}
+
+	fields := make([]string, len(positions))
+	for i, pos := range positions {
+		fields[i] = field(record, pos)
+	}
+	return strings.Join(fields, "\x00")
+}
+
+func field(record []string, pos int) string {
+	if pos < len(record) {
+		return record[pos]
+	}
+	return ""
+}
+
+// rowSource yields rows until io.EOF
+type rowSource func() ([]string, error)
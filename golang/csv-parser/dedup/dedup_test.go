@@ -0,0 +1,107 @@
+package dedup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedup(t *testing.T) {
+	input := "id,name,email\n1,alice,a@x\n2,bob,b@x\n1,alice,a@x\n3,carol,a@x\n2,bobby,b@x\n"
+
+	testCases := []struct {
+		name     string
+		input    string
+		noHeader bool
+		keys     []string
+		keep     Keep
+		expected string
+		err      error
+	}{
+		{name: "full row", input: input, keep: First, expected: "id,name,email\n1,alice,a@x\n2,bob,b@x\n3,carol,a@x\n2,bobby,b@x\n"},
+		{name: "key keeps first", input: input, keys: []string{"id"}, keep: First, expected: "id,name,email\n1,alice,a@x\n2,bob,b@x\n3,carol,a@x\n"},
+		{name: "key keeps last", input: input, keys: []string{"id"}, keep: Last, expected: "id,name,email\n1,alice,a@x\n3,carol,a@x\n2,bobby,b@x\n"},
+		{name: "several keys", input: input, keys: []string{"email", "id"}, keep: First, expected: "id,name,email\n1,alice,a@x\n2,bob,b@x\n3,carol,a@x\n"},
+		{name: "without header", input: "a,1\nb,2\na,1\n", noHeader: true, keep: Last, expected: "b,2\na,1\n"},
+		{name: "unknown column", input: input, keys: []string{"nope"}, keep: First, err: csv.ErrUnknownColumn},
+		{name: "key without header", input: "a,1\n", noHeader: true, keys: []string{"a"}, keep: First, err: errNoHeader},
+		{name: "unknown keep", input: input, keep: "middle", err: errUnknownKeep},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var sb strings.Builder
+			reader := csv.NewCsvReader(strings.NewReader(currTestCase.input), csv.WithHeader(!currTestCase.noHeader))
+			err := Dedup(reader, csv.NewCsvWriter(&sb), currTestCase.keys, currTestCase.keep)
+			if currTestCase.err != nil {
+				assert.True(t, errors.Is(err, currTestCase.err), err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, currTestCase.expected, sb.String())
+		})
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	input := "id,note\n1,a\n2,\"two\nlines\"\n1,b\n\n3,c\n2,d\n1,e\n"
+
+	var dups []Duplicate
+	reader := csv.NewCsvReader(strings.NewReader(input), csv.WithHeader(true))
+	err := Duplicates(reader, []string{"id"}, func(dup Duplicate) error {
+		dups = append(dups, dup)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []Duplicate{
+		{Key: []string{"1"}, Lines: []int{2, 5, 9}},
+		{Key: []string{"2"}, Lines: []int{3, 8}},
+	}, dups)
+}
+
+func TestSpill(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("id,value\n")
+	for i := 0; i < 4000; i++ {
+		fmt.Fprintf(&sb, "%d,\"v\n%d\"\n", (i*7919)%1500, i%3)
+	}
+	input := sb.String()
+
+	dedup := func(keys []string, keep Keep, options ...Option) string {
+		var out strings.Builder
+		reader := csv.NewCsvReader(strings.NewReader(input), csv.WithHeader(true))
+		assert.NoError(t, Dedup(reader, csv.NewCsvWriter(&out), keys, keep, options...))
+		return out.String()
+	}
+	duplicates := func(options ...Option) []Duplicate {
+		var dups []Duplicate
+		reader := csv.NewCsvReader(strings.NewReader(input), csv.WithHeader(true))
+		assert.NoError(t, Duplicates(reader, []string{"id"}, func(dup Duplicate) error {
+			dups = append(dups, dup)
+			return nil
+		}, options...))
+		return dups
+	}
+
+	tempDir := t.TempDir()
+	spill := []Option{WithMemoryLimit(8 << 10), WithTempDir(tempDir)}
+	assert.Equal(t, dedup(nil, First), dedup(nil, First, spill...))
+	assert.Equal(t, dedup([]string{"id"}, First), dedup([]string{"id"}, First, spill...))
+	assert.Equal(t, dedup([]string{"id"}, Last), dedup([]string{"id"}, Last, spill...))
+
+	dups := duplicates()
+	assert.Len(t, dups, 1500)
+	assert.Equal(t, dups, duplicates(spill...))
+
+	entries, err := os.ReadDir(tempDir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
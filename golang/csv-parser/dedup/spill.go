@@ -0,0 +1,236 @@
+package dedup
+
+import (
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/jeremyseow/csv-parser/extsort"
+	"github.com/jeremyseow/csv-parser/internal/spill"
+)
+
+const (
+	// past this depth a partition is processed in memory whatever its size,
+	// since hashing again cannot split a single key repeated many times
+	maxDepth = 4
+	// rough per key overhead used to estimate memory use
+	groupOverhead = 96
+)
+
+// group is every row seen with a key. Rows start with their sequence number or line.
+type group struct {
+	row   []string
+	lines []string
+}
+
+type result struct {
+	seq int
+	row []string
+}
+
+type spiller struct {
+	*deduper
+	key          func(row []string) string
+	keepLast     bool
+	collectLines bool
+
+	dir string
+	// results stay in memory until the input is partitioned, then they go to a file
+	results     []result
+	resultFile  *os.File
+	resultWrite *csv.CsvWriter
+}
+
+func (s *spiller) cleanup() {
+	if s.resultFile != nil {
+		s.resultFile.Close()
+	}
+	if s.dir != "" {
+		os.RemoveAll(s.dir)
+	}
+}
+
+func (s *spiller) createTemp(pattern string) (*os.File, error) {
+	if s.dir == "" {
+		dir, err := os.MkdirTemp(s.tempDir, "csv-dedup-")
+		if err != nil {
+			return nil, err
+		}
+		s.dir = dir
+	}
+	return os.CreateTemp(s.dir, pattern)
+}
+
+// run processes every row of source and hands the results to emit ordered by their
+// first field, the sequence number or line they were read at
+func (s *spiller) run(source rowSource, emit func([]string) error, flush func() error) error {
+	if err := s.process(source, 0); err != nil {
+		return err
+	}
+
+	if s.resultFile == nil {
+		sort.Slice(s.results, func(i, j int) bool {
+			return s.results[i].seq < s.results[j].seq
+		})
+		for _, r := range s.results {
+			if err := emit(r.row); err != nil {
+				return err
+			}
+		}
+	} else if err := s.emitSorted(emit); err != nil {
+		return err
+	}
+
+	if flush != nil {
+		return flush()
+	}
+	return nil
+}
+
+func (s *spiller) emitSorted(emit func([]string) error) error {
+	if err := s.resultWrite.Flush(); err != nil {
+		return err
+	}
+	if _, err := s.resultFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	sorted, err := s.createTemp("sorted-*.csv")
+	if err != nil {
+		return err
+	}
+	defer sorted.Close()
+
+	// results were written from records that may have been read leniently
+	err = extsort.Sort(csv.NewCsvReader(s.resultFile, csv.WithLenient(true)), csv.NewCsvWriter(sorted),
+		[]extsort.Key{{Index: 0, Type: extsort.Number}},
+		extsort.WithMemoryLimit(s.memoryLimit),
+		extsort.WithTempDir(s.dir))
+	if err != nil {
+		return err
+	}
+	if _, err := sorted.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	reader := csv.NewCsvReader(sorted, csv.WithLenient(true))
+	for {
+		row, err := reader.ReadRecord()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := emit(row); err != nil {
+			return err
+		}
+	}
+}
+
+// process groups the rows of source by key. Once the groups in memory pass the memory limit,
+// rows of new keys go to partition files which are processed in turn afterwards, so every
+// key is only ever grouped in one place.
+func (s *spiller) process(source rowSource, depth int) error {
+	groups := map[string]*group{}
+	var used int64
+	var partitions []*spill.Partition
+
+	for {
+		row, err := source()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		key := s.key(row)
+		g, ok := groups[key]
+		if !ok {
+			if used > s.memoryLimit && depth < maxDepth {
+				if partitions == nil {
+					if partitions, err = s.newPartitions(); err != nil {
+						return err
+					}
+				}
+				if err := partitions[spill.Index(key, depth)].Writer.Write(row); err != nil {
+					return err
+				}
+				continue
+			}
+
+			g = &group{row: row}
+			groups[key] = g
+			used += spill.RecordSize(row) + int64(len(key)) + groupOverhead
+		} else if s.keepLast {
+			used += spill.RecordSize(row) - spill.RecordSize(g.row)
+			g.row = row
+		}
+
+		if s.collectLines {
+			g.lines = append(g.lines, row[0])
+			used += int64(len(row[0])) + 16
+		}
+	}
+
+	for _, g := range groups {
+		if err := s.addResult(g); err != nil {
+			return err
+		}
+	}
+	groups = nil
+
+	for _, p := range partitions {
+		if err := s.processPartition(p, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *spiller) processPartition(p *spill.Partition, depth int) error {
+	if err := p.Rewind(); err != nil {
+		return err
+	}
+	defer p.Remove()
+
+	reader := csv.NewCsvReader(p.File, csv.WithLenient(true))
+	return s.process(reader.ReadRecord, depth+1)
+}
+
+func (s *spiller) addResult(g *group) error {
+	row := g.row
+	if s.collectLines {
+		if len(g.lines) < 2 {
+			return nil
+		}
+		row = append([]string{g.lines[0], strings.Join(g.lines, " ")}, g.row[1:]...)
+	}
+
+	if s.resultFile != nil {
+		return s.resultWrite.Write(row)
+	}
+
+	seq, err := strconv.Atoi(row[0])
+	if err != nil {
+		return err
+	}
+	s.results = append(s.results, result{seq: seq, row: row})
+	return nil
+}
+
+func (s *spiller) newPartitions() ([]*spill.Partition, error) {
+	// from now on results can outgrow memory too
+	if s.resultFile == nil {
+		file, err := s.createTemp("results-*.csv")
+		if err != nil {
+			return nil, err
+		}
+		s.resultFile, s.resultWrite = file, csv.NewCsvWriter(file)
+	}
+	return spill.NewPartitions(s.createTemp)
+}
@@ -0,0 +1,382 @@
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/jeremyseow/csv-parser/extsort"
+)
+
+var (
+	errNoHeader = errors.New("diffing needs a header on both sides")
+	errNoKeys   = errors.New("no key columns given")
+	errUnsorted = errors.New("input is not sorted by key")
+)
+
+type Kind string
+
+const (
+	Added   Kind = "added"
+	Removed Kind = "removed"
+	Changed Kind = "changed"
+)
+
+// Schema describes the two inputs. Columns are compared by name, so they can be reordered
+// between versions; AddedColumns and RemovedColumns are only in the new or old header.
+type Schema struct {
+	Keys           []string
+	Old            []string
+	New            []string
+	AddedColumns   []string
+	RemovedColumns []string
+}
+
+// Change is a record that was added, removed or changed. Record holds an added or removed
+// record in the order of its own header, Fields the columns of a changed record that differ.
+type Change struct {
+	Kind   Kind
+	Key    []string
+	Record []string
+	Fields []FieldChange
+}
+
+type FieldChange struct {
+	Column string
+	Old    string
+	New    string
+}
+
+type Summary struct {
+	Added     int
+	Removed   int
+	Changed   int
+	Unchanged int
+}
+
+func (s Summary) Differs() bool {
+	return s.Added+s.Removed+s.Changed > 0
+}
+
+// Reporter receives the schema, then every change in key order, then the summary
+type Reporter interface {
+	Start(schema Schema) error
+	Change(change Change) error
+	End(summary Summary) error
+}
+
+type differ struct {
+	sorted      bool
+	memoryLimit int64
+	tempDir     string
+
+	oldKeyPos []int
+	newKeyPos []int
+	// compared holds the columns in both headers as positions in the old and new records
+	compared []columnPair
+}
+
+type columnPair struct {
+	name     string
+	old, new int
+}
+
+type Option func(*differ)
+
+// WithSorted tells Diff that both inputs are already sorted by key, as strings, so they
+// are merged as they are read. An input found out of order is an error.
+func WithSorted(sorted bool) Option {
+	return func(d *differ) {
+		d.sorted = sorted
+	}
+}
+
+// WithMemoryLimit sets roughly how many bytes of records are sorted in memory at a time
+// when the inputs are not sorted. It defaults to 256MB.
+func WithMemoryLimit(bytes int64) Option {
+	return func(d *differ) {
+		d.memoryLimit = bytes
+	}
+}
+
+// WithTempDir sets where sorted inputs are written, the system temp dir by default
+func WithTempDir(dir string) Option {
+	return func(d *differ) {
+		d.tempDir = dir
+	}
+}
+
+// Diff compares the records of oldReader and newReader that share the key columns and hands
+// every difference to reporter. Unless the inputs are declared sorted, both are first sorted
+// by key on disk so that they can be merged in a single pass whatever their size. Records
+// sharing a key are paired up in the order they were read, extra ones are added or removed.
+func Diff(oldReader, newReader csv.RecordReader, keys []string, reporter Reporter, options ...Option) (Summary, error) {
+	var summary Summary
+	if len(keys) == 0 {
+		return summary, errNoKeys
+	}
+
+	d := &differ{memoryLimit: 256 << 20}
+	for _, op := range options {
+		op(d)
+	}
+
+	oldHeader, err := oldReader.Header()
+	if err != nil {
+		return summary, err
+	}
+	newHeader, err := newReader.Header()
+	if err != nil {
+		return summary, err
+	}
+	if oldHeader == nil || newHeader == nil {
+		return summary, errNoHeader
+	}
+
+	schema, err := d.resolve(keys, oldHeader, newHeader)
+	if err != nil {
+		return summary, err
+	}
+
+	if !d.sorted {
+		dir, err := os.MkdirTemp(d.tempDir, "csv-diff-")
+		if err != nil {
+			return summary, err
+		}
+		defer os.RemoveAll(dir)
+
+		oldFile, err := d.sortToFile(oldReader, d.oldKeyPos, dir)
+		if err != nil {
+			return summary, err
+		}
+		defer oldFile.Close()
+
+		newFile, err := d.sortToFile(newReader, d.newKeyPos, dir)
+		if err != nil {
+			return summary, err
+		}
+		defer newFile.Close()
+
+		oldReader = csv.NewCsvReader(oldFile, csv.WithHeader(true), csv.WithLenient(true))
+		newReader = csv.NewCsvReader(newFile, csv.WithHeader(true), csv.WithLenient(true))
+	}
+
+	if err := reporter.Start(schema); err != nil {
+		return summary, err
+	}
+	if err := d.merge(oldReader, newReader, reporter, &summary); err != nil {
+		return summary, err
+	}
+	return summary, reporter.End(summary)
+}
+
+func (d *differ) resolve(keys, oldHeader, newHeader []string) (Schema, error) {
+	schema := Schema{Keys: keys, Old: oldHeader, New: newHeader}
+
+	var err error
+	if d.oldKeyPos, err = csv.ColumnPositions(oldHeader, keys); err != nil {
+		return schema, err
+	}
+	if d.newKeyPos, err = csv.ColumnPositions(newHeader, keys); err != nil {
+		return schema, err
+	}
+
+	oldIndex := index(oldHeader)
+	newIndex := index(newHeader)
+
+	for i, name := range oldHeader {
+		if oldIndex[name] != i {
+			continue
+		}
+		if newPos, ok := newIndex[name]; ok {
+			d.compared = append(d.compared, columnPair{name: name, old: i, new: newPos})
+		} else {
+			schema.RemovedColumns = append(schema.RemovedColumns, name)
+		}
+	}
+	for i, name := range newHeader {
+		if _, ok := oldIndex[name]; !ok && newIndex[name] == i {
+			schema.AddedColumns = append(schema.AddedColumns, name)
+		}
+	}
+	return schema, nil
+}
+
+// index maps the column names to their first position. It is not csv.ColumnPositions because
+// a name missing from the other header is a schema change to report rather than an error.
+func index(header []string) map[string]int {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		if _, ok := index[name]; !ok {
+			index[name] = i
+		}
+	}
+	return index
+}
+
+func (d *differ) sortToFile(r csv.RecordReader, keyPos []int, dir string) (*os.File, error) {
+	file, err := os.CreateTemp(dir, "sorted-*.csv")
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]extsort.Key, len(keyPos))
+	for i, pos := range keyPos {
+		keys[i] = extsort.Key{Index: pos, Type: extsort.String}
+	}
+
+	err = extsort.Sort(r, csv.NewCsvWriter(file), keys,
+		extsort.WithMemoryLimit(d.memoryLimit),
+		extsort.WithTempDir(dir))
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+func (d *differ) merge(oldReader, newReader csv.RecordReader, reporter Reporter, summary *Summary) error {
+	oc := &cursor{reader: oldReader, keyPos: d.oldKeyPos, name: "old"}
+	nc := &cursor{reader: newReader, keyPos: d.newKeyPos, name: "new"}
+	if err := oc.advance(); err != nil {
+		return err
+	}
+	if err := nc.advance(); err != nil {
+		return err
+	}
+
+	for oc.record != nil || nc.record != nil {
+		var c int
+		switch {
+		case nc.record == nil:
+			c = -1
+		case oc.record == nil:
+			c = 1
+		default:
+			c = compareKeys(oc.key, nc.key)
+		}
+
+		key := oc.key
+		if c > 0 {
+			key = nc.key
+		}
+
+		var olds, news [][]string
+		var err error
+		if c <= 0 {
+			if olds, err = oc.group(); err != nil {
+				return err
+			}
+		}
+		if c >= 0 {
+			if news, err = nc.group(); err != nil {
+				return err
+			}
+		}
+
+		for i := 0; i < max(len(olds), len(news)); i++ {
+			var change Change
+			switch {
+			case i >= len(olds):
+				change = Change{Kind: Added, Key: key, Record: news[i]}
+				summary.Added++
+			case i >= len(news):
+				change = Change{Kind: Removed, Key: key, Record: olds[i]}
+				summary.Removed++
+			default:
+				fields := d.compare(olds[i], news[i])
+				if len(fields) == 0 {
+					summary.Unchanged++
+					continue
+				}
+				change = Change{Kind: Changed, Key: key, Fields: fields}
+				summary.Changed++
+			}
+
+			if err := reporter.Change(change); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (d *differ) compare(oldRecord, newRecord []string) []FieldChange {
+	var fields []FieldChange
+	for _, pair := range d.compared {
+		oldValue, newValue := field(oldRecord, pair.old), field(newRecord, pair.new)
+		if oldValue != newValue {
+			fields = append(fields, FieldChange{Column: pair.name, Old: oldValue, New: newValue})
+		}
+	}
+	return fields
+}
+
+// field treats the missing fields of short records, read leniently, as empty
+func field(record []string, pos int) string {
+	if pos < len(record) {
+		return record[pos]
+	}
+	return ""
+}
+
+func compareKeys(a, b []string) int {
+	for i := range a {
+		if c := strings.Compare(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// cursor walks an input sorted by key
+type cursor struct {
+	reader    csv.RecordReader
+	keyPos    []int
+	name      string
+	record    []string
+	key       []string
+	recordNum int
+}
+
+func (c *cursor) advance() error {
+	record, err := c.reader.ReadRecord()
+	if err == io.EOF {
+		c.record = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c.recordNum++
+
+	key := make([]string, len(c.keyPos))
+	for i, pos := range c.keyPos {
+		key[i] = field(record, pos)
+	}
+	if c.record != nil && compareKeys(key, c.key) < 0 {
+		return fmt.Errorf("%w: %s record %d has key %q after %q", errUnsorted, c.name, c.recordNum, key, c.key)
+	}
+
+	c.record, c.key = record, key
+	return nil
+}
+
+// group reads every record with the current key
+func (c *cursor) group() ([][]string, error) {
+	key := c.key
+	var records [][]string
+	for c.record != nil && compareKeys(c.key, key) == 0 {
+		records = append(records, c.record)
+		if err := c.advance(); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
@@ -0,0 +1,132 @@
+package diff
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	oldInput := "id,name,amount,note\n3,carol,2,x\n1,alice,5,\n2,bob,1,y\n"
+	// columns reordered, note dropped and country added
+	newInput := "amount,id,country,name\n7,1,SG,alice\n2,3,MY,carol\n3,4,SG,dave smith\n"
+
+	testCases := []struct {
+		name     string
+		oldInput string
+		newInput string
+		keys     []string
+		format   Format
+		options  []Option
+		expected string
+		err      error
+	}{
+		{
+			name: "text", oldInput: oldInput, newInput: newInput, keys: []string{"id"}, format: FormatText,
+			expected: "columns added: country\ncolumns removed: note\n~ id=1: amount 5 -> 7\n- id=2 name=bob amount=1 note=y\n+ id=4 amount=3 country=SG name=\"dave smith\"\n1 added, 1 removed, 1 changed, 1 unchanged\n",
+		},
+		{
+			name: "csv", oldInput: oldInput, newInput: newInput, keys: []string{"id"}, format: FormatCSV,
+			expected: "change,id,column,old,new\nchanged,1,amount,5,7\nremoved,2,id,2,\nremoved,2,name,bob,\nremoved,2,amount,1,\nremoved,2,note,y,\nadded,4,amount,,3\nadded,4,id,,4\nadded,4,country,,SG\nadded,4,name,,dave smith\n",
+		},
+		{
+			name: "several keys", oldInput: "a,b,v\n1,x,1\n1,y,2\n", newInput: "b,a,v\nx,1,1\ny,1,3\n", keys: []string{"a", "b"}, format: FormatText,
+			expected: "~ a=1 b=y: v 2 -> 3\n0 added, 0 removed, 1 changed, 1 unchanged\n",
+		},
+		{
+			name: "duplicate keys pair up in order", oldInput: "id,v\n1,a\n1,b\n", newInput: "id,v\n1,a\n1,c\n1,d\n", keys: []string{"id"}, format: FormatText,
+			expected: "~ id=1: v b -> c\n+ id=1 v=d\n1 added, 0 removed, 1 changed, 1 unchanged\n",
+		},
+		{
+			name: "sorted", oldInput: "id,v\n1,a\n2,b\n", newInput: "id,v\n2,b\n3,c\n", keys: []string{"id"}, format: FormatText, options: []Option{WithSorted(true)},
+			expected: "- id=1 v=a\n+ id=3 v=c\n1 added, 1 removed, 0 changed, 1 unchanged\n",
+		},
+		{name: "not sorted", oldInput: oldInput, newInput: newInput, keys: []string{"id"}, format: FormatText, options: []Option{WithSorted(true)}, err: errUnsorted},
+		{name: "unknown key", oldInput: oldInput, newInput: newInput, keys: []string{"note"}, format: FormatText, err: csv.ErrUnknownColumn},
+		{name: "no keys", oldInput: oldInput, newInput: newInput, format: FormatText, err: errNoKeys},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var sb strings.Builder
+			reporter, err := NewReporter(&sb, currTestCase.format)
+			assert.NoError(t, err)
+
+			oldReader := csv.NewCsvReader(strings.NewReader(currTestCase.oldInput), csv.WithHeader(true))
+			newReader := csv.NewCsvReader(strings.NewReader(currTestCase.newInput), csv.WithHeader(true))
+			_, err = Diff(oldReader, newReader, currTestCase.keys, reporter, currTestCase.options...)
+			if currTestCase.err != nil {
+				assert.True(t, errors.Is(err, currTestCase.err), err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, currTestCase.expected, sb.String())
+		})
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	var sb strings.Builder
+	reporter, err := NewReporter(&sb, FormatJSON)
+	assert.NoError(t, err)
+
+	oldReader := csv.NewCsvReader(strings.NewReader("id,name\n1,\"a \"\"b\"\"\"\n2,c\n"), csv.WithHeader(true))
+	newReader := csv.NewCsvReader(strings.NewReader("name,id\nd,1\ne,3\n"), csv.WithHeader(true))
+	summary, err := Diff(oldReader, newReader, []string{"id"}, reporter)
+	assert.NoError(t, err)
+	assert.Equal(t, Summary{Added: 1, Removed: 1, Changed: 1}, summary)
+
+	var report map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(sb.String()), &report), sb.String())
+	assert.Equal(t, []any{
+		map[string]any{"change": "changed", "key": map[string]any{"id": "1"}, "fields": map[string]any{"name": map[string]any{"old": `a "b"`, "new": "d"}}},
+		map[string]any{"change": "removed", "key": map[string]any{"id": "2"}, "record": map[string]any{"id": "2", "name": "c"}},
+		map[string]any{"change": "added", "key": map[string]any{"id": "3"}, "record": map[string]any{"name": "e", "id": "3"}},
+	}, report["changes"])
+	assert.Equal(t, map[string]any{"added": 1.0, "removed": 1.0, "changed": 1.0, "unchanged": 0.0}, report["summary"])
+}
+
+func TestDiffSpill(t *testing.T) {
+	var oldInput, newInput strings.Builder
+	oldInput.WriteString("id,value\n")
+	newInput.WriteString("value,id\n")
+	for i := 0; i < 3000; i++ {
+		id := (i * 7919) % 3000
+		fmt.Fprintf(&oldInput, "%d,\"v\n%d\"\n", id, id%10)
+		if id%5 != 0 {
+			fmt.Fprintf(&newInput, "\"v\n%d\",%d\n", id%7, id)
+		}
+	}
+
+	run := func(options ...Option) (string, Summary) {
+		var sb strings.Builder
+		reporter, err := NewReporter(&sb, FormatCSV)
+		assert.NoError(t, err)
+
+		oldReader := csv.NewCsvReader(strings.NewReader(oldInput.String()), csv.WithHeader(true))
+		newReader := csv.NewCsvReader(strings.NewReader(newInput.String()), csv.WithHeader(true))
+		summary, err := Diff(oldReader, newReader, []string{"id"}, reporter, options...)
+		assert.NoError(t, err)
+		return sb.String(), summary
+	}
+
+	tempDir := t.TempDir()
+	inMemory, summary := run()
+	spilled, spilledSummary := run(WithMemoryLimit(4<<10), WithTempDir(tempDir))
+	assert.Equal(t, inMemory, spilled)
+	assert.Equal(t, summary, spilledSummary)
+	assert.Equal(t, 600, summary.Removed)
+
+	entries, err := os.ReadDir(tempDir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,274 @@
+package diff
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jeremyseow/csv-parser/convert"
+	"github.com/jeremyseow/csv-parser/csv"
+)
+
+var errUnsupportedFormat = errors.New("unsupported format")
+
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatCSV  Format = "csv"
+	FormatJSON Format = "json"
+)
+
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(name)) {
+	case FormatText, "txt":
+		return FormatText, nil
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", errUnsupportedFormat, name)
+}
+
+// NewReporter writes the changes to w in the given format:
+//   - text lists added records with +, removed ones with - and changed ones with ~
+//   - csv has a row per column of every change: change, the keys, column, old and new
+//   - json is a single object holding the column changes, the changes and the summary
+func NewReporter(w io.Writer, format Format) (Reporter, error) {
+	switch format {
+	case FormatText:
+		return &textReporter{writer: bufio.NewWriter(w)}, nil
+	case FormatCSV:
+		return &csvReporter{writer: csv.NewCsvWriter(w)}, nil
+	case FormatJSON:
+		return &jsonReporter{writer: bufio.NewWriter(w)}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", errUnsupportedFormat, format)
+}
+
+type textReporter struct {
+	writer *bufio.Writer
+	schema Schema
+}
+
+func (tr *textReporter) Start(schema Schema) error {
+	tr.schema = schema
+	if len(schema.AddedColumns) > 0 {
+		fmt.Fprintf(tr.writer, "columns added: %s\n", strings.Join(schema.AddedColumns, ", "))
+	}
+	if len(schema.RemovedColumns) > 0 {
+		fmt.Fprintf(tr.writer, "columns removed: %s\n", strings.Join(schema.RemovedColumns, ", "))
+	}
+	return nil
+}
+
+func (tr *textReporter) Change(change Change) error {
+	var sb strings.Builder
+	switch change.Kind {
+	case Added:
+		sb.WriteString("+ ")
+	case Removed:
+		sb.WriteString("- ")
+	default:
+		sb.WriteString("~ ")
+	}
+
+	for i, name := range tr.schema.Keys {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		fmt.Fprintf(&sb, "%s=%s", name, display(change.Key[i]))
+	}
+
+	switch change.Kind {
+	case Added, Removed:
+		header := tr.schema.New
+		if change.Kind == Removed {
+			header = tr.schema.Old
+		}
+		for i, name := range header {
+			if !isKey(tr.schema.Keys, name) {
+				fmt.Fprintf(&sb, " %s=%s", name, display(field(change.Record, i)))
+			}
+		}
+	default:
+		for i, f := range change.Fields {
+			sep := ";"
+			if i == 0 {
+				sep = ":"
+			}
+			fmt.Fprintf(&sb, "%s %s %s -> %s", sep, f.Column, display(f.Old), display(f.New))
+		}
+	}
+
+	sb.WriteByte('\n')
+	_, err := tr.writer.WriteString(sb.String())
+	return err
+}
+
+func (tr *textReporter) End(summary Summary) error {
+	fmt.Fprintf(tr.writer, "%d added, %d removed, %d changed, %d unchanged\n",
+		summary.Added, summary.Removed, summary.Changed, summary.Unchanged)
+	return tr.writer.Flush()
+}
+
+// display quotes values that would be hard to read as they are
+func display(value string) string {
+	if value == "" || strings.IndexFunc(value, func(r rune) bool {
+		return unicode.IsSpace(r) || !unicode.IsPrint(r) || r == '"' || r == '=' || r == ';'
+	}) >= 0 {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+func isKey(keys []string, name string) bool {
+	for _, key := range keys {
+		if key == name {
+			return true
+		}
+	}
+	return false
+}
+
+type csvReporter struct {
+	writer *csv.CsvWriter
+	schema Schema
+}
+
+func (cr *csvReporter) Start(schema Schema) error {
+	cr.schema = schema
+	header := append([]string{"change"}, schema.Keys...)
+	return cr.writer.Write(append(header, "column", "old", "new"))
+}
+
+func (cr *csvReporter) Change(change Change) error {
+	write := func(column, oldValue, newValue string) error {
+		row := append([]string{string(change.Kind)}, change.Key...)
+		return cr.writer.Write(append(row, column, oldValue, newValue))
+	}
+
+	switch change.Kind {
+	case Added:
+		for i, name := range cr.schema.New {
+			if err := write(name, "", field(change.Record, i)); err != nil {
+				return err
+			}
+		}
+	case Removed:
+		for i, name := range cr.schema.Old {
+			if err := write(name, field(change.Record, i), ""); err != nil {
+				return err
+			}
+		}
+	default:
+		for _, f := range change.Fields {
+			if err := write(f.Column, f.Old, f.New); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (cr *csvReporter) End(Summary) error {
+	return cr.writer.Flush()
+}
+
+type jsonReporter struct {
+	writer *bufio.Writer
+	schema Schema
+	buf    []byte
+	count  int
+}
+
+func (jr *jsonReporter) Start(schema Schema) error {
+	jr.schema = schema
+	buf := append(jr.buf[:0], `{"keys":`...)
+	buf = appendStrings(buf, schema.Keys)
+	buf = append(buf, `,"added_columns":`...)
+	buf = appendStrings(buf, schema.AddedColumns)
+	buf = append(buf, `,"removed_columns":`...)
+	buf = appendStrings(buf, schema.RemovedColumns)
+	buf = append(buf, `,"changes":[`...)
+	jr.buf = buf
+	_, err := jr.writer.Write(buf)
+	return err
+}
+
+func (jr *jsonReporter) Change(change Change) error {
+	buf := jr.buf[:0]
+	if jr.count > 0 {
+		buf = append(buf, ',')
+	}
+	jr.count++
+
+	buf = append(buf, "\n"+`{"change":"`...)
+	buf = append(buf, change.Kind...)
+	buf = append(buf, `","key":`...)
+	buf = appendObject(buf, jr.schema.Keys, change.Key)
+
+	switch change.Kind {
+	case Added:
+		buf = append(buf, `,"record":`...)
+		buf = appendObject(buf, jr.schema.New, change.Record)
+	case Removed:
+		buf = append(buf, `,"record":`...)
+		buf = appendObject(buf, jr.schema.Old, change.Record)
+	default:
+		buf = append(buf, `,"fields":{`...)
+		for i, f := range change.Fields {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = convert.AppendJSONString(buf, f.Column)
+			buf = append(buf, `:{"old":`...)
+			buf = convert.AppendJSONString(buf, f.Old)
+			buf = append(buf, `,"new":`...)
+			buf = convert.AppendJSONString(buf, f.New)
+			buf = append(buf, '}')
+		}
+		buf = append(buf, '}')
+	}
+
+	buf = append(buf, '}')
+	jr.buf = buf
+	_, err := jr.writer.Write(buf)
+	return err
+}
+
+func (jr *jsonReporter) End(summary Summary) error {
+	fmt.Fprintf(jr.writer, "\n],\"summary\":{\"added\":%d,\"removed\":%d,\"changed\":%d,\"unchanged\":%d}}\n",
+		summary.Added, summary.Removed, summary.Changed, summary.Unchanged)
+	return jr.writer.Flush()
+}
+
+func appendStrings(buf []byte, values []string) []byte {
+	buf = append(buf, '[')
+	for i, value := range values {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = convert.AppendJSONString(buf, value)
+	}
+	return append(buf, ']')
+}
+
+func appendObject(buf []byte, names, values []string) []byte {
+	buf = append(buf, '{')
+	for i, name := range names {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = convert.AppendJSONString(buf, name)
+		buf = append(buf, ':')
+		buf = convert.AppendJSONString(buf, field(values, i))
+	}
+	return append(buf, '}')
+}
@@ -0,0 +1,447 @@
+package extsort
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/jeremyseow/csv-parser/internal/spill"
+)
+
+// maxFanIn is the most runs merged at once, more runs are first merged into bigger runs
+const maxFanIn = 64
+
+var (
+	errNoKeys   = errors.New("no sort keys given")
+	errBadKey   = errors.New("bad sort key")
+	errNoHeader = errors.New("sorting by column name needs a header")
+)
+
+type Type string
+
+const (
+	String Type = "string"
+	Number Type = "number"
+	Date   Type = "date"
+)
+
+// Key is a column to sort by, named by header or by 0-based index when Name is empty.
+// Values that do not parse as the key's Type, such as empty fields, sort before all others,
+// whether the key is Desc or not.
+type Key struct {
+	Name  string
+	Index int
+	Type  Type
+	Desc  bool
+}
+
+// ParseKeys reads a comma separated list of keys such as "country,amount:number:desc,3:date".
+// Whole numbers are 1-based column indexes. Types can be shortened to s, n and d.
+func ParseKeys(spec string) ([]Key, error) {
+	var keys []Key
+	for _, item := range strings.Split(spec, ",") {
+		parts := strings.Split(strings.TrimSpace(item), ":")
+		if parts[0] == "" {
+			continue
+		}
+
+		key := Key{Name: parts[0], Type: String}
+		if index, err := strconv.Atoi(parts[0]); err == nil {
+			if index < 1 {
+				return nil, fmt.Errorf("%w: column indexes start at 1, got %d", errBadKey, index)
+			}
+			key = Key{Index: index - 1, Type: String}
+		}
+
+		for _, part := range parts[1:] {
+			switch strings.ToLower(part) {
+			case "s", "string":
+				key.Type = String
+			case "n", "num", "number":
+				key.Type = Number
+			case "d", "date":
+				key.Type = Date
+			case "asc":
+				key.Desc = false
+			case "desc":
+				key.Desc = true
+			default:
+				return nil, fmt.Errorf("%w: %q in %q", errBadKey, part, item)
+			}
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, errNoKeys
+	}
+	return keys, nil
+}
+
+type sorter struct {
+	keys        []Key
+	positions   []int
+	memoryLimit int64
+	tempDir     string
+	dir         string
+}
+
+type Option func(*sorter)
+
+// WithMemoryLimit sets roughly how many bytes of records are sorted in memory at a time.
+// It defaults to 256MB.
+func WithMemoryLimit(bytes int64) Option {
+	return func(s *sorter) {
+		s.memoryLimit = bytes
+	}
+}
+
+// WithTempDir sets where the sorted runs are written, the system temp dir by default
+func WithTempDir(dir string) Option {
+	return func(s *sorter) {
+		s.tempDir = dir
+	}
+}
+
+// item is a record with its sort keys parsed once
+type item struct {
+	record []string
+	values []keyValue
+}
+
+type keyValue struct {
+	ok   bool
+	str  string
+	num  float64
+	time time.Time
+}
+
+// Sort writes the header and records of r to w ordered by keys. The sort is stable. Records
+// are read in chunks up to the memory limit, each chunk is sorted and written to a temp file
+// as csv, and the files are then merged, so inputs much larger than memory can be sorted.
+func Sort(r csv.RecordReader, w *csv.CsvWriter, keys []Key, options ...Option) error {
+	if len(keys) == 0 {
+		return errNoKeys
+	}
+
+	s := &sorter{keys: keys, memoryLimit: 256 << 20}
+	for _, op := range options {
+		op(s)
+	}
+	defer s.cleanup()
+
+	header, err := r.Header()
+	if err != nil {
+		return err
+	}
+	if err := s.resolve(header); err != nil {
+		return err
+	}
+
+	if header != nil {
+		if err := w.Write(header); err != nil {
+			return err
+		}
+	}
+
+	var runs []string
+	var chunk []*item
+	var used int64
+	for {
+		record, err := r.ReadRecord()
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		if record != nil {
+			chunk = append(chunk, s.newItem(record))
+			used += spill.RecordSize(record) + int64(len(s.keys))*keyValueSize
+		}
+
+		// the last chunk is merged straight from memory
+		if err == io.EOF {
+			break
+		}
+
+		if used > s.memoryLimit {
+			path, err := s.writeRun(chunk)
+			if err != nil {
+				return err
+			}
+			runs = append(runs, path)
+			chunk, used = nil, 0
+		}
+	}
+
+	for len(runs) > maxFanIn {
+		if runs, err = s.mergeRuns(runs); err != nil {
+			return err
+		}
+	}
+
+	s.sortChunk(chunk)
+	if err := s.merge(runs, chunk, w); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func (s *sorter) resolve(header []string) error {
+	s.positions = make([]int, len(s.keys))
+	for i, key := range s.keys {
+		if key.Name == "" {
+			s.positions[i] = key.Index
+			continue
+		}
+
+		if header == nil {
+			return errNoHeader
+		}
+
+		pos, err := csv.ColumnPositions(header, []string{key.Name})
+		if err != nil {
+			return err
+		}
+		s.positions[i] = pos[0]
+	}
+	return nil
+}
+
+func (s *sorter) newItem(record []string) *item {
+	it := &item{record: record, values: make([]keyValue, len(s.keys))}
+	for i, key := range s.keys {
+		pos := s.positions[i]
+		if pos >= len(record) {
+			continue
+		}
+
+		field := record[pos]
+		kv := &it.values[i]
+		switch key.Type {
+		case Number:
+			num, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			kv.num, kv.ok = num, err == nil
+		case Date:
+			kv.time, kv.ok = csv.ParseTime(strings.TrimSpace(field))
+		}
+
+		// values that fail to parse are still ordered among themselves as strings
+		kv.str = field
+		if key.Type == String {
+			kv.ok = true
+		}
+	}
+	return it
+}
+
+func (s *sorter) compare(a, b *item) int {
+	for i, key := range s.keys {
+		av, bv := a.values[i], b.values[i]
+
+		// values that fail to parse come first in either direction
+		if av.ok != bv.ok {
+			if !av.ok {
+				return -1
+			}
+			return 1
+		}
+
+		c := 0
+		switch {
+		case !av.ok || key.Type == String:
+			c = strings.Compare(av.str, bv.str)
+		case key.Type == Number:
+			if av.num < bv.num {
+				c = -1
+			} else if av.num > bv.num {
+				c = 1
+			}
+		case key.Type == Date:
+			c = av.time.Compare(bv.time)
+		}
+
+		if key.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func (s *sorter) sortChunk(chunk []*item) {
+	sort.SliceStable(chunk, func(i, j int) bool {
+		return s.compare(chunk[i], chunk[j]) < 0
+	})
+}
+
+func (s *sorter) writeRun(chunk []*item) (string, error) {
+	s.sortChunk(chunk)
+
+	if s.dir == "" {
+		dir, err := os.MkdirTemp(s.tempDir, "csv-sort-")
+		if err != nil {
+			return "", err
+		}
+		s.dir = dir
+	}
+
+	file, err := os.CreateTemp(s.dir, "run-*.csv")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	cw := csv.NewCsvWriter(file)
+	for _, it := range chunk {
+		if err := cw.Write(it.record); err != nil {
+			return "", err
+		}
+	}
+	return file.Name(), cw.Flush()
+}
+
+// mergeRuns merges consecutive groups of maxFanIn runs, keeping them in input order
+func (s *sorter) mergeRuns(runs []string) ([]string, error) {
+	var merged []string
+	for start := 0; start < len(runs); start += maxFanIn {
+		group := runs[start:min(start+maxFanIn, len(runs))]
+
+		file, err := os.CreateTemp(s.dir, "run-*.csv")
+		if err != nil {
+			return nil, err
+		}
+
+		cw := csv.NewCsvWriter(file)
+		err = s.merge(group, nil, cw)
+		if err == nil {
+			err = cw.Flush()
+		}
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range group {
+			os.Remove(path)
+		}
+		merged = append(merged, file.Name())
+	}
+	return merged, nil
+}
+
+func (s *sorter) cleanup() {
+	if s.dir != "" {
+		os.RemoveAll(s.dir)
+	}
+}
+
+// keyValueSize roughly accounts for a parsed key
+const keyValueSize = 64
+
+// cursor is the next item of a run during the merge, runs hold the records in input order
+// so that ties are broken by run number to keep the merge stable
+type cursor struct {
+	item *item
+	run  int
+	next func() (*item, error)
+}
+
+type mergeHeap struct {
+	cursors []*cursor
+	sorter  *sorter
+}
+
+func (h *mergeHeap) Len() int { return len(h.cursors) }
+func (h *mergeHeap) Less(i, j int) bool {
+	if c := h.sorter.compare(h.cursors[i].item, h.cursors[j].item); c != 0 {
+		return c < 0
+	}
+	return h.cursors[i].run < h.cursors[j].run
+}
+func (h *mergeHeap) Swap(i, j int) { h.cursors[i], h.cursors[j] = h.cursors[j], h.cursors[i] }
+func (h *mergeHeap) Push(x any)    { h.cursors = append(h.cursors, x.(*cursor)) }
+func (h *mergeHeap) Pop() any {
+	last := h.cursors[len(h.cursors)-1]
+	h.cursors = h.cursors[:len(h.cursors)-1]
+	return last
+}
+
+func (s *sorter) merge(runs []string, last []*item, w *csv.CsvWriter) error {
+	h := &mergeHeap{sorter: s}
+	add := func(run int, next func() (*item, error)) error {
+		it, err := next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		h.cursors = append(h.cursors, &cursor{item: it, run: run, next: next})
+		return nil
+	}
+
+	for i, path := range runs {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		// runs were written from records that may have been read leniently
+		reader := csv.NewCsvReader(file, csv.WithLenient(true))
+		err = add(i, func() (*item, error) {
+			record, err := reader.ReadRecord()
+			if err != nil {
+				return nil, err
+			}
+			return s.newItem(record), nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	err := add(len(runs), func() (*item, error) {
+		if len(last) == 0 {
+			return nil, io.EOF
+		}
+		it := last[0]
+		last = last[1:]
+		return it, nil
+	})
+	if err != nil {
+		return err
+	}
+	heap.Init(h)
+
+	for h.Len() > 0 {
+		c := h.cursors[0]
+		if err := w.Write(c.item.record); err != nil {
+			return err
+		}
+
+		it, err := c.next()
+		if err == io.EOF {
+			heap.Pop(h)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		c.item = it
+		heap.Fix(h, 0)
+	}
+
+	return nil
+}
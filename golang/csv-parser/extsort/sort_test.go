@@ -0,0 +1,120 @@
+package extsort
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSort(t *testing.T) {
+	input := "id,amount,joined,country\n1,100,2024-03-01,SG\n2,9.5,2023-12-31,MY\n3,,2024-01-15T10:00:00Z,SG\n4,100,2022-06-30,ID\n5,-3,,MY\n"
+
+	testCases := []struct {
+		name     string
+		input    string
+		noHeader bool
+		keys     string
+		expected []string
+		err      error
+	}{
+		{name: "string", input: input, keys: "country", expected: []string{"4", "2", "5", "1", "3"}},
+		{name: "number, invalid first", input: input, keys: "amount:number", expected: []string{"3", "5", "2", "1", "4"}},
+		{name: "number desc is stable, invalid first", input: input, keys: "amount:n:desc", expected: []string{"3", "1", "4", "2", "5"}},
+		{name: "date", input: input, keys: "joined:date", expected: []string{"5", "4", "2", "3", "1"}},
+		{name: "date desc, invalid first", input: input, keys: "joined:date:desc", expected: []string{"5", "1", "3", "2", "4"}},
+		{name: "several keys", input: input, keys: "country:desc,amount:n", expected: []string{"3", "1", "5", "2", "4"}},
+		{name: "index without header", input: "b,2\na,10\nc,1\n", noHeader: true, keys: "2:n", expected: []string{"c", "b", "a"}},
+		{name: "numbers as strings", input: "b,2\na,10\nc,1\n", noHeader: true, keys: "2", expected: []string{"c", "a", "b"}},
+		{name: "unknown column", input: input, keys: "nope", err: csv.ErrUnknownColumn},
+		{name: "name without header", input: "a,1\n", noHeader: true, keys: "x", err: errNoHeader},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			keys, err := ParseKeys(currTestCase.keys)
+			assert.NoError(t, err)
+
+			var sb strings.Builder
+			reader := csv.NewCsvReader(strings.NewReader(currTestCase.input), csv.WithHeader(!currTestCase.noHeader))
+			err = Sort(reader, csv.NewCsvWriter(&sb), keys)
+			if currTestCase.err != nil {
+				assert.True(t, errors.Is(err, currTestCase.err), err)
+				return
+			}
+			assert.NoError(t, err)
+
+			records, err := csv.NewCsvReader(strings.NewReader(sb.String()), csv.WithHeader(!currTestCase.noHeader)).Read()
+			assert.NoError(t, err)
+			ids := []string{}
+			for _, record := range records {
+				ids = append(ids, record[0])
+			}
+			assert.Equal(t, currTestCase.expected, ids)
+		})
+	}
+}
+
+func TestSortSpill(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("seq,group,value\n")
+	for i := 0; i < 3000; i++ {
+		fmt.Fprintf(&sb, "%d,g%d,\"%d\n%d\"\n", i, (i*7919)%13, (i*31)%97, i)
+	}
+	input := sb.String()
+
+	keys, err := ParseKeys("group,value:number:desc")
+	assert.NoError(t, err)
+
+	run := func(options ...Option) string {
+		var out strings.Builder
+		reader := csv.NewCsvReader(strings.NewReader(input), csv.WithHeader(true))
+		assert.NoError(t, Sort(reader, csv.NewCsvWriter(&out), keys, options...))
+		return out.String()
+	}
+
+	tempDir := t.TempDir()
+	inMemory := run()
+	// small enough for more than maxFanIn runs, so runs are merged in two passes
+	spilled := run(WithMemoryLimit(4<<10), WithTempDir(tempDir))
+	assert.Equal(t, inMemory, spilled)
+
+	entries, err := os.ReadDir(tempDir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestSortSpillKeepsSepLines(t *testing.T) {
+	t.Parallel()
+
+	keys, err := ParseKeys("v")
+	assert.NoError(t, err)
+
+	// every record is a run of its own, and a run starting with a sep= line must not lose it
+	var out strings.Builder
+	reader := csv.NewCsvReader(strings.NewReader("v\nsep=;\nb;c\na\n"), csv.WithHeader(true))
+	assert.NoError(t, Sort(reader, csv.NewCsvWriter(&out), keys, WithMemoryLimit(1), WithTempDir(t.TempDir())))
+	assert.Equal(t, "v\na\nb;c\nsep=;\n", out.String())
+}
+
+func TestParseKeys(t *testing.T) {
+	keys, err := ParseKeys("name, 2:n:desc, joined:date:asc")
+	assert.NoError(t, err)
+	assert.Equal(t, []Key{
+		{Name: "name", Type: String},
+		{Index: 1, Type: Number, Desc: true},
+		{Name: "joined", Type: Date},
+	}, keys)
+
+	for _, spec := range []string{"", "a:bogus", "0"} {
+		_, err := ParseKeys(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,273 @@
+package filter
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jeremyseow/csv-parser/csv"
+)
+
+type kind int
+
+const (
+	kindField kind = iota // raw text from a record, coerced according to what it is compared with
+	kindString
+	kindNumber
+	kindBool
+	kindNull
+)
+
+type value struct {
+	kind    kind
+	str     string
+	num     float64
+	boolean bool
+}
+
+func (v value) isNull() bool {
+	return v.kind == kindNull || (v.kind == kindField && v.str == "")
+}
+
+// node is a boolean expression evaluated against a record
+type node interface {
+	eval(record []string) bool
+}
+
+// operand is anything that can be compared
+type operand interface {
+	value(record []string) value
+}
+
+type column struct {
+	index int
+}
+
+func (c *column) value(record []string) value {
+	// short records, read leniently, are padded with nulls
+	if c.index >= len(record) {
+		return value{kind: kindNull}
+	}
+	return value{kind: kindField, str: record[c.index]}
+}
+
+type literal struct {
+	val value
+}
+
+func (l *literal) value(record []string) value {
+	return l.val
+}
+
+// group is a parenthesised expression used as an operand
+type group struct {
+	inner node
+}
+
+func (g *group) value(record []string) value {
+	return value{kind: kindBool, boolean: g.inner.eval(record)}
+}
+
+type orNode struct {
+	left, right node
+}
+
+func (n *orNode) eval(record []string) bool {
+	return n.left.eval(record) || n.right.eval(record)
+}
+
+type andNode struct {
+	left, right node
+}
+
+func (n *andNode) eval(record []string) bool {
+	return n.left.eval(record) && n.right.eval(record)
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) eval(record []string) bool {
+	return !n.operand.eval(record)
+}
+
+type compareNode struct {
+	op          string
+	left, right operand
+}
+
+func (n *compareNode) eval(record []string) bool {
+	return compare(n.op, n.left.value(record), n.right.value(record))
+}
+
+type inNode struct {
+	operand operand
+	list    []operand
+	negate  bool
+}
+
+func (n *inNode) eval(record []string) bool {
+	v := n.operand.value(record)
+	for _, item := range n.list {
+		if compare("==", v, item.value(record)) {
+			return !n.negate
+		}
+	}
+	return n.negate
+}
+
+type matchNode struct {
+	operand operand
+	pattern *regexp.Regexp
+	negate  bool
+}
+
+func (n *matchNode) eval(record []string) bool {
+	v := n.operand.value(record)
+	if v.isNull() {
+		return false
+	}
+	return n.pattern.MatchString(v.str) != n.negate
+}
+
+type nullNode struct {
+	operand operand
+	negate  bool
+}
+
+func (n *nullNode) eval(record []string) bool {
+	return n.operand.value(record).isNull() != n.negate
+}
+
+// truthyNode lets a lone operand be used as a condition, e.g. `active && !deleted`
+type truthyNode struct {
+	operand operand
+}
+
+func (n *truthyNode) eval(record []string) bool {
+	v := n.operand.value(record)
+	switch {
+	case v.isNull():
+		return false
+	case v.kind == kindBool:
+		return v.boolean
+	case v.kind == kindNumber:
+		return v.num != 0
+	}
+
+	b, err := strconv.ParseBool(strings.ToLower(v.str))
+	if err == nil {
+		return b
+	}
+	return true
+}
+
+func parseNumber(v value) (float64, bool) {
+	switch v.kind {
+	case kindNumber:
+		return v.num, true
+	case kindField, kindString:
+		num, err := strconv.ParseFloat(strings.TrimSpace(v.str), 64)
+		return num, err == nil
+	}
+	return 0, false
+}
+
+func parseBool(v value) (bool, bool) {
+	switch v.kind {
+	case kindBool:
+		return v.boolean, true
+	case kindField, kindString:
+		b, err := strconv.ParseBool(strings.ToLower(strings.TrimSpace(v.str)))
+		return b, err == nil
+	}
+	return false, false
+}
+
+// compare coerces both sides to a common type: numbers when either side is a number literal,
+// booleans when either is a boolean literal, and otherwise dates when both sides look like
+// dates, numbers when two fields both look like numbers, and plain strings as a last resort.
+// null only equals null, and a value that cannot be coerced never matches except with !=.
+func compare(op string, left, right value) bool {
+	if left.isNull() || right.isNull() {
+		switch op {
+		case "==":
+			return left.isNull() && right.isNull()
+		case "!=":
+			return left.isNull() != right.isNull()
+		}
+		return false
+	}
+
+	var cmp int
+	switch {
+	case left.kind == kindNumber || right.kind == kindNumber:
+		l, lok := parseNumber(left)
+		r, rok := parseNumber(right)
+		if !lok || !rok {
+			return op == "!="
+		}
+		cmp = compareOrdered(l, r)
+
+	case left.kind == kindBool || right.kind == kindBool:
+		l, lok := parseBool(left)
+		r, rok := parseBool(right)
+		if !lok || !rok {
+			return op == "!="
+		}
+		if op != "==" && op != "!=" {
+			return false
+		}
+		if l != r {
+			cmp = 1
+		}
+
+	default:
+		cmp = compareText(left, right)
+	}
+
+	switch op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+func compareText(left, right value) int {
+	if l, ok := csv.ParseTime(left.str); ok {
+		if r, ok := csv.ParseTime(right.str); ok {
+			return l.Compare(r)
+		}
+	}
+
+	if left.kind == kindField && right.kind == kindField {
+		l, lok := parseNumber(left)
+		r, rok := parseNumber(right)
+		if lok && rok {
+			return compareOrdered(l, r)
+		}
+	}
+
+	return strings.Compare(left.str, right.str)
+}
+
+func compareOrdered(l, r float64) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,90 @@
+package filter
+
+import (
+	"errors"
+
+	"github.com/jeremyseow/csv-parser/csv"
+)
+
+var errSyntax = errors.New("syntax error")
+
+// Expr is a compiled filter expression, safe to share between goroutines.
+//
+// Columns are referenced by header name, by `quoted name` when the name is not a plain
+// identifier, or by $1, $2 ... Literals are numbers, "strings" or 'strings', true, false
+// and null, where an empty field counts as null. Supported operators are == (or =), !=,
+// <, <=, >, >=, in (...), not in (...), =~ and !~ with a regular expression, is null,
+// is not null, and && (and), || (or), ! (not) with parentheses for grouping.
+type Expr struct {
+	source string
+	root   node
+}
+
+// Compile parses expr, resolving column names against header, which may be nil
+func Compile(expr string, header []string) (*Expr, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, header: header}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, syntaxError(tok.pos, "unexpected %q", tok.text)
+	}
+
+	return &Expr{source: expr, root: root}, nil
+}
+
+func (e *Expr) String() string {
+	return e.source
+}
+
+// Match reports whether record satisfies the expression
+func (e *Expr) Match(record []string) bool {
+	return e.root.eval(record)
+}
+
+// Reader only passes on the records of the underlying reader that match an expression
+type Reader struct {
+	reader csv.RecordReader
+	expr   *Expr
+}
+
+// Filter compiles expr against the header of r and returns a reader over the matching records
+func Filter(r csv.RecordReader, expr string) (*Reader, error) {
+	header, err := r.Header()
+	if err != nil {
+		return nil, err
+	}
+
+	compiled, err := Compile(expr, header)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reader{reader: r, expr: compiled}, nil
+}
+
+func (fr *Reader) Header() ([]string, error) {
+	return fr.reader.Header()
+}
+
+func (fr *Reader) ReadRecord() ([]string, error) {
+	for {
+		record, err := fr.reader.ReadRecord()
+		if err != nil {
+			return nil, err
+		}
+
+		if fr.expr.Match(record) {
+			return record, nil
+		}
+	}
+}
+
+var _ csv.RecordReader = (*Reader)(nil)
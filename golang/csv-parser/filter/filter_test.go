@@ -0,0 +1,90 @@
+package filter
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/stretchr/testify/assert"
+)
+
+const people = `id,name,amount,country,joined,active,email
+1,Ah Kow,150,SG,2024-03-01,true,ahkow@example.com
+2,Mei,50.5,MY,2023-12-31,false,
+3,Raj,1000,SG,2024-01-15T10:00:00Z,TRUE,raj@example.org
+4,Siti,,ID,,,siti@example.com
+`
+
+func TestFilter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expr     string
+		expected []string
+		err      error
+	}{
+		{name: "numeric comparison", expr: "amount > 100", expected: []string{"1", "3"}},
+		{name: "numbers are not compared as strings", expr: "amount < 200", expected: []string{"1", "2"}},
+		{name: "and with string equality", expr: `amount > 100 && country == "SG"`, expected: []string{"1", "3"}},
+		{name: "single equals and single quotes", expr: `country = 'MY'`, expected: []string{"2"}},
+		{name: "or and not", expr: `country == "ID" || !(amount >= 100)`, expected: []string{"2", "4"}},
+		{name: "keywords", expr: `country == "SG" and not active == false`, expected: []string{"1", "3"}},
+		{name: "in", expr: `country in ("MY", "ID")`, expected: []string{"2", "4"}},
+		{name: "not in with numbers", expr: `id not in (1, 2)`, expected: []string{"3", "4"}},
+		{name: "regex", expr: `email =~ "@example\\.com$"`, expected: []string{"1", "4"}},
+		{name: "negated regex skips nulls", expr: `email !~ "\\.com$"`, expected: []string{"3"}},
+		{name: "is null", expr: `amount is null || email is null`, expected: []string{"2", "4"}},
+		{name: "is not null", expr: `joined is not null`, expected: []string{"1", "2", "3"}},
+		{name: "null literal", expr: `email != null`, expected: []string{"1", "3", "4"}},
+		{name: "dates", expr: `joined >= "2024-01-01"`, expected: []string{"1", "3"}},
+		{name: "booleans are case insensitive", expr: `active == true`, expected: []string{"1", "3"}},
+		{name: "truthy column", expr: `active`, expected: []string{"1", "3"}},
+		{name: "column index and quoted name", expr: "$2 == \"Mei\" || `name` == \"Raj\"", expected: []string{"2", "3"}},
+		{name: "negative number", expr: `amount > -1 && amount < 60`, expected: []string{"2"}},
+		{name: "unknown column", expr: `nope == 1`, err: csv.ErrUnknownColumn},
+		{name: "unterminated string", expr: `name == "x`, err: errSyntax},
+		{name: "dangling operator", expr: `amount >`, err: errSyntax},
+		{name: "bad regex", expr: `name =~ "("`, err: errSyntax},
+		{name: "trailing tokens", expr: `amount > 1 2`, err: errSyntax},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			reader := csv.NewCsvReader(strings.NewReader(people), csv.WithHeader(true))
+			fr, err := Filter(reader, currTestCase.expr)
+			if currTestCase.err != nil {
+				assert.True(t, errors.Is(err, currTestCase.err), err)
+				return
+			}
+			assert.NoError(t, err)
+
+			ids := []string{}
+			for {
+				record, err := fr.ReadRecord()
+				if err == io.EOF {
+					break
+				}
+				assert.NoError(t, err)
+				ids = append(ids, record[0])
+			}
+			assert.Equal(t, currTestCase.expected, ids)
+		})
+	}
+}
+
+func BenchmarkMatch(b *testing.B) {
+	expr, err := Compile(`amount > 100 && country in ("SG", "MY") && email =~ "@example"`, []string{"id", "amount", "country", "email"})
+	if err != nil {
+		b.Fatal(err)
+	}
+	record := []string{"1", "150", "SG", "someone@example.com"}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		expr.Match(record)
+	}
+}
@@ -0,0 +1,367 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jeremyseow/csv-parser/csv"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenColumn // $1, $2 ... or `quoted name`
+	tokenNumber
+	tokenString
+	tokenOp
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	index int // $N columns
+	pos   int
+}
+
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		ch := expr[i]
+		start := i
+
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+			continue
+		case ch == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: start})
+			i++
+		case ch == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: start})
+			i++
+		case ch == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: start})
+			i++
+		case ch == '"' || ch == '\'':
+			value, n, err := readQuoted(expr[i:], ch)
+			if err != nil {
+				return nil, syntaxError(start, "%v", err)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: value, pos: start})
+			i += n
+		case ch == '`':
+			end := strings.IndexByte(expr[i+1:], '`')
+			if end < 0 {
+				return nil, syntaxError(start, "unterminated column name")
+			}
+			tokens = append(tokens, token{kind: tokenColumn, text: expr[i+1 : i+1+end], index: -1, pos: start})
+			i += end + 2
+		case ch == '$':
+			i++
+			for i < len(expr) && isDigit(expr[i]) {
+				i++
+			}
+			index, err := strconv.Atoi(expr[start+1 : i])
+			if err != nil || index < 1 {
+				return nil, syntaxError(start, "column indexes look like $1, $2, ...")
+			}
+			tokens = append(tokens, token{kind: tokenColumn, text: expr[start:i], index: index - 1, pos: start})
+		case isDigit(ch) || (ch == '-' && i+1 < len(expr) && isDigit(expr[i+1]) && !followsOperand(tokens)) || (ch == '.' && i+1 < len(expr) && isDigit(expr[i+1])):
+			i++
+			for i < len(expr) && (isDigit(expr[i]) || expr[i] == '.' || expr[i] == 'e' || expr[i] == 'E' ||
+				((expr[i] == '+' || expr[i] == '-') && (expr[i-1] == 'e' || expr[i-1] == 'E'))) {
+				i++
+			}
+			if _, err := strconv.ParseFloat(expr[start:i], 64); err != nil {
+				return nil, syntaxError(start, "bad number %q", expr[start:i])
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: expr[start:i], pos: start})
+		case isIdentStart(ch):
+			for i < len(expr) && isIdentPart(expr[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: expr[start:i], pos: start})
+		default:
+			op := ""
+			for _, candidate := range []string{"==", "!=", "<=", ">=", "=~", "!~", "&&", "||", "<", ">", "!", "="} {
+				if strings.HasPrefix(expr[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, syntaxError(start, "unexpected character %q", ch)
+			}
+			i += len(op)
+			if op == "=" {
+				op = "=="
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: start})
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(expr)}), nil
+}
+
+// followsOperand tells a binary minus from a negative number, although the language has no arithmetic yet
+func followsOperand(tokens []token) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	switch tokens[len(tokens)-1].kind {
+	case tokenIdent, tokenColumn, tokenNumber, tokenString, tokenRParen:
+		return true
+	}
+	return false
+}
+
+func readQuoted(s string, quote byte) (string, int, error) {
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case quote:
+			return sb.String(), i + 1, nil
+		case '\\':
+			if i+1 == len(s) {
+				return "", 0, fmt.Errorf("unterminated string")
+			}
+			i++
+			switch s[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			default:
+				sb.WriteByte(s[i])
+			}
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isIdentStart(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func isIdentPart(ch byte) bool {
+	return isIdentStart(ch) || isDigit(ch) || ch == '.'
+}
+
+// parser is a recursive descent parser, from the lowest precedence down:
+//
+//	or         := and (("||" | "or") and)*
+//	and        := not (("&&" | "and") not)*
+//	not        := ("!" | "not") not | comparison
+//	comparison := operand (op operand | ["not"] "in" list | "is" ["not"] "null" | ("=~" | "!~") string)?
+//	operand    := column | number | string | true | false | null | "(" or ")"
+type parser struct {
+	tokens []token
+	pos    int
+	header []string
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) isKeyword(tok token, keyword string) bool {
+	return tok.kind == tokenIdent && strings.EqualFold(tok.text, keyword)
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for tok := p.peek(); (tok.kind == tokenOp && tok.text == "||") || p.isKeyword(tok, "or"); tok = p.peek() {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for tok := p.peek(); (tok.kind == tokenOp && tok.text == "&&") || p.isKeyword(tok, "and"); tok = p.peek() {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if tok := p.peek(); (tok.kind == tokenOp && tok.text == "!") || p.isKeyword(tok, "not") {
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.peek()
+	switch {
+	case tok.kind == tokenOp && (tok.text == "=~" || tok.text == "!~"):
+		p.next()
+		patternTok := p.next()
+		if patternTok.kind != tokenString {
+			return nil, syntaxError(patternTok.pos, "expected a quoted regular expression after %s", tok.text)
+		}
+		pattern, err := regexp.Compile(patternTok.text)
+		if err != nil {
+			return nil, syntaxError(patternTok.pos, "%v", err)
+		}
+		return &matchNode{operand: left, pattern: pattern, negate: tok.text == "!~"}, nil
+
+	case tok.kind == tokenOp && tok.text != "!" && tok.text != "&&" && tok.text != "||":
+		p.next()
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return &compareNode{op: tok.text, left: left, right: right}, nil
+
+	case p.isKeyword(tok, "in") || (p.isKeyword(tok, "not") && p.isKeyword(p.tokens[p.pos+1], "in")):
+		negate := p.isKeyword(tok, "not")
+		if negate {
+			p.next()
+		}
+		p.next()
+		list, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return &inNode{operand: left, list: list, negate: negate}, nil
+
+	case p.isKeyword(tok, "is"):
+		p.next()
+		negate := false
+		if p.isKeyword(p.peek(), "not") {
+			p.next()
+			negate = true
+		}
+		if nullTok := p.next(); !p.isKeyword(nullTok, "null") {
+			return nil, syntaxError(nullTok.pos, "expected null after is")
+		}
+		return &nullNode{operand: left, negate: negate}, nil
+	}
+
+	return &truthyNode{operand: left}, nil
+}
+
+func (p *parser) parseList() ([]operand, error) {
+	if tok := p.next(); tok.kind != tokenLParen {
+		return nil, syntaxError(tok.pos, "expected ( after in")
+	}
+
+	var list []operand
+	for {
+		item, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+
+		tok := p.next()
+		if tok.kind == tokenRParen {
+			return list, nil
+		}
+		if tok.kind != tokenComma {
+			return nil, syntaxError(tok.pos, "expected , or ) in list")
+		}
+	}
+}
+
+func (p *parser) parseOperand() (operand, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber:
+		num, _ := strconv.ParseFloat(tok.text, 64)
+		return &literal{val: value{kind: kindNumber, str: tok.text, num: num}}, nil
+	case tokenString:
+		return &literal{val: value{kind: kindString, str: tok.text}}, nil
+	case tokenColumn:
+		if tok.index >= 0 {
+			return &column{index: tok.index}, nil
+		}
+		return p.column(tok)
+	case tokenIdent:
+		switch strings.ToLower(tok.text) {
+		case "true", "false":
+			return &literal{val: value{kind: kindBool, boolean: strings.EqualFold(tok.text, "true"), str: tok.text}}, nil
+		case "null":
+			return &literal{val: value{kind: kindNull}}, nil
+		}
+		return p.column(tok)
+	case tokenLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, syntaxError(closing.pos, "expected )")
+		}
+		return &group{inner: inner}, nil
+	case tokenEOF:
+		return nil, syntaxError(tok.pos, "unexpected end of expression")
+	}
+
+	return nil, syntaxError(tok.pos, "unexpected %q", tok.text)
+}
+
+func (p *parser) column(tok token) (operand, error) {
+	positions, err := csv.ColumnPositions(p.header, []string{tok.text})
+	if err != nil {
+		return nil, fmt.Errorf("%w at position %d", err, tok.pos+1)
+	}
+	return &column{index: positions[0]}, nil
+}
+
+func syntaxError(pos int, format string, args ...any) error {
+	return fmt.Errorf("%w at position %d: %s", errSyntax, pos+1, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,67 @@
+package spill
+
+import (
+	"hash/fnv"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/jeremyseow/csv-parser/csv"
+)
+
+// NumPartitions is how many files the records of an input that outgrows memory are hashed into
+const NumPartitions = 16
+
+// Partition is a temp file holding the records of some of the keys
+type Partition struct {
+	Path   string
+	File   *os.File
+	Writer *csv.CsvWriter
+}
+
+// NewPartitions creates NumPartitions partition files with createTemp
+func NewPartitions(createTemp func(pattern string) (*os.File, error)) ([]*Partition, error) {
+	partitions := make([]*Partition, NumPartitions)
+	for i := range partitions {
+		file, err := createTemp("partition-*.csv")
+		if err != nil {
+			return nil, err
+		}
+		partitions[i] = &Partition{Path: file.Name(), File: file, Writer: csv.NewCsvWriter(file)}
+	}
+	return partitions, nil
+}
+
+// Rewind flushes the records written to the partition and goes back to its start to read them
+func (p *Partition) Rewind() error {
+	if err := p.Writer.Flush(); err != nil {
+		return err
+	}
+	_, err := p.File.Seek(0, io.SeekStart)
+	return err
+}
+
+// Remove closes the partition file and deletes it
+func (p *Partition) Remove() {
+	p.File.Close()
+	os.Remove(p.Path)
+}
+
+// Index returns the partition of key. The depth is mixed in so that a partition splits
+// differently when it is partitioned again.
+func Index(key string, depth int) int {
+	h := fnv.New32a()
+	h.Write([]byte(strconv.Itoa(depth)))
+	h.Write([]byte(key))
+	return int(h.Sum32() % NumPartitions)
+}
+
+// RecordSize roughly estimates the memory a record takes
+func RecordSize(record []string) int64 {
+	// slice header plus a string header per field
+	size := int64(24 + 16*len(record))
+	for _, field := range record {
+		size += int64(len(field))
+	}
+	return size
+}
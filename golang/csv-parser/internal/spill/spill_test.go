@@ -0,0 +1,46 @@
+package spill
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartitions(t *testing.T) {
+	dir := t.TempDir()
+	partitions, err := NewPartitions(func(pattern string) (*os.File, error) {
+		return os.CreateTemp(dir, pattern)
+	})
+	assert.NoError(t, err)
+	assert.Len(t, partitions, NumPartitions)
+
+	written := map[int][][]string{}
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprint(i)
+		index := Index(key, 0)
+		assert.NoError(t, partitions[index].Writer.Write([]string{key}))
+		written[index] = append(written[index], []string{key})
+	}
+	// another depth splits the keys of a partition differently
+	assert.NotEqual(t, Index("1", 0), Index("1", 1))
+
+	for i, p := range partitions {
+		assert.NoError(t, p.Rewind())
+		records, err := csv.NewCsvReader(p.File).Read()
+		assert.NoError(t, err)
+		assert.Equal(t, written[i], records)
+		p.Remove()
+	}
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestRecordSize(t *testing.T) {
+	assert.Equal(t, int64(24), RecordSize(nil))
+	assert.Equal(t, int64(24+32+4), RecordSize([]string{"abc", "d"}))
+}
@@ -0,0 +1,506 @@
+package join
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/jeremyseow/csv-parser/extsort"
+	"github.com/jeremyseow/csv-parser/internal/spill"
+)
+
+var (
+	errNoHeader        = errors.New("joining needs a header on both sides")
+	errKeyMismatch     = errors.New("left and right need the same number of key columns")
+	errNoKeys          = errors.New("no key columns given")
+	errUnknownJoinKind = errors.New("unknown join type")
+)
+
+type Kind string
+
+const (
+	Inner Kind = "inner"
+	Left  Kind = "left"
+	Right Kind = "right"
+	Full  Kind = "full"
+)
+
+func ParseKind(name string) (Kind, error) {
+	switch strings.ToLower(name) {
+	case "inner":
+		return Inner, nil
+	case "left":
+		return Left, nil
+	case "right":
+		return Right, nil
+	case "full", "outer", "full-outer":
+		return Full, nil
+	}
+	return "", fmt.Errorf("%w: %s", errUnknownJoinKind, name)
+}
+
+type joiner struct {
+	kind        Kind
+	memoryLimit int64
+	tempDir     string
+	leftSuffix  string
+	rightSuffix string
+
+	leftKeyPos  []int
+	rightKeyPos []int
+	leftWidth   int
+	// rightKeep are the right columns in the output, all but the keys which are already on the left
+	rightKeep []int
+}
+
+type Option func(*joiner)
+
+// WithMemoryLimit sets roughly how many bytes of an input are held in a hash table, 256MB by
+// default. When neither input fits, both are sorted to disk instead. Finding out whether the
+// left input fits when the right one does not takes up to twice as much memory.
+func WithMemoryLimit(bytes int64) Option {
+	return func(j *joiner) {
+		j.memoryLimit = bytes
+	}
+}
+
+// WithTempDir sets where sorted inputs are written, the system temp dir by default
+func WithTempDir(dir string) Option {
+	return func(j *joiner) {
+		j.tempDir = dir
+	}
+}
+
+// WithSuffixes sets what is appended to the names of non key columns found on both sides,
+// "_left" and "_right" by default
+func WithSuffixes(leftSuffix, rightSuffix string) Option {
+	return func(j *joiner) {
+		j.leftSuffix = leftSuffix
+		j.rightSuffix = rightSuffix
+	}
+}
+
+// Join writes the records of left and right that agree on the key columns to w. The output
+// has the left columns followed by the right columns other than the keys; when a record only
+// exists on the right, its keys fill the left key columns. Keys with an empty field never match.
+//
+// When the right input fits in the memory limit it is loaded into a hash table and the output
+// follows the order of left, with unmatched right records at the end. Otherwise, when the left
+// input fits, it is the one loaded and the output follows the order of right, with unmatched
+// left records at the end. When neither fits, both inputs are sorted on disk and merged, and
+// the output is ordered by key.
+func Join(left, right csv.RecordReader, w *csv.CsvWriter, leftKeys, rightKeys []string, kind Kind, options ...Option) error {
+	if len(leftKeys) == 0 {
+		return errNoKeys
+	}
+	if rightKeys == nil {
+		rightKeys = leftKeys
+	}
+	if len(leftKeys) != len(rightKeys) {
+		return errKeyMismatch
+	}
+
+	j := &joiner{
+		kind:        kind,
+		memoryLimit: 256 << 20,
+		leftSuffix:  "_left",
+		rightSuffix: "_right",
+	}
+	for _, op := range options {
+		op(j)
+	}
+
+	switch kind {
+	case Inner, Left, Right, Full:
+	default:
+		return fmt.Errorf("%w: %s", errUnknownJoinKind, kind)
+	}
+
+	leftHeader, err := left.Header()
+	if err != nil {
+		return err
+	}
+	rightHeader, err := right.Header()
+	if err != nil {
+		return err
+	}
+	if leftHeader == nil || rightHeader == nil {
+		return errNoHeader
+	}
+
+	if j.leftKeyPos, err = csv.ColumnPositions(leftHeader, leftKeys); err != nil {
+		return err
+	}
+	if j.rightKeyPos, err = csv.ColumnPositions(rightHeader, rightKeys); err != nil {
+		return err
+	}
+	j.leftWidth = len(leftHeader)
+
+	if err := w.Write(j.outputHeader(leftHeader, rightHeader)); err != nil {
+		return err
+	}
+
+	if err := j.join(left, right, leftHeader, rightHeader, w); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// join picks the input to hold in a hash table, right first, and sorts both when neither fits
+func (j *joiner) join(left, right csv.RecordReader, leftHeader, rightHeader []string, w *csv.CsvWriter) error {
+	rightRecords, fits, err := j.buffer(right)
+	if err != nil {
+		return err
+	}
+	if fits {
+		return j.hashJoin(rightRecords, left, false, w)
+	}
+	rightRest := &replayReader{header: rightHeader, records: rightRecords, reader: right}
+
+	leftRecords, fits, err := j.buffer(left)
+	if err != nil {
+		return err
+	}
+	if fits {
+		return j.hashJoin(leftRecords, rightRest, true, w)
+	}
+	return j.sortMergeJoin(&replayReader{header: leftHeader, records: leftRecords, reader: left}, rightRest, w)
+}
+
+// buffer reads the records of r until it is exhausted, when they fit, or past the memory limit
+func (j *joiner) buffer(r csv.RecordReader) ([][]string, bool, error) {
+	var records [][]string
+	var used int64
+	for used <= j.memoryLimit {
+		record, err := r.ReadRecord()
+		if err == io.EOF {
+			return records, true, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		records = append(records, record)
+		used += spill.RecordSize(record) + heldRowSize
+	}
+	return records, false, nil
+}
+
+func (j *joiner) outputHeader(leftHeader, rightHeader []string) []string {
+	isRightKey := map[int]bool{}
+	for _, pos := range j.rightKeyPos {
+		isRightKey[pos] = true
+	}
+	isLeftKey := map[int]bool{}
+	for _, pos := range j.leftKeyPos {
+		isLeftKey[pos] = true
+	}
+
+	j.rightKeep = nil
+	rightNames := map[string]bool{}
+	for i, name := range rightHeader {
+		if !isRightKey[i] {
+			j.rightKeep = append(j.rightKeep, i)
+			rightNames[name] = true
+		}
+	}
+
+	header := make([]string, 0, len(leftHeader)+len(j.rightKeep))
+	leftNames := map[string]bool{}
+	for i, name := range leftHeader {
+		leftNames[name] = true
+		if !isLeftKey[i] && rightNames[name] {
+			name += j.leftSuffix
+		}
+		header = append(header, name)
+	}
+	for _, i := range j.rightKeep {
+		name := rightHeader[i]
+		if leftNames[name] {
+			name += j.rightSuffix
+		}
+		header = append(header, name)
+	}
+	return header
+}
+
+// key joins the key fields, ok is false when one of them is empty
+func key(record []string, pos []int) (string, bool) {
+	fields := make([]string, len(pos))
+	for i, p := range pos {
+		if p >= len(record) || record[p] == "" {
+			return "", false
+		}
+		fields[i] = record[p]
+	}
+	return strings.Join(fields, "\x00"), true
+}
+
+func (j *joiner) output(l, r []string) []string {
+	row := make([]string, j.leftWidth+len(j.rightKeep))
+	if l != nil {
+		copy(row, l)
+	} else {
+		for i, pos := range j.leftKeyPos {
+			if j.rightKeyPos[i] < len(r) {
+				row[pos] = r[j.rightKeyPos[i]]
+			}
+		}
+	}
+
+	if r != nil {
+		for i, pos := range j.rightKeep {
+			if pos < len(r) {
+				row[j.leftWidth+i] = r[pos]
+			}
+		}
+	}
+	return row
+}
+
+type heldRow struct {
+	record  []string
+	matched bool
+}
+
+// heldRowSize roughly accounts for a heldRow and the pointers to it besides its record
+const heldRowSize = 40
+
+// hashJoin loads the held records, from the left input when heldLeft is set and the right one
+// otherwise, into a hash table and looks up the records of the other input in it
+func (j *joiner) hashJoin(held [][]string, streamed csv.RecordReader, heldLeft bool, w *csv.CsvWriter) error {
+	heldKeyPos, streamedKeyPos := j.rightKeyPos, j.leftKeyPos
+	keepHeld, keepStreamed := j.kind == Right || j.kind == Full, j.kind == Left || j.kind == Full
+	if heldLeft {
+		heldKeyPos, streamedKeyPos = streamedKeyPos, heldKeyPos
+		keepHeld, keepStreamed = keepStreamed, keepHeld
+	}
+	// output takes the left record first whichever input is held
+	output := func(held, streamed []string) []string {
+		if heldLeft {
+			return j.output(held, streamed)
+		}
+		return j.output(streamed, held)
+	}
+
+	table := map[string][]*heldRow{}
+	rows := make([]*heldRow, len(held))
+	for i, record := range held {
+		rows[i] = &heldRow{record: record}
+		if k, ok := key(record, heldKeyPos); ok {
+			table[k] = append(table[k], rows[i])
+		}
+	}
+
+	for {
+		record, err := streamed.ReadRecord()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		var matches []*heldRow
+		if k, ok := key(record, streamedKeyPos); ok {
+			matches = table[k]
+		}
+
+		for _, match := range matches {
+			match.matched = true
+			if err := w.Write(output(match.record, record)); err != nil {
+				return err
+			}
+		}
+
+		if len(matches) == 0 && keepStreamed {
+			if err := w.Write(output(nil, record)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if keepHeld {
+		for _, row := range rows {
+			if !row.matched {
+				if err := w.Write(output(row.record, nil)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (j *joiner) sortMergeJoin(left, right csv.RecordReader, w *csv.CsvWriter) error {
+	dir, err := os.MkdirTemp(j.tempDir, "csv-join-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	leftSorted, err := j.sortToFile(left, j.leftKeyPos, dir)
+	if err != nil {
+		return err
+	}
+	defer leftSorted.Close()
+
+	rightSorted, err := j.sortToFile(right, j.rightKeyPos, dir)
+	if err != nil {
+		return err
+	}
+	defer rightSorted.Close()
+
+	lc, err := newCursor(leftSorted, j.leftKeyPos)
+	if err != nil {
+		return err
+	}
+	rc, err := newCursor(rightSorted, j.rightKeyPos)
+	if err != nil {
+		return err
+	}
+
+	for lc.record != nil || rc.record != nil {
+		c := compareCursors(lc, rc)
+		switch {
+		case c < 0:
+			if j.kind == Left || j.kind == Full {
+				if err := w.Write(j.output(lc.record, nil)); err != nil {
+					return err
+				}
+			}
+			err = lc.advance()
+
+		case c > 0:
+			if j.kind == Right || j.kind == Full {
+				if err := w.Write(j.output(nil, rc.record)); err != nil {
+					return err
+				}
+			}
+			err = rc.advance()
+
+		default:
+			// every right record with this key is held while the left ones are paired with it
+			k := rc.key
+			var group [][]string
+			for rc.record != nil && rc.ok && rc.key == k {
+				group = append(group, rc.record)
+				if err := rc.advance(); err != nil {
+					return err
+				}
+			}
+			for lc.record != nil && lc.ok && lc.key == k {
+				for _, r := range group {
+					if err := w.Write(j.output(lc.record, r)); err != nil {
+						return err
+					}
+				}
+				if err := lc.advance(); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (j *joiner) sortToFile(r csv.RecordReader, keyPos []int, dir string) (*os.File, error) {
+	file, err := os.CreateTemp(dir, "sorted-*.csv")
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]extsort.Key, len(keyPos))
+	for i, pos := range keyPos {
+		keys[i] = extsort.Key{Index: pos, Type: extsort.String}
+	}
+
+	err = extsort.Sort(r, csv.NewCsvWriter(file), keys,
+		extsort.WithMemoryLimit(j.memoryLimit),
+		extsort.WithTempDir(dir))
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// cursor walks a sorted input. Records with an empty key field sort first and never match.
+type cursor struct {
+	reader *csv.CsvReader
+	keyPos []int
+	record []string
+	key    string
+	ok     bool
+}
+
+func newCursor(file *os.File, keyPos []int) (*cursor, error) {
+	c := &cursor{reader: csv.NewCsvReader(file, csv.WithHeader(true), csv.WithLenient(true)), keyPos: keyPos}
+	return c, c.advance()
+}
+
+func (c *cursor) advance() error {
+	record, err := c.reader.ReadRecord()
+	if err == io.EOF {
+		c.record = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	c.record = record
+	c.key, c.ok = key(record, c.keyPos)
+	return nil
+}
+
+// compareCursors orders unmatchable records first so they are flushed out on their own
+func compareCursors(lc, rc *cursor) int {
+	switch {
+	case rc.record == nil:
+		return -1
+	case lc.record == nil:
+		return 1
+	case !lc.ok:
+		return -1
+	case !rc.ok:
+		return 1
+	}
+
+	for i := range lc.keyPos {
+		if c := strings.Compare(lc.record[lc.keyPos[i]], rc.record[rc.keyPos[i]]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// replayReader hands back records already read from reader before reading on
+type replayReader struct {
+	header  []string
+	records [][]string
+	reader  csv.RecordReader
+}
+
+func (rr *replayReader) Header() ([]string, error) {
+	return rr.header, nil
+}
+
+func (rr *replayReader) ReadRecord() ([]string, error) {
+	if len(rr.records) > 0 {
+		record := rr.records[0]
+		rr.records = rr.records[1:]
+		return record, nil
+	}
+	return rr.reader.ReadRecord()
+}
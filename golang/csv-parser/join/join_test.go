@@ -0,0 +1,154 @@
+package join
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJoin(t *testing.T) {
+	customers := "id,name,country\n1,alice,SG\n2,bob,MY\n3,carol,SG\n,nobody,ID\n"
+	orders := "order,customer_id,amount,country\n10,1,5,SG\n11,1,7,MY\n12,3,2,SG\n13,4,9,ID\n14,,1,SG\n"
+
+	testCases := []struct {
+		name      string
+		kind      Kind
+		leftKeys  []string
+		rightKeys []string
+		options   []Option
+		expected  string
+		err       error
+	}{
+		{
+			name: "inner", kind: Inner, leftKeys: []string{"id"}, rightKeys: []string{"customer_id"},
+			expected: "id,name,country_left,order,amount,country_right\n1,alice,SG,10,5,SG\n1,alice,SG,11,7,MY\n3,carol,SG,12,2,SG\n",
+		},
+		{
+			name: "left", kind: Left, leftKeys: []string{"id"}, rightKeys: []string{"customer_id"},
+			expected: "id,name,country_left,order,amount,country_right\n1,alice,SG,10,5,SG\n1,alice,SG,11,7,MY\n2,bob,MY,,,\n3,carol,SG,12,2,SG\n,nobody,ID,,,\n",
+		},
+		{
+			name: "right fills the left keys", kind: Right, leftKeys: []string{"id"}, rightKeys: []string{"customer_id"},
+			expected: "id,name,country_left,order,amount,country_right\n1,alice,SG,10,5,SG\n1,alice,SG,11,7,MY\n3,carol,SG,12,2,SG\n4,,,13,9,ID\n,,,14,1,SG\n",
+		},
+		{
+			name: "full", kind: Full, leftKeys: []string{"id"}, rightKeys: []string{"customer_id"},
+			expected: "id,name,country_left,order,amount,country_right\n1,alice,SG,10,5,SG\n1,alice,SG,11,7,MY\n2,bob,MY,,,\n3,carol,SG,12,2,SG\n,nobody,ID,,,\n4,,,13,9,ID\n,,,14,1,SG\n",
+		},
+		{
+			name: "several keys", kind: Inner, leftKeys: []string{"id", "country"}, rightKeys: []string{"customer_id", "country"},
+			expected: "id,name,country,order,amount\n1,alice,SG,10,5\n3,carol,SG,12,2\n",
+		},
+		{
+			name: "suffixes", kind: Inner, leftKeys: []string{"id"}, rightKeys: []string{"customer_id"}, options: []Option{WithSuffixes("", "_order")},
+			expected: "id,name,country,order,amount,country_order\n1,alice,SG,10,5,SG\n1,alice,SG,11,7,MY\n3,carol,SG,12,2,SG\n",
+		},
+		{name: "unknown column", kind: Inner, leftKeys: []string{"id"}, rightKeys: []string{"nope"}, err: csv.ErrUnknownColumn},
+		{name: "key count", kind: Inner, leftKeys: []string{"id"}, rightKeys: []string{"customer_id", "country"}, err: errKeyMismatch},
+		{name: "unknown kind", kind: "cross", leftKeys: []string{"id"}, rightKeys: []string{"customer_id"}, err: errUnknownJoinKind},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var sb strings.Builder
+			left := csv.NewCsvReader(strings.NewReader(customers), csv.WithHeader(true))
+			right := csv.NewCsvReader(strings.NewReader(orders), csv.WithHeader(true))
+			err := Join(left, right, csv.NewCsvWriter(&sb), currTestCase.leftKeys, currTestCase.rightKeys, currTestCase.kind, currTestCase.options...)
+			if currTestCase.err != nil {
+				assert.True(t, errors.Is(err, currTestCase.err), err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, currTestCase.expected, sb.String())
+		})
+	}
+}
+
+func TestJoinSortMerge(t *testing.T) {
+	var left, right strings.Builder
+	left.WriteString("id,name\n")
+	right.WriteString("id,value\n")
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&left, "%d,\"name\n%d\"\n", (i*7919)%2500, i)
+		if i%3 != 0 {
+			fmt.Fprintf(&right, "%d,%d\n", (i*31)%2200, i)
+		}
+	}
+	fmt.Fprintf(&right, ",empty\n")
+
+	// the hash join and the sort-merge join only differ in the order of their output
+	run := func(kind Kind, options ...Option) []string {
+		var out strings.Builder
+		l := csv.NewCsvReader(strings.NewReader(left.String()), csv.WithHeader(true))
+		r := csv.NewCsvReader(strings.NewReader(right.String()), csv.WithHeader(true))
+		assert.NoError(t, Join(l, r, csv.NewCsvWriter(&out), []string{"id"}, nil, kind, options...))
+
+		records, err := csv.NewCsvReader(strings.NewReader(out.String())).Read()
+		assert.NoError(t, err)
+		rows := make([]string, len(records))
+		for i, record := range records {
+			rows[i] = strings.Join(record, "|")
+		}
+		sort.Strings(rows)
+		return rows
+	}
+
+	tempDir := t.TempDir()
+	for _, kind := range []Kind{Inner, Left, Right, Full} {
+		hashed := run(kind)
+		merged := run(kind, WithMemoryLimit(8<<10), WithTempDir(tempDir))
+		assert.Equal(t, hashed, merged, kind)
+	}
+
+	entries, err := os.ReadDir(tempDir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestJoinHoldsLeft(t *testing.T) {
+	customers := "id,name\n1,alice\n2,bob\n3,carol\n"
+	var orders strings.Builder
+	orders.WriteString("order,customer_id\n")
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(&orders, "%d,%d\n", i, i%5)
+	}
+
+	run := func(kind Kind, options ...Option) (string, error) {
+		var out strings.Builder
+		l := csv.NewCsvReader(strings.NewReader(customers), csv.WithHeader(true))
+		r := csv.NewCsvReader(strings.NewReader(orders.String()), csv.WithHeader(true))
+		err := Join(l, r, csv.NewCsvWriter(&out), []string{"id"}, []string{"customer_id"}, kind, options...)
+		return out.String(), err
+	}
+	sorted := func(out string) []string {
+		rows := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+		sort.Strings(rows[1:])
+		return rows
+	}
+
+	// sorting on disk would fail in a directory that does not exist
+	missing := filepath.Join(t.TempDir(), "missing")
+	for _, kind := range []Kind{Inner, Left, Right, Full} {
+		hashed, err := run(kind)
+		assert.NoError(t, err)
+		heldLeft, err := run(kind, WithMemoryLimit(1<<10), WithTempDir(missing))
+		assert.NoError(t, err, kind)
+		assert.Equal(t, sorted(hashed), sorted(heldLeft), kind)
+		// the output follows the order of right
+		first := "1,alice,1"
+		if kind == Right || kind == Full {
+			first = "0,,0"
+		}
+		assert.Equal(t, first, strings.Split(heldLeft, "\n")[1], kind)
+	}
+}
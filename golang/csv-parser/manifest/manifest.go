@@ -0,0 +1,218 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/jeremyseow/csv-parser/csv"
+)
+
+var (
+	errBadHash      = errors.New("bad hash")
+	errBadChunkSize = errors.New("chunk size must be positive")
+	errChunkSize    = errors.New("manifests of different chunk sizes cannot be compared")
+)
+
+// the first byte of every hashed message says what is hashed, so that a row can never
+// hash the same as a chunk or a tree node
+const (
+	rowPrefix   = 0
+	chunkPrefix = 1
+	nodePrefix  = 2
+)
+
+// Hash is a SHA-256 sum, written in hex
+type Hash [sha256.Size]byte
+
+func (h Hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+func (h *Hash) UnmarshalText(text []byte) error {
+	if hex.DecodedLen(len(text)) != len(h) {
+		return fmt.Errorf("%w: %q", errBadHash, text)
+	}
+	if _, err := hex.Decode(h[:], text); err != nil {
+		return fmt.Errorf("%w: %v", errBadHash, err)
+	}
+	return nil
+}
+
+// RowHash hashes the values of a record as parsed, so that the same values hash the same
+// however they were quoted. Every value is prefixed with its length, which keeps ["a,b"]
+// and ["a", "b"] apart.
+func RowHash(record []string) Hash {
+	h := sha256.New()
+	h.Write([]byte{rowPrefix})
+	writeLength(h, len(record))
+	for _, value := range record {
+		writeLength(h, len(value))
+		io.WriteString(h, value)
+	}
+
+	var sum Hash
+	h.Sum(sum[:0])
+	return sum
+}
+
+func writeLength(h hash.Hash, n int) {
+	var buf [binary.MaxVarintLen64]byte
+	h.Write(buf[:binary.PutUvarint(buf[:], uint64(n))])
+}
+
+// Chunk is a run of consecutive records. Its hash is rolled over the hashes of its records.
+type Chunk struct {
+	// FirstRow is the 1-based number of the first record of the chunk, the header not counted
+	FirstRow int  `json:"first_row"`
+	Rows     int  `json:"rows"`
+	Hash     Hash `json:"hash"`
+}
+
+// Manifest describes the data of a csv file. Root is the root of a Merkle tree over the hash
+// of the columns and the hashes of the chunks, so that two files parsed to the same data
+// exactly when their roots are equal, and the chunks that differ can be told from the others.
+type Manifest struct {
+	Rows      int      `json:"rows"`
+	Columns   []string `json:"columns"`
+	ChunkSize int      `json:"chunk_size"`
+	Root      Hash     `json:"root"`
+	Chunks    []Chunk  `json:"chunks"`
+}
+
+type builder struct {
+	chunkSize int
+}
+
+type Option func(*builder)
+
+// WithChunkSize sets how many records make a chunk, 10000 by default
+func WithChunkSize(rows int) Option {
+	return func(b *builder) {
+		b.chunkSize = rows
+	}
+}
+
+// Build reads every record of r and returns its manifest
+func Build(r csv.RecordReader, options ...Option) (*Manifest, error) {
+	b := &builder{chunkSize: 10000}
+	for _, op := range options {
+		op(b)
+	}
+	if b.chunkSize <= 0 {
+		return nil, fmt.Errorf("%w: %d", errBadChunkSize, b.chunkSize)
+	}
+
+	header, err := r.Header()
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{Columns: header, ChunkSize: b.chunkSize}
+	if m.Columns == nil {
+		m.Columns = []string{}
+	}
+
+	var chunk hash.Hash
+	closeChunk := func() {
+		last := &m.Chunks[len(m.Chunks)-1]
+		chunk.Sum(last.Hash[:0])
+	}
+	for {
+		record, err := r.ReadRecord()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if m.Rows%b.chunkSize == 0 {
+			if chunk != nil {
+				closeChunk()
+			}
+			chunk = sha256.New()
+			chunk.Write([]byte{chunkPrefix})
+			m.Chunks = append(m.Chunks, Chunk{FirstRow: m.Rows + 1})
+		}
+
+		m.Rows++
+		m.Chunks[len(m.Chunks)-1].Rows++
+		rowHash := RowHash(record)
+		chunk.Write(rowHash[:])
+	}
+	if chunk != nil {
+		closeChunk()
+	}
+
+	m.Root = m.root()
+	return m, nil
+}
+
+// root builds the tree level by level, an odd node out going up a level as it is
+func (m *Manifest) root() Hash {
+	level := []Hash{RowHash(m.Columns)}
+	for _, chunk := range m.Chunks {
+		level = append(level, chunk.Hash)
+	}
+
+	for len(level) > 1 {
+		var next []Hash
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+
+			var node Hash
+			h := sha256.New()
+			h.Write([]byte{nodePrefix})
+			h.Write(level[i][:])
+			h.Write(level[i+1][:])
+			h.Sum(node[:0])
+			next = append(next, node)
+		}
+		level = next
+	}
+	return level[0]
+}
+
+// Changes is what differs between two manifests
+type Changes struct {
+	Columns bool
+	// Chunks are the indexes of the chunks that differ, or that only one manifest has
+	Chunks []int
+}
+
+// Unchanged reports whether the manifests describe the same data
+func (c *Changes) Unchanged() bool {
+	return !c.Columns && len(c.Chunks) == 0
+}
+
+// Compare tells which parts of the data described by two manifests differ. The manifests
+// must have been built with the same chunk size.
+func Compare(expected, actual *Manifest) (*Changes, error) {
+	if expected.ChunkSize != actual.ChunkSize {
+		return nil, fmt.Errorf("%w: %d and %d", errChunkSize, expected.ChunkSize, actual.ChunkSize)
+	}
+
+	changes := &Changes{}
+	if expected.Root == actual.Root {
+		return changes, nil
+	}
+
+	changes.Columns = RowHash(expected.Columns) != RowHash(actual.Columns)
+	for i := 0; i < len(expected.Chunks) || i < len(actual.Chunks); i++ {
+		if i >= len(expected.Chunks) || i >= len(actual.Chunks) || expected.Chunks[i] != actual.Chunks[i] {
+			changes.Chunks = append(changes.Chunks, i)
+		}
+	}
+	return changes, nil
+}
@@ -0,0 +1,107 @@
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/stretchr/testify/assert"
+)
+
+func build(t *testing.T, input string, options ...Option) *Manifest {
+	m, err := Build(csv.NewCsvReader(strings.NewReader(input), csv.WithHeader(true)), options...)
+	assert.NoError(t, err)
+	return m
+}
+
+func TestRowHash(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, RowHash([]string{"a", "b"}), RowHash([]string{"a", "b"}))
+	assert.NotEqual(t, RowHash([]string{"a,b"}), RowHash([]string{"a", "b"}))
+	assert.NotEqual(t, RowHash([]string{"ab", ""}), RowHash([]string{"a", "b"}))
+	assert.NotEqual(t, RowHash([]string{}), RowHash([]string{""}))
+}
+
+func TestCompare(t *testing.T) {
+	input := "id,name\n1,ann\n2,bob\n3,cat\n4,dan\n5,eve\n"
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected *Changes
+		err      error
+	}{
+		{
+			name:     "quoting and line endings do not matter",
+			input:    "\"id\",name\r\n1,\"ann\"\r\n2,bob\r\n\r\n3,cat\r\n4,dan\r\n5,\"eve\"",
+			expected: &Changes{},
+		},
+		{
+			name:     "changed value",
+			input:    "id,name\n1,ann\n2,bob\n3,cat\n4,dan\n5,Eve\n",
+			expected: &Changes{Chunks: []int{2}},
+		},
+		{
+			name:     "inserted record shifts the later chunks",
+			input:    "id,name\n1,ann\n2,bob\n2.5,bea\n3,cat\n4,dan\n5,eve\n",
+			expected: &Changes{Chunks: []int{1, 2}},
+		},
+		{
+			name:     "missing records",
+			input:    "id,name\n1,ann\n2,bob\n",
+			expected: &Changes{Chunks: []int{1, 2}},
+		},
+		{
+			name:     "renamed column",
+			input:    "id,full_name\n1,ann\n2,bob\n3,cat\n4,dan\n5,eve\n",
+			expected: &Changes{Columns: true},
+		},
+	}
+
+	expected := build(t, input, WithChunkSize(2))
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual := build(t, currTestCase.input, WithChunkSize(2))
+			changes, err := Compare(expected, actual)
+			assert.NoError(t, err)
+			assert.Equal(t, currTestCase.expected, changes)
+			assert.Equal(t, changes.Unchanged(), expected.Root == actual.Root)
+		})
+	}
+
+	_, err := Compare(expected, build(t, input))
+	assert.True(t, errors.Is(err, errChunkSize), err)
+}
+
+func TestBuild(t *testing.T) {
+	t.Parallel()
+
+	m := build(t, "a,b\n1,2\n3,4\n5,6\n", WithChunkSize(2))
+	assert.Equal(t, 3, m.Rows)
+	assert.Equal(t, []string{"a", "b"}, m.Columns)
+	assert.Len(t, m.Chunks, 2)
+	assert.Equal(t, 3, m.Chunks[1].FirstRow)
+	assert.Equal(t, 1, m.Chunks[1].Rows)
+
+	// a manifest survives its JSON form
+	data, err := json.Marshal(m)
+	assert.NoError(t, err)
+	var decoded Manifest
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, m, &decoded)
+
+	empty := build(t, "")
+	assert.Equal(t, 0, empty.Rows)
+	assert.Empty(t, empty.Chunks)
+
+	_, err = Build(csv.NewCsvReader(strings.NewReader("a\n")), WithChunkSize(0))
+	assert.True(t, errors.Is(err, errBadChunkSize), err)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"root": "abc"}`), &decoded))
+}
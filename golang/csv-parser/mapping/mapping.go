@@ -0,0 +1,307 @@
+package mapping
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jeremyseow/csv-parser/csv"
+)
+
+var (
+	errNoHeader       = errors.New("mapping columns by name needs a header")
+	errMissingColumns = errors.New("missing required columns")
+)
+
+// Column is a canonical column, and the other names it goes by in the inputs
+type Column struct {
+	Name     string
+	Aliases  []string
+	Required bool
+}
+
+type MatchKind string
+
+const (
+	ByName  MatchKind = "name"
+	ByAlias MatchKind = "alias"
+	ByFuzzy MatchKind = "fuzzy"
+)
+
+// Match is an input column mapped to a canonical column
+type Match struct {
+	Header   string
+	Position int
+	Column   string
+	By       MatchKind
+}
+
+// Unmapped is an input column no canonical column was found for. Suggestions are the
+// canonical columns whose names are within the edit distance, closest first.
+type Unmapped struct {
+	Header      string
+	Position    int
+	Suggestions []string
+}
+
+// Report is how a header maps to the canonical columns. Missing lists the required columns
+// no input column was mapped to.
+type Report struct {
+	Matches  []Match
+	Unmapped []Unmapped
+	Missing  []string
+}
+
+type mapper struct {
+	normalization []csv.Normalization
+	maxDistance   int
+	fuzzy         bool
+}
+
+type Option func(*mapper)
+
+// WithNormalization sets how names are normalized before they are compared. By default byte
+// order marks, case, spaces and punctuation are ignored, so that "Customer ID", "customer_id"
+// and "CUSTOMERID" all name the same column.
+func WithNormalization(steps ...csv.Normalization) Option {
+	return func(m *mapper) {
+		m.normalization = steps
+	}
+}
+
+// WithMaxDistance sets the largest edit distance between normalized names for a canonical
+// column to be suggested, 2 by default
+func WithMaxDistance(distance int) Option {
+	return func(m *mapper) {
+		m.maxDistance = distance
+	}
+}
+
+// WithFuzzy maps an input column to its closest suggestion when there is only one at that
+// distance, instead of only suggesting it
+func WithFuzzy(fuzzy bool) Option {
+	return func(m *mapper) {
+		m.fuzzy = fuzzy
+	}
+}
+
+func newMapper(options []Option) *mapper {
+	m := &mapper{
+		normalization: []csv.Normalization{csv.NormalizeBOM, csv.NormalizeSnake, csv.NormalizePunct},
+		maxDistance:   2,
+	}
+	for _, op := range options {
+		op(m)
+	}
+	return m
+}
+
+// Map maps the names of header to columns. An input column is mapped by the name of a column
+// first, then by its aliases, and then, when WithFuzzy is set, by edit distance. Every column
+// is mapped at most once, to the first input column matching it.
+func Map(header []string, columns []Column, options ...Option) *Report {
+	return newMapper(options).mapHeader(header, columns)
+}
+
+func (m *mapper) key(name string) string {
+	return csv.NormalizeHeader(name, m.normalization...)
+}
+
+func (m *mapper) mapHeader(header []string, columns []Column) *Report {
+	// names take precedence over aliases, and earlier columns over later ones
+	type target struct {
+		column int
+		by     MatchKind
+	}
+	targets := map[string]target{}
+	for i, column := range columns {
+		if _, ok := targets[m.key(column.Name)]; !ok {
+			targets[m.key(column.Name)] = target{column: i, by: ByName}
+		}
+	}
+	for i, column := range columns {
+		for _, alias := range column.Aliases {
+			if _, ok := targets[m.key(alias)]; !ok {
+				targets[m.key(alias)] = target{column: i, by: ByAlias}
+			}
+		}
+	}
+
+	report := &Report{}
+	taken := make([]bool, len(columns))
+	var rest []int
+	for pos, name := range header {
+		t, ok := targets[m.key(name)]
+		if !ok || taken[t.column] {
+			rest = append(rest, pos)
+			continue
+		}
+		taken[t.column] = true
+		report.Matches = append(report.Matches, Match{Header: name, Position: pos, Column: columns[t.column].Name, By: t.by})
+	}
+
+	// the exact matches are all made before any fuzzy one, which could otherwise take a
+	// column from a later input column naming it exactly
+	for _, pos := range rest {
+		suggestions := m.suggest(header[pos], columns, taken)
+		if m.fuzzy && len(suggestions) > 0 && (len(suggestions) == 1 || suggestions[0].distance < suggestions[1].distance) {
+			taken[suggestions[0].column] = true
+			report.Matches = append(report.Matches, Match{Header: header[pos], Position: pos, Column: columns[suggestions[0].column].Name, By: ByFuzzy})
+			continue
+		}
+
+		unmapped := Unmapped{Header: header[pos], Position: pos}
+		for _, s := range suggestions {
+			unmapped.Suggestions = append(unmapped.Suggestions, columns[s.column].Name)
+		}
+		report.Unmapped = append(report.Unmapped, unmapped)
+	}
+	sort.SliceStable(report.Matches, func(i, j int) bool {
+		return report.Matches[i].Position < report.Matches[j].Position
+	})
+
+	for i, column := range columns {
+		if column.Required && !taken[i] {
+			report.Missing = append(report.Missing, column.Name)
+		}
+	}
+	return report
+}
+
+type suggestion struct {
+	column   int
+	distance int
+}
+
+// suggest lists the columns not taken yet within the edit distance of name, closest first
+func (m *mapper) suggest(name string, columns []Column, taken []bool) []suggestion {
+	k := m.key(name)
+	var suggestions []suggestion
+	for i, column := range columns {
+		if taken[i] {
+			continue
+		}
+
+		best := -1
+		for _, other := range append([]string{column.Name}, column.Aliases...) {
+			if d := distance(k, m.key(other)); best < 0 || d < best {
+				best = d
+			}
+		}
+		if best <= m.maxDistance {
+			suggestions = append(suggestions, suggestion{column: i, distance: best})
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].distance < suggestions[j].distance
+	})
+	return suggestions
+}
+
+// distance is the Levenshtein distance between a and b, counted in runes
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// Reader returns the records of another RecordReader in the canonical columns, in the order
+// they are given. Columns no input column was mapped to are left empty, and the input columns
+// that were not mapped are dropped.
+type Reader struct {
+	r       csv.RecordReader
+	columns []Column
+	mapper  *mapper
+
+	report    *Report
+	header    []string
+	positions []int
+	started   bool
+	err       error
+}
+
+func NewReader(r csv.RecordReader, columns []Column, options ...Option) *Reader {
+	return &Reader{r: r, columns: columns, mapper: newMapper(options)}
+}
+
+// Header maps the header of the input and returns the names of the canonical columns. It
+// fails when a required column is missing, before any record is read.
+func (mr *Reader) Header() ([]string, error) {
+	if !mr.started {
+		mr.started = true
+		mr.header, mr.err = mr.mapInput()
+	}
+	return mr.header, mr.err
+}
+
+// Report returns how the header was mapped, nil until it has been read
+func (mr *Reader) Report() *Report {
+	return mr.report
+}
+
+func (mr *Reader) ReadRecord() ([]string, error) {
+	if _, err := mr.Header(); err != nil {
+		return nil, err
+	}
+
+	record, err := mr.r.ReadRecord()
+	if err != nil {
+		return nil, err
+	}
+
+	mapped := make([]string, len(mr.positions))
+	for i, pos := range mr.positions {
+		if pos >= 0 && pos < len(record) {
+			mapped[i] = record[pos]
+		}
+	}
+	return mapped, nil
+}
+
+func (mr *Reader) mapInput() ([]string, error) {
+	header, err := mr.r.Header()
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errNoHeader
+	}
+
+	mr.report = mr.mapper.mapHeader(header, mr.columns)
+	if len(mr.report.Missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", errMissingColumns, strings.Join(mr.report.Missing, ", "))
+	}
+
+	index := map[string]int{}
+	for _, match := range mr.report.Matches {
+		index[match.Column] = match.Position
+	}
+	names := make([]string, len(mr.columns))
+	mr.positions = make([]int, len(mr.columns))
+	for i, column := range mr.columns {
+		names[i] = column.Name
+		mr.positions[i] = -1
+		if pos, ok := index[column.Name]; ok {
+			mr.positions[i] = pos
+		}
+	}
+	return names, nil
+}
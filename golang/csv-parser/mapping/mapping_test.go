@@ -0,0 +1,134 @@
+package mapping
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/stretchr/testify/assert"
+)
+
+var columns = []Column{
+	{Name: "customer_id", Aliases: []string{"cust_no", "client"}, Required: true},
+	{Name: "order_date", Required: true},
+	{Name: "amount"},
+	{Name: "currency"},
+}
+
+func TestMap(t *testing.T) {
+	testCases := []struct {
+		name     string
+		header   []string
+		options  []Option
+		expected *Report
+	}{
+		{
+			name:   "normalized names",
+			header: []string{"\ufeffCUSTOMERID", "Order Date", "Amount"},
+			expected: &Report{Matches: []Match{
+				{Header: "\ufeffCUSTOMERID", Position: 0, Column: "customer_id", By: ByName},
+				{Header: "Order Date", Position: 1, Column: "order_date", By: ByName},
+				{Header: "Amount", Position: 2, Column: "amount", By: ByName},
+			}},
+		},
+		{
+			name:   "alias",
+			header: []string{"Cust No.", "orderDate"},
+			expected: &Report{Matches: []Match{
+				{Header: "Cust No.", Position: 0, Column: "customer_id", By: ByAlias},
+				{Header: "orderDate", Position: 1, Column: "order_date", By: ByName},
+			}},
+		},
+		{
+			name:   "suggestions and missing columns",
+			header: []string{"ordr_date", "amout", "notes", "amount"},
+			expected: &Report{
+				Matches: []Match{{Header: "amount", Position: 3, Column: "amount", By: ByName}},
+				Unmapped: []Unmapped{
+					{Header: "ordr_date", Position: 0, Suggestions: []string{"order_date"}},
+					{Header: "amout", Position: 1},
+					{Header: "notes", Position: 2},
+				},
+				Missing: []string{"customer_id", "order_date"},
+			},
+		},
+		{
+			name:    "fuzzy",
+			header:  []string{"customer-idd", "ordr_date", "amount", "amount"},
+			options: []Option{WithFuzzy(true)},
+			expected: &Report{
+				Matches: []Match{
+					{Header: "customer-idd", Position: 0, Column: "customer_id", By: ByFuzzy},
+					{Header: "ordr_date", Position: 1, Column: "order_date", By: ByFuzzy},
+					{Header: "amount", Position: 2, Column: "amount", By: ByName},
+				},
+				Unmapped: []Unmapped{{Header: "amount", Position: 3}},
+			},
+		},
+		{
+			name:    "exact normalization",
+			header:  []string{"Customer ID", "customer_id", "order_date"},
+			options: []Option{WithNormalization(csv.NormalizeTrim), WithMaxDistance(0)},
+			expected: &Report{
+				Matches: []Match{
+					{Header: "customer_id", Position: 1, Column: "customer_id", By: ByName},
+					{Header: "order_date", Position: 2, Column: "order_date", By: ByName},
+				},
+				Unmapped: []Unmapped{{Header: "Customer ID", Position: 0}},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, currTestCase.expected, Map(currTestCase.header, columns, currTestCase.options...))
+		})
+	}
+}
+
+func TestReader(t *testing.T) {
+	t.Parallel()
+
+	input := "Amount,Client,Notes,Order Date\n10,c1,x,2024-01-01\n20,c2,y\n"
+	reader := NewReader(csv.NewCsvReader(strings.NewReader(input), csv.WithHeader(true), csv.WithLenient(true)), columns)
+
+	header, err := reader.Header()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"customer_id", "order_date", "amount", "currency"}, header)
+	assert.Equal(t, []Unmapped{{Header: "Notes", Position: 2}}, reader.Report().Unmapped)
+
+	var records [][]string
+	for {
+		record, err := reader.ReadRecord()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		records = append(records, record)
+	}
+	assert.Equal(t, [][]string{{"c1", "2024-01-01", "10", ""}, {"c2", "", "20", ""}}, records)
+
+	// a missing required column fails before any record is read
+	reader = NewReader(csv.NewCsvReader(strings.NewReader("amount\n1\n"), csv.WithHeader(true)), columns)
+	_, err = reader.ReadRecord()
+	assert.True(t, errors.Is(err, errMissingColumns), err)
+	assert.Equal(t, []string{"customer_id", "order_date"}, reader.Report().Missing)
+
+	reader = NewReader(csv.NewCsvReader(strings.NewReader("amount\n1\n")), columns)
+	_, err = reader.Header()
+	assert.True(t, errors.Is(err, errNoHeader), err)
+}
+
+func TestDistance(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 0, distance("", ""))
+	assert.Equal(t, 3, distance("", "abc"))
+	assert.Equal(t, 3, distance("kitten", "sitting"))
+	assert.Equal(t, 2, distance("größe", "grösse"))
+}
@@ -0,0 +1,294 @@
+package mask
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jeremyseow/csv-parser/csv"
+)
+
+var (
+	// ErrMissingKey is returned when a spec hashes or shifts by max_days without WithKey
+	ErrMissingKey = errors.New("hash and shift by max_days need a key")
+
+	errNoHeader = errors.New("masking by column name needs a header")
+	errBadValue = errors.New("value cannot be masked")
+)
+
+type masker struct {
+	key []byte
+}
+
+type Option func(*masker)
+
+// WithKey sets the secret key of hash and of shift by max_days. The same key gives the same
+// pseudonyms and offsets, so that masked extracts can still be joined with each other.
+func WithKey(key []byte) Option {
+	return func(m *masker) {
+		m.key = key
+	}
+}
+
+// maskFunc masks the field at pos of record
+type maskFunc func(record []string, pos int) (string, error)
+
+// Reader masks the records of another RecordReader as they are read
+type Reader struct {
+	r       csv.RecordReader
+	spec    *Spec
+	masker  *masker
+	header  []string
+	masks   []maskFunc
+	started bool
+	err     error
+}
+
+// NewReader returns a Reader masking the records of r by spec. Every column of spec must be
+// in the header of r.
+func NewReader(r csv.RecordReader, spec *Spec, options ...Option) (*Reader, error) {
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+
+	m := &masker{}
+	for _, op := range options {
+		op(m)
+	}
+	if len(m.key) == 0 {
+		for _, rule := range spec.Columns {
+			if rule.needsKey() {
+				return nil, ErrMissingKey
+			}
+		}
+	}
+
+	return &Reader{r: r, spec: spec, masker: m}, nil
+}
+
+// Mask writes the header and the masked records of r to w, and flushes it
+func Mask(r csv.RecordReader, w *csv.CsvWriter, spec *Spec, options ...Option) error {
+	mr, err := NewReader(r, spec, options...)
+	if err != nil {
+		return err
+	}
+
+	header, err := mr.Header()
+	if err != nil {
+		return err
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	for {
+		record, err := mr.ReadRecord()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func (mr *Reader) Header() ([]string, error) {
+	if !mr.started {
+		mr.started = true
+		mr.header, mr.err = mr.r.Header()
+		if mr.err == nil {
+			mr.masks, mr.err = mr.compile(mr.header)
+		}
+	}
+	return mr.header, mr.err
+}
+
+// ReadRecord returns the next record with its columns masked
+func (mr *Reader) ReadRecord() ([]string, error) {
+	if _, err := mr.Header(); err != nil {
+		return nil, err
+	}
+
+	record, err := mr.r.ReadRecord()
+	if err != nil {
+		return nil, err
+	}
+
+	// every field is masked from the original record, as shift may read another column
+	masked := make([]string, len(record))
+	copy(masked, record)
+	for pos, mask := range mr.masks {
+		if mask == nil || pos >= len(record) || record[pos] == "" {
+			continue
+		}
+		if masked[pos], err = mask(record, pos); err != nil {
+			return nil, fmt.Errorf("%w: column %s: %v", errBadValue, mr.header[pos], err)
+		}
+	}
+	return masked, nil
+}
+
+func (mr *Reader) compile(header []string) ([]maskFunc, error) {
+	if header == nil {
+		return nil, errNoHeader
+	}
+
+	// a name the header has twice is masked in both columns, leaving neither in clear
+	positions := map[string][]int{}
+	for pos, name := range header {
+		positions[name] = append(positions[name], pos)
+	}
+
+	masks := make([]maskFunc, len(header))
+	for name, rule := range mr.spec.Columns {
+		if len(positions[name]) == 0 {
+			return nil, fmt.Errorf("%w: %q", csv.ErrUnknownColumn, name)
+		}
+
+		byPos := -1
+		if rule.By != "" {
+			by, err := csv.ColumnPositions(header, []string{rule.By})
+			if err != nil {
+				return nil, err
+			}
+			byPos = by[0]
+		}
+
+		mask := mr.masker.compile(rule, byPos)
+		for _, pos := range positions[name] {
+			masks[pos] = mask
+		}
+	}
+	return masks, nil
+}
+
+func (m *masker) compile(rule Rule, byPos int) maskFunc {
+	switch rule.Op {
+	case OpHash:
+		return func(record []string, pos int) (string, error) {
+			sum := hex.EncodeToString(m.mac(record[pos]))
+			if rule.Length > 0 && rule.Length < len(sum) {
+				sum = sum[:rule.Length]
+			}
+			return sum, nil
+		}
+
+	case OpRedact:
+		with := "REDACTED"
+		if rule.With != nil {
+			with = *rule.With
+		}
+		return func(record []string, pos int) (string, error) {
+			return with, nil
+		}
+
+	case OpTruncate:
+		return func(record []string, pos int) (string, error) {
+			return firstRunes(record[pos], rule.Length), nil
+		}
+
+	case OpPartial:
+		keep, char := 4, "*"
+		if rule.Keep != nil {
+			keep = *rule.Keep
+		}
+		if rule.Char != "" {
+			char = rule.Char
+		}
+		return func(record []string, pos int) (string, error) {
+			return partial(record[pos], keep, char), nil
+		}
+
+	case OpShift:
+		return func(record []string, pos int) (string, error) {
+			days := rule.Days
+			if rule.MaxDays > 0 {
+				subject := ""
+				if byPos >= 0 && byPos < len(record) {
+					subject = record[byPos]
+				}
+				days = m.offset(subject, rule.MaxDays)
+			}
+			return shiftDate(record[pos], days)
+		}
+	}
+
+	return func(record []string, pos int) (string, error) {
+		return bucket(record[pos], rule.Size)
+	}
+}
+
+func (m *masker) mac(value string) []byte {
+	h := hmac.New(sha256.New, m.key)
+	h.Write([]byte(value))
+	return h.Sum(nil)
+}
+
+// offset picks a number of days between -maxDays and maxDays from the key and subject
+func (m *masker) offset(subject string, maxDays int) int {
+	sum := m.mac("shift:" + subject)
+	span := uint64(2*maxDays + 1)
+	return int(binary.BigEndian.Uint64(sum)%span) - maxDays
+}
+
+func firstRunes(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
+
+func partial(s string, keep int, char string) string {
+	length := utf8.RuneCountInString(s)
+	if length <= keep {
+		return strings.Repeat(char, length)
+	}
+
+	masked := length - keep
+	return strings.Repeat(char, masked) + s[len(firstRunes(s, masked)):]
+}
+
+// shiftDate moves a date or timestamp by days, keeping a date a date
+func shiftDate(value string, days int) (string, error) {
+	t, ok := csv.ParseTime(value)
+	if !ok {
+		// the value itself is kept out of the error, it is what is being hidden
+		return "", errors.New("not a date")
+	}
+
+	t = t.AddDate(0, 0, days)
+	if len(value) == len(time.DateOnly) {
+		return t.Format(time.DateOnly), nil
+	}
+	return t.Format(time.RFC3339Nano), nil
+}
+
+func bucket(value string, size float64) (string, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", errors.New("not a number")
+	}
+
+	lower := math.Floor(f/size) * size
+	return formatNumber(lower) + "-" + formatNumber(lower+size), nil
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,176 @@
+package mask
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSpec(t *testing.T) {
+	two := 2
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected *Spec
+		err      error
+	}{
+		{
+			name:  "yaml",
+			input: "columns:\n  email: {op: hash, length: 16}\n  card:\n    op: partial\n    keep: 2\n",
+			expected: &Spec{Columns: map[string]Rule{
+				"email": {Op: OpHash, Length: 16},
+				"card":  {Op: OpPartial, Keep: &two},
+			}},
+		},
+		{
+			name:     "json",
+			input:    "{\n\t\"columns\": {\"salary\": {\"op\": \"bucket\", \"size\": 1000}}\n}",
+			expected: &Spec{Columns: map[string]Rule{"salary": {Op: OpBucket, Size: 1000}}},
+		},
+		{name: "unknown op", input: "columns:\n  a: {op: scramble}\n", err: errUnknownOp},
+		{name: "unknown field", input: "columns:\n  a: {op: hash, lenght: 3}\n", err: errBadSpec},
+		{name: "missing size", input: `{"columns": {"a": {"op": "bucket"}}}`, err: errBadSpec},
+		{name: "days and max days", input: "columns:\n  a: {op: shift, days: 3, max_days: 5}\n", err: errBadSpec},
+		{name: "no columns", input: "columns: {}\n", err: errBadSpec},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			spec, err := ParseSpec([]byte(currTestCase.input))
+			if currTestCase.err != nil {
+				assert.True(t, errors.Is(err, currTestCase.err), err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, currTestCase.expected, spec)
+		})
+	}
+}
+
+func TestMask(t *testing.T) {
+	input := "id,email,name,card,born,salary,note\n" +
+		"1,ann@x.com,Ann Lee,4111111111111111,1990-05-17,52000,héllo world\n" +
+		"2,bob@x.com,Bob,123,1985-01-02T08:30:00Z,,\n" +
+		"3,ann@x.com,Ann,,,-1500.5,hi\n"
+
+	testCases := []struct {
+		name     string
+		spec     string
+		options  []Option
+		expected [][]string
+		err      error
+	}{
+		{
+			name: "redact truncate partial bucket",
+			spec: "columns:\n  name: {op: redact}\n  note: {op: truncate, length: 5}\n  card: {op: partial}\n  salary: {op: bucket, size: 1000}\n",
+			expected: [][]string{
+				{"1", "ann@x.com", "REDACTED", "************1111", "1990-05-17", "52000-53000", "héllo"},
+				{"2", "bob@x.com", "REDACTED", "***", "1985-01-02T08:30:00Z", "", ""},
+				{"3", "ann@x.com", "REDACTED", "", "", "-2000--1000", "hi"},
+			},
+		},
+		{
+			name:    "hash and fixed shift",
+			spec:    "columns:\n  email: {op: hash, length: 12}\n  born: {op: shift, days: -10}\n",
+			options: []Option{WithKey([]byte("secret"))},
+			expected: [][]string{
+				{"1", "70a4a45d7788", "Ann Lee", "4111111111111111", "1990-05-07", "52000", "héllo world"},
+				{"2", "3b43e6e0b243", "Bob", "123", "1984-12-23T08:30:00Z", "", ""},
+				{"3", "70a4a45d7788", "Ann", "", "", "-1500.5", "hi"},
+			},
+		},
+		{
+			name:    "redact with",
+			spec:    "columns:\n  name: {op: redact, with: ''}\n",
+			options: []Option{WithKey([]byte("secret"))},
+			expected: [][]string{
+				{"1", "ann@x.com", "", "4111111111111111", "1990-05-17", "52000", "héllo world"},
+				{"2", "bob@x.com", "", "123", "1985-01-02T08:30:00Z", "", ""},
+				{"3", "ann@x.com", "", "", "", "-1500.5", "hi"},
+			},
+		},
+		{
+			name: "partial keeping nothing",
+			spec: "columns:\n  card: {op: partial, keep: 0, char: '#'}\n",
+			expected: [][]string{
+				{"1", "ann@x.com", "Ann Lee", "################", "1990-05-17", "52000", "héllo world"},
+				{"2", "bob@x.com", "Bob", "###", "1985-01-02T08:30:00Z", "", ""},
+				{"3", "ann@x.com", "Ann", "", "", "-1500.5", "hi"},
+			},
+		},
+		{name: "missing key", spec: "columns:\n  email: {op: hash}\n", err: ErrMissingKey},
+		{name: "unknown column", spec: "columns:\n  phone: {op: redact}\n", err: csv.ErrUnknownColumn},
+		{name: "unknown by column", spec: "columns:\n  born: {op: shift, max_days: 5, by: who}\n", options: []Option{WithKey([]byte("k"))}, err: csv.ErrUnknownColumn},
+		{name: "not a number", spec: "columns:\n  name: {op: bucket, size: 10}\n", err: errBadValue},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			spec, err := ParseSpec([]byte(currTestCase.spec))
+			assert.NoError(t, err)
+
+			var sb strings.Builder
+			reader := csv.NewCsvReader(strings.NewReader(input), csv.WithHeader(true))
+			err = Mask(reader, csv.NewCsvWriter(&sb), spec, currTestCase.options...)
+			if currTestCase.err != nil {
+				assert.True(t, errors.Is(err, currTestCase.err), err)
+				return
+			}
+			assert.NoError(t, err)
+
+			records, err := csv.NewCsvReader(strings.NewReader(sb.String()), csv.WithHeader(true)).Read()
+			assert.NoError(t, err)
+			assert.Equal(t, currTestCase.expected, records)
+		})
+	}
+}
+
+func TestShiftByColumn(t *testing.T) {
+	t.Parallel()
+
+	input := "who,day\nann,2024-01-10\nbob,2024-01-10\nann,2024-01-20\n"
+	spec := &Spec{Columns: map[string]Rule{"day": {Op: OpShift, MaxDays: 30, By: "who"}}}
+
+	mask := func(key string) [][]string {
+		reader, err := NewReader(csv.NewCsvReader(strings.NewReader(input), csv.WithHeader(true)), spec, WithKey([]byte(key)))
+		assert.NoError(t, err)
+		var records [][]string
+		for {
+			record, err := reader.ReadRecord()
+			if err != nil {
+				break
+			}
+			records = append(records, record)
+		}
+		return records
+	}
+
+	records := mask("secret")
+	first, _ := csv.ParseTime(records[0][1])
+	third, _ := csv.ParseTime(records[2][1])
+	// the records of a subject move together, keeping the ten days between them
+	assert.Equal(t, 10*24.0, third.Sub(first).Hours())
+	assert.Equal(t, records, mask("secret"))
+	assert.NotEqual(t, records, mask("other"))
+}
+
+func TestMaskDuplicateColumns(t *testing.T) {
+	t.Parallel()
+
+	spec := &Spec{Columns: map[string]Rule{"email": {Op: OpRedact}}}
+	reader := csv.NewCsvReader(strings.NewReader("email,id,email\nann@x.com,1,ann@y.com\n"), csv.WithHeader(true))
+
+	var sb strings.Builder
+	assert.NoError(t, Mask(reader, csv.NewCsvWriter(&sb), spec))
+	assert.Equal(t, "email,id,email\nREDACTED,1,REDACTED\n", sb.String())
+}
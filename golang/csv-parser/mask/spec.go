@@ -0,0 +1,129 @@
+package mask
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	errBadSpec   = errors.New("bad mask spec")
+	errUnknownOp = errors.New("unknown mask operation")
+)
+
+type Op string
+
+const (
+	OpHash     Op = "hash"
+	OpRedact   Op = "redact"
+	OpTruncate Op = "truncate"
+	OpPartial  Op = "partial"
+	OpShift    Op = "shift"
+	OpBucket   Op = "bucket"
+)
+
+// Rule says how to mask a column. Which of the other fields apply depends on Op:
+//
+//	hash      keyed HMAC-SHA256 of the value in hex, cut to Length characters when set
+//	redact    replaced with With, "REDACTED" by default
+//	truncate  the first Length characters
+//	partial   every character but the last Keep, 4 by default, replaced with Char, "*" by
+//	          default; a value no longer than Keep is masked entirely
+//	shift     a date moved by Days, or by a number of days between -MaxDays and MaxDays picked
+//	          from the key, and from the value of the By column when set
+//	bucket    a number replaced with the range of width Size it falls in, such as 50000-60000
+//
+// Empty fields are left empty by every operation.
+type Rule struct {
+	Op      Op      `json:"op" yaml:"op"`
+	Length  int     `json:"length,omitempty" yaml:"length,omitempty"`
+	With    *string `json:"with,omitempty" yaml:"with,omitempty"`
+	Keep    *int    `json:"keep,omitempty" yaml:"keep,omitempty"`
+	Char    string  `json:"char,omitempty" yaml:"char,omitempty"`
+	Days    int     `json:"days,omitempty" yaml:"days,omitempty"`
+	MaxDays int     `json:"max_days,omitempty" yaml:"max_days,omitempty"`
+	By      string  `json:"by,omitempty" yaml:"by,omitempty"`
+	Size    float64 `json:"size,omitempty" yaml:"size,omitempty"`
+}
+
+// Spec maps column names to the rule masking them. Every column of a name the header has more
+// than once is masked. Columns without a rule are left as they are.
+type Spec struct {
+	Columns map[string]Rule `json:"columns" yaml:"columns"`
+}
+
+// ParseSpec reads a spec written in JSON or YAML, such as
+//
+//	columns:
+//	  email: {op: hash, length: 16}
+//	  card: {op: partial, keep: 4}
+//	  born: {op: shift, max_days: 90, by: email}
+func ParseSpec(data []byte) (*Spec, error) {
+	spec := &Spec{}
+
+	var err error
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(spec)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(spec)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errBadSpec, err)
+	}
+
+	return spec, spec.validate()
+}
+
+func (s *Spec) validate() error {
+	if len(s.Columns) == 0 {
+		return fmt.Errorf("%w: no columns", errBadSpec)
+	}
+
+	for name, rule := range s.Columns {
+		var err error
+		switch rule.Op {
+		case OpHash, OpRedact:
+			if rule.Length < 0 {
+				err = fmt.Errorf("length must not be negative")
+			}
+		case OpTruncate:
+			if rule.Length <= 0 {
+				err = fmt.Errorf("truncate needs a positive length")
+			}
+		case OpPartial:
+			if (rule.Keep != nil && *rule.Keep < 0) || len([]rune(rule.Char)) > 1 {
+				err = fmt.Errorf("partial needs a keep of 0 or more and a single char")
+			}
+		case OpShift:
+			if rule.MaxDays < 0 || (rule.Days != 0 && rule.MaxDays != 0) {
+				err = fmt.Errorf("shift needs either days or a positive max_days")
+			}
+			if rule.By != "" && rule.MaxDays == 0 {
+				err = fmt.Errorf("shift by a column needs max_days")
+			}
+		case OpBucket:
+			if rule.Size <= 0 {
+				err = fmt.Errorf("bucket needs a positive size")
+			}
+		default:
+			return fmt.Errorf("%w: %q for column %s", errUnknownOp, rule.Op, name)
+		}
+
+		if err != nil {
+			return fmt.Errorf("%w: column %s: %v", errBadSpec, name, err)
+		}
+	}
+	return nil
+}
+
+// needsKey reports whether a rule is keyed
+func (r Rule) needsKey() bool {
+	return r.Op == OpHash || (r.Op == OpShift && r.MaxDays > 0)
+}
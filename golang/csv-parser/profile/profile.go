@@ -0,0 +1,213 @@
+package profile
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jeremyseow/csv-parser/csv"
+)
+
+type Type string
+
+const (
+	TypeEmpty   Type = "empty"
+	TypeInteger Type = "integer"
+	TypeFloat   Type = "float"
+	TypeBoolean Type = "boolean"
+	TypeDate    Type = "date"
+	TypeString  Type = "string"
+)
+
+// Column describes the values found in a column. Type is the narrowest type every non-empty
+// value has, and Min and Max are compared as that type.
+type Column struct {
+	Name           string `json:"name"`
+	Type           Type   `json:"type"`
+	Count          int    `json:"count"`
+	Empty          int    `json:"empty"`
+	Distinct       int    `json:"distinct"`
+	DistinctCapped bool   `json:"distinct_capped,omitempty"`
+	Min            string `json:"min,omitempty"`
+	Max            string `json:"max,omitempty"`
+	MinLength      int    `json:"min_length"`
+	MaxLength      int    `json:"max_length"`
+}
+
+// Profile is the result of Read
+type Profile struct {
+	Records int      `json:"records"`
+	Columns []Column `json:"columns"`
+}
+
+type profiler struct {
+	distinctLimit int
+}
+
+type Option func(*profiler)
+
+// WithDistinctLimit caps the distinct values remembered per column, 10000 by default. Past it
+// Distinct stops counting and DistinctCapped is set.
+func WithDistinctLimit(limit int) Option {
+	return func(p *profiler) {
+		p.distinctLimit = limit
+	}
+}
+
+// columnState accumulates one column while reading. The type is only settled at the end, so
+// the smallest and largest values are kept for every type still possible.
+type columnState struct {
+	Column
+	isInteger, isFloat, isBoolean, isDate bool
+	seen                                  map[string]struct{}
+
+	integers bounds[int64]
+	floats   bounds[float64]
+	dates    bounds[int64]
+	text     bounds[string]
+}
+
+// bounds keeps the smallest and largest value seen, with the field they were parsed from
+type bounds[T int64 | float64 | string] struct {
+	set              bool
+	min, max         T
+	minText, maxText string
+}
+
+func (b *bounds[T]) add(value T, text string) {
+	if !b.set || value < b.min {
+		b.min, b.minText = value, text
+	}
+	if !b.set || value > b.max {
+		b.max, b.maxText = value, text
+	}
+	b.set = true
+}
+
+// Read profiles every column of r. Columns are named after the header, or col1, col2 and so on
+// without one; records longer than the header add columns.
+func Read(r csv.RecordReader, options ...Option) (*Profile, error) {
+	p := &profiler{distinctLimit: 10000}
+	for _, op := range options {
+		op(p)
+	}
+
+	header, err := r.Header()
+	if err != nil {
+		return nil, err
+	}
+
+	var columns []*columnState
+	addColumn := func() {
+		name := fmt.Sprintf("col%d", len(columns)+1)
+		if len(columns) < len(header) {
+			name = header[len(columns)]
+		}
+		columns = append(columns, &columnState{
+			Column:    Column{Name: name},
+			isInteger: true, isFloat: true, isBoolean: true, isDate: true,
+			seen: map[string]struct{}{},
+		})
+	}
+	for range header {
+		addColumn()
+	}
+
+	records := 0
+	for {
+		record, err := r.ReadRecord()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		records++
+		for len(columns) < len(record) {
+			addColumn()
+		}
+		for i, value := range record {
+			p.add(columns[i], value)
+		}
+	}
+
+	profile := &Profile{Records: records, Columns: make([]Column, len(columns))}
+	for i, column := range columns {
+		// fields missing from short records count as empty too
+		column.Empty = records - column.Count
+		profile.Columns[i] = column.finish()
+	}
+	return profile, nil
+}
+
+func (p *profiler) add(column *columnState, value string) {
+	if value == "" {
+		return
+	}
+
+	if column.Count == 0 || len(value) < column.MinLength {
+		column.MinLength = len(value)
+	}
+	column.MaxLength = max(column.MaxLength, len(value))
+	column.Count++
+
+	if _, ok := column.seen[value]; !ok {
+		if len(column.seen) < p.distinctLimit {
+			column.seen[value] = struct{}{}
+		} else {
+			column.DistinctCapped = true
+		}
+	}
+
+	if column.isInteger {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if column.isInteger = err == nil; column.isInteger {
+			column.integers.add(n, value)
+		}
+	}
+	if column.isFloat {
+		f, err := strconv.ParseFloat(value, 64)
+		if column.isFloat = err == nil; column.isFloat {
+			column.floats.add(f, value)
+		}
+	}
+	if column.isBoolean {
+		column.isBoolean = strings.EqualFold(value, "true") || strings.EqualFold(value, "false")
+	}
+	if column.isDate {
+		var t time.Time
+		if t, column.isDate = csv.ParseTime(value); column.isDate {
+			column.dates.add(t.UnixNano(), value)
+		}
+	}
+	column.text.add(value, value)
+}
+
+func (column *columnState) finish() Column {
+	result := column.Column
+	result.Distinct = len(column.seen)
+
+	switch {
+	case column.Count == 0:
+		result.Type = TypeEmpty
+	case column.isInteger:
+		result.Type = TypeInteger
+		result.Min, result.Max = column.integers.minText, column.integers.maxText
+	case column.isFloat:
+		result.Type = TypeFloat
+		result.Min, result.Max = column.floats.minText, column.floats.maxText
+	case column.isBoolean:
+		result.Type = TypeBoolean
+		result.Min, result.Max = column.text.minText, column.text.maxText
+	case column.isDate:
+		result.Type = TypeDate
+		result.Min, result.Max = column.dates.minText, column.dates.maxText
+	default:
+		result.Type = TypeString
+		result.Min, result.Max = column.text.minText, column.text.maxText
+	}
+	return result
+}
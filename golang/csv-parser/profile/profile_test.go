@@ -0,0 +1,54 @@
+package profile
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRead(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		noHeader bool
+		options  []Option
+		expected *Profile
+	}{
+		{
+			name:  "types",
+			input: "id,price,active,day,note,blank\n10,2.5,true,2024-03-01,b,\n9,-1,FALSE,2023-12-31,aa,\n100,1e3,true,2024-01-15,c,\n",
+			expected: &Profile{Records: 3, Columns: []Column{
+				{Name: "id", Type: TypeInteger, Count: 3, Distinct: 3, Min: "9", Max: "100", MinLength: 1, MaxLength: 3},
+				{Name: "price", Type: TypeFloat, Count: 3, Distinct: 3, Min: "-1", Max: "1e3", MinLength: 2, MaxLength: 3},
+				{Name: "active", Type: TypeBoolean, Count: 3, Distinct: 2, Min: "FALSE", Max: "true", MinLength: 4, MaxLength: 5},
+				{Name: "day", Type: TypeDate, Count: 3, Distinct: 3, Min: "2023-12-31", Max: "2024-03-01", MinLength: 10, MaxLength: 10},
+				{Name: "note", Type: TypeString, Count: 3, Distinct: 3, Min: "aa", Max: "c", MinLength: 1, MaxLength: 2},
+				{Name: "blank", Type: TypeEmpty, Empty: 3},
+			}},
+		},
+		{
+			name:     "without header and ragged records",
+			input:    "1\n2,x\n\"\"\n",
+			noHeader: true,
+			options:  []Option{WithDistinctLimit(1)},
+			expected: &Profile{Records: 3, Columns: []Column{
+				{Name: "col1", Type: TypeInteger, Count: 2, Empty: 1, Distinct: 1, DistinctCapped: true, Min: "1", Max: "2", MinLength: 1, MaxLength: 1},
+				{Name: "col2", Type: TypeString, Count: 1, Empty: 2, Distinct: 1, Min: "x", Max: "x", MinLength: 1, MaxLength: 1},
+			}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			reader := csv.NewCsvReader(strings.NewReader(currTestCase.input), csv.WithHeader(!currTestCase.noHeader), csv.WithLenient(true))
+			profile, err := Read(reader, currTestCase.options...)
+			assert.NoError(t, err)
+			assert.Equal(t, currTestCase.expected, profile)
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package sample
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jeremyseow/csv-parser/csv"
+)
+
+var (
+	errNoHeader    = errors.New("sampling by column name needs a header")
+	errBadSize     = errors.New("sample size must be positive")
+	errBadFraction = errors.New("sample fraction must be above 0 and at most 1")
+)
+
+type sampler struct {
+	rng *rand.Rand
+}
+
+type Option func(*sampler)
+
+// WithSeed makes the sample reproducible, the same seed and input always giving the same
+// records. Without it every run picks differently.
+func WithSeed(seed int64) Option {
+	return func(s *sampler) {
+		s.rng = rand.New(rand.NewSource(seed))
+	}
+}
+
+func newSampler(options []Option) *sampler {
+	s := &sampler{}
+	for _, op := range options {
+		op(s)
+	}
+	if s.rng == nil {
+		s.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return s
+}
+
+// Reservoir writes the header and n records of r picked uniformly at random to w, in their
+// input order. Only the n picked records are kept in memory, however long r is. Inputs with
+// n records or fewer are copied whole.
+func Reservoir(r csv.RecordReader, w *csv.CsvWriter, n int, options ...Option) error {
+	if n <= 0 {
+		return fmt.Errorf("%w: %d", errBadSize, n)
+	}
+	s := newSampler(options)
+
+	if err := writeHeader(r, w); err != nil {
+		return err
+	}
+
+	res := s.newReservoir(n)
+	for seq := 0; ; seq++ {
+		record, err := r.ReadRecord()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		res.add(seq, record)
+	}
+
+	return writeRows(w, res.rows)
+}
+
+// Bernoulli writes the header of r to w and then every record with probability fraction, so
+// that the sample holds about fraction of the records. It keeps nothing in memory.
+func Bernoulli(r csv.RecordReader, w *csv.CsvWriter, fraction float64, options ...Option) error {
+	if !(fraction > 0 && fraction <= 1) {
+		return fmt.Errorf("%w: %v", errBadFraction, fraction)
+	}
+	s := newSampler(options)
+
+	if err := writeHeader(r, w); err != nil {
+		return err
+	}
+
+	for {
+		record, err := r.ReadRecord()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if s.rng.Float64() < fraction {
+			if err := w.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	return w.Flush()
+}
+
+// Stratified writes the header of r to w and up to n records picked uniformly at random for
+// every distinct value of the key columns, in their input order, so that rare values are as
+// present in the sample as common ones. n records are kept in memory per distinct key.
+func Stratified(r csv.RecordReader, w *csv.CsvWriter, keys []string, n int, options ...Option) error {
+	if n <= 0 {
+		return fmt.Errorf("%w: %d", errBadSize, n)
+	}
+	s := newSampler(options)
+
+	header, err := r.Header()
+	if err != nil {
+		return err
+	}
+	positions, err := resolve(header, keys)
+	if err != nil {
+		return err
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	strata := map[string]*reservoir{}
+	for seq := 0; ; seq++ {
+		record, err := r.ReadRecord()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		k := key(record, positions)
+		res, ok := strata[k]
+		if !ok {
+			res = s.newReservoir(n)
+			strata[k] = res
+		}
+		res.add(seq, record)
+	}
+
+	var rows []row
+	for _, res := range strata {
+		rows = append(rows, res.rows...)
+	}
+	return writeRows(w, rows)
+}
+
+// row is a picked record and its position in the input
+type row struct {
+	seq    int
+	record []string
+}
+
+type reservoir struct {
+	rng  *rand.Rand
+	size int
+	seen int
+	rows []row
+}
+
+func (s *sampler) newReservoir(size int) *reservoir {
+	return &reservoir{rng: s.rng, size: size}
+}
+
+// add keeps every record with probability size/seen, replacing a kept one at random
+func (res *reservoir) add(seq int, record []string) {
+	res.seen++
+	if len(res.rows) < res.size {
+		res.rows = append(res.rows, row{seq: seq, record: record})
+		return
+	}
+	if i := res.rng.Intn(res.seen); i < res.size {
+		res.rows[i] = row{seq: seq, record: record}
+	}
+}
+
+func writeHeader(r csv.RecordReader, w *csv.CsvWriter) error {
+	header, err := r.Header()
+	if err != nil || header == nil {
+		return err
+	}
+	return w.Write(header)
+}
+
+// writeRows writes rows back in their input order and flushes w
+func writeRows(w *csv.CsvWriter, rows []row) error {
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].seq < rows[j].seq
+	})
+	for _, row := range rows {
+		if err := w.Write(row.record); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func resolve(header, keys []string) ([]int, error) {
+	if header == nil {
+		return nil, errNoHeader
+	}
+	return csv.ColumnPositions(header, keys)
+}
+
+func key(record []string, positions []int) string {
+	fields := make([]string, len(positions))
+	for i, pos := range positions {
+		if pos < len(record) {
+			fields[i] = record[pos]
+		}
+	}
+	return strings.Join(fields, "\x00")
+}
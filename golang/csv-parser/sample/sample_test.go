@@ -0,0 +1,170 @@
+package sample
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/stretchr/testify/assert"
+)
+
+// numbered is a header and records 0 to n-1, in region a for most and b for every tenth
+func numbered(n int) string {
+	var sb strings.Builder
+	sb.WriteString("id,region\n")
+	for i := 0; i < n; i++ {
+		region := "a"
+		if i%10 == 9 {
+			region = "b"
+		}
+		fmt.Fprintf(&sb, "%d,%s\n", i, region)
+	}
+	return sb.String()
+}
+
+func run(t *testing.T, input string, fn func(r csv.RecordReader, w *csv.CsvWriter) error) ([][]string, error) {
+	var sb strings.Builder
+	err := fn(csv.NewCsvReader(strings.NewReader(input), csv.WithHeader(true)), csv.NewCsvWriter(&sb))
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := csv.NewCsvReader(strings.NewReader(sb.String()), csv.WithHeader(true)).Read()
+	assert.NoError(t, err)
+	return records, nil
+}
+
+func TestSample(t *testing.T) {
+	input := numbered(1000)
+
+	testCases := []struct {
+		name   string
+		sample func(r csv.RecordReader, w *csv.CsvWriter) error
+		check  func(t *testing.T, records [][]string)
+		err    error
+	}{
+		{
+			name: "reservoir",
+			sample: func(r csv.RecordReader, w *csv.CsvWriter) error {
+				return Reservoir(r, w, 5, WithSeed(1))
+			},
+			check: func(t *testing.T, records [][]string) {
+				assert.Len(t, records, 5)
+			},
+		},
+		{
+			name: "reservoir larger than the input",
+			sample: func(r csv.RecordReader, w *csv.CsvWriter) error {
+				return Reservoir(r, w, 2000, WithSeed(1))
+			},
+			check: func(t *testing.T, records [][]string) {
+				assert.Len(t, records, 1000)
+			},
+		},
+		{
+			name: "bernoulli",
+			sample: func(r csv.RecordReader, w *csv.CsvWriter) error {
+				return Bernoulli(r, w, 0.2, WithSeed(1))
+			},
+			check: func(t *testing.T, records [][]string) {
+				assert.InDelta(t, 200, len(records), 50)
+			},
+		},
+		{
+			name: "stratified",
+			sample: func(r csv.RecordReader, w *csv.CsvWriter) error {
+				return Stratified(r, w, []string{"region"}, 20, WithSeed(1))
+			},
+			check: func(t *testing.T, records [][]string) {
+				regions := map[string]int{}
+				for _, record := range records {
+					regions[record[1]]++
+				}
+				assert.Equal(t, map[string]int{"a": 20, "b": 20}, regions)
+			},
+		},
+		{
+			name: "zero size",
+			sample: func(r csv.RecordReader, w *csv.CsvWriter) error {
+				return Reservoir(r, w, 0)
+			},
+			err: errBadSize,
+		},
+		{
+			name: "fraction above one",
+			sample: func(r csv.RecordReader, w *csv.CsvWriter) error {
+				return Bernoulli(r, w, 1.5)
+			},
+			err: errBadFraction,
+		},
+		{
+			name: "unknown column",
+			sample: func(r csv.RecordReader, w *csv.CsvWriter) error {
+				return Stratified(r, w, []string{"country"}, 1)
+			},
+			err: csv.ErrUnknownColumn,
+		},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			records, err := run(t, input, currTestCase.sample)
+			if currTestCase.err != nil {
+				assert.True(t, errors.Is(err, currTestCase.err), err)
+				return
+			}
+			assert.NoError(t, err)
+			currTestCase.check(t, records)
+
+			// picked records stay in their input order
+			last := -1
+			for _, record := range records {
+				id, err := strconv.Atoi(record[0])
+				assert.NoError(t, err)
+				assert.Greater(t, id, last)
+				last = id
+			}
+
+			// and the same seed picks the same records
+			again, err := run(t, input, currTestCase.sample)
+			assert.NoError(t, err)
+			assert.Equal(t, records, again)
+		})
+	}
+}
+
+func TestReservoirUniform(t *testing.T) {
+	t.Parallel()
+
+	input := numbered(10)
+	picked := make([]int, 10)
+	for seed := int64(0); seed < 2000; seed++ {
+		records, err := run(t, input, func(r csv.RecordReader, w *csv.CsvWriter) error {
+			return Reservoir(r, w, 2, WithSeed(seed))
+		})
+		assert.NoError(t, err)
+		for _, record := range records {
+			id, _ := strconv.Atoi(record[0])
+			picked[id]++
+		}
+	}
+
+	// every record is picked 2 times in 10, the last ones as often as the first ones
+	for id, n := range picked {
+		assert.InDelta(t, 400, n, 80, "record %d", id)
+	}
+}
+
+func TestStratifiedWithoutHeader(t *testing.T) {
+	t.Parallel()
+
+	reader := csv.NewCsvReader(strings.NewReader("a,1\n"))
+	err := Stratified(reader, csv.NewCsvWriter(&strings.Builder{}), []string{"a"}, 1)
+	assert.True(t, errors.Is(err, errNoHeader), err)
+}
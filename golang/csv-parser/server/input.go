@@ -0,0 +1,104 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// openInput returns the csv of the request: the body, or the first file of a multipart form.
+// Gzipped data is recognised by its magic bytes, whatever the headers say.
+func (s *Server) openInput(w http.ResponseWriter, r *http.Request) (io.Reader, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, s.maxSize)
+
+	var src io.Reader = r.Body
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		part, err := filePart(r)
+		if err != nil {
+			return nil, err
+		}
+		src = part
+	}
+
+	br := bufio.NewReader(&inputReader{ctx: r.Context(), r: src})
+	if magic, _ := br.Peek(len(gzipMagic)); bytes.Equal(magic, gzipMagic) {
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errBadRequest, err)
+		}
+		// the decompressed size is limited too, a small upload can inflate a lot
+		return &inputReader{ctx: r.Context(), r: gr, remaining: s.maxSize, limited: true}, nil
+	}
+	return br, nil
+}
+
+func filePart(r *http.Request) (io.Reader, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errBadRequest, err)
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%w: no file in the multipart form", errBadRequest)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errBadRequest, err)
+		}
+		if part.FileName() != "" || part.FormName() == "file" {
+			return part, nil
+		}
+	}
+}
+
+// inputReader stops reading once the request times out, and marks the errors of the upload
+// as the client's fault
+type inputReader struct {
+	ctx       context.Context
+	r         io.Reader
+	remaining int64
+	limited   bool
+}
+
+func (ir *inputReader) Read(p []byte) (int, error) {
+	if err := ir.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if ir.limited {
+		if ir.remaining <= 0 {
+			return 0, ir.past()
+		}
+		p = p[:min(int64(len(p)), ir.remaining)]
+	}
+
+	n, err := ir.r.Read(p)
+	ir.remaining -= int64(n)
+	return n, ir.clientError(err)
+}
+
+// past reads a byte past the limit, the input is only too large when there is one
+func (ir *inputReader) past() error {
+	var b [1]byte
+	n, err := io.ReadFull(ir.r, b[:])
+	if n > 0 {
+		return errTooLarge
+	}
+	return ir.clientError(err)
+}
+
+func (ir *inputReader) clientError(err error) error {
+	if err != nil && err != io.EOF {
+		// the client gave up, sent too much or sent something broken
+		err = fmt.Errorf("%w: %w", errBadRequest, err)
+	}
+	return err
+}
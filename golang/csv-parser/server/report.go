@@ -0,0 +1,86 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/jeremyseow/csv-parser/convert"
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/jeremyseow/csv-parser/profile"
+)
+
+// validate reads the whole upload, carrying on after parse errors, and streams a report:
+//
+//	{"errors":[{"line":3,"column":1,"start_line":3,"error":"wrong number of fields"}],"records":2,"valid":false}
+func (s *Server) validate(w *responseWriter, r *http.Request, cr *csv.CsvReader) error {
+	w.Header().Set("Content-Type", "application/json")
+
+	numErrors, records := 0, 0
+	report := func(err error) error {
+		var parseErr *csv.ParseError
+		if !errors.As(err, &parseErr) {
+			return err
+		}
+
+		buf := []byte(",\n")
+		if numErrors == 0 {
+			buf = []byte(`{"errors":[` + "\n")
+		}
+		numErrors++
+
+		buf = append(buf, `{"line":`...)
+		buf = strconv.AppendInt(buf, int64(parseErr.Line), 10)
+		buf = append(buf, `,"column":`...)
+		buf = strconv.AppendInt(buf, int64(parseErr.Column), 10)
+		buf = append(buf, `,"start_line":`...)
+		buf = strconv.AppendInt(buf, int64(parseErr.StartLine), 10)
+		buf = append(buf, `,"error":`...)
+		buf = convert.AppendJSONString(buf, parseErr.Err.Error())
+		_, err = w.Write(append(buf, '}'))
+		return err
+	}
+
+	if _, err := cr.Header(); err != nil {
+		if err := report(err); err != nil {
+			return err
+		}
+	}
+	for {
+		_, err := cr.ReadRecord()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if err := report(err); err != nil {
+				return err
+			}
+			continue
+		}
+		records++
+	}
+
+	buf := []byte(`{"errors":[`)
+	if numErrors > 0 {
+		buf = []byte("\n")
+	}
+	buf = append(buf, `],"records":`...)
+	buf = strconv.AppendInt(buf, int64(records), 10)
+	buf = append(buf, `,"valid":`...)
+	buf = strconv.AppendBool(buf, numErrors == 0)
+	_, err := w.Write(append(buf, "}\n"...))
+	return err
+}
+
+// profile describes every column once the whole upload is read, see profile.Column
+func (s *Server) profile(w *responseWriter, r *http.Request, cr *csv.CsvReader) error {
+	result, err := profile.Read(cr)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(result)
+}
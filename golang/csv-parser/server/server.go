@@ -0,0 +1,305 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jeremyseow/csv-parser/convert"
+	"github.com/jeremyseow/csv-parser/csv"
+)
+
+const errorGracePeriod = 5 * time.Second
+
+var (
+	errBadRequest = errors.New("bad request")
+	errTooLarge   = errors.New("upload too large")
+)
+
+// Server parses uploaded csv over HTTP. Every endpoint takes a POST with the csv as the raw
+// body or as the file of a multipart form, gzipped or not, and streams its response as the
+// records are parsed:
+//
+//	/records   the records as JSON, NDJSON or any other format of the convert package
+//	/validate  every parse error with its position, then the number of valid records
+//	/profile   the type, counts and range of every column
+//
+// The CsvReader is configured from the query string: delimiter, quote, header, encoding and
+// lenient, with the defaults of the command line tool.
+type Server struct {
+	maxSize int64
+	timeout time.Duration
+	mux     *http.ServeMux
+}
+
+type Option func(*Server)
+
+// WithMaxSize limits an upload, before and after decompression, to 32MB by default
+func WithMaxSize(maxSize int64) Option {
+	return func(s *Server) {
+		s.maxSize = maxSize
+	}
+}
+
+// WithTimeout limits the time spent on a request, reading and parsing the upload and writing
+// the response, to a minute by default
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.timeout = timeout
+	}
+}
+
+func NewServer(options ...Option) *Server {
+	s := &Server{
+		maxSize: 32 << 20,
+		timeout: time.Minute,
+		mux:     http.NewServeMux(),
+	}
+	for _, op := range options {
+		op(s)
+	}
+
+	s.mux.HandleFunc("/records", s.handle(s.records))
+	s.mux.HandleFunc("/validate", s.handle(s.validate))
+	s.mux.HandleFunc("/profile", s.handle(s.profile))
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+type handlerFunc func(w *responseWriter, r *http.Request, cr *csv.CsvReader) error
+
+// handle sets up the limits and the CsvReader for fn and turns what it returns into a response
+func (s *Server) handle(fn handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%w: use POST", errBadRequest))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+
+		// deadlines on the connection stop a client that is slow to send or to receive,
+		// which the context alone cannot interrupt. Writing gets a little longer, so that a
+		// timeout can still be reported.
+		deadline, _ := ctx.Deadline()
+		rc := http.NewResponseController(w)
+		rc.SetReadDeadline(deadline)
+		rc.SetWriteDeadline(deadline.Add(errorGracePeriod))
+		// streaming means writing the response while the upload is still being read, which
+		// HTTP/1.1 servers do not allow by default
+		rc.EnableFullDuplex()
+
+		options, err := readerOptions(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		input, err := s.openInput(w, r)
+		if err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+
+		// errors after the response has started can only be reported in a trailer
+		w.Header().Set("Trailer", "X-Error")
+		rw := &responseWriter{ResponseWriter: w, controller: rc}
+		err = fn(rw, r, csv.NewCsvReader(input, options...))
+		if err == nil {
+			err = rw.finish()
+		}
+		if err == nil {
+			return
+		}
+
+		if !rw.wrote {
+			w.Header().Del("Trailer")
+			writeError(w, statusOf(err), err)
+			return
+		}
+		w.Header().Set("X-Error", err.Error())
+		if rw.onError != nil {
+			rw.onError(err)
+		}
+	}
+}
+
+// records converts the upload to the format query parameter, json by default. With infer=true
+// numbers, booleans and empty fields become JSON numbers, booleans and null.
+func (s *Server) records(w *responseWriter, r *http.Request, cr *csv.CsvReader) error {
+	query := r.URL.Query()
+	format := convert.FormatJSON
+	if name := query.Get("format"); name != "" {
+		var err error
+		if format, err = convert.ParseFormat(name); err != nil {
+			return fmt.Errorf("%w: %v", errBadRequest, err)
+		}
+	}
+	inferTypes, err := boolParam(query.Get("infer"), false)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", contentType(format))
+	if format == convert.FormatNDJSON {
+		// an NDJSON client reads the error as one more line
+		w.onError = func(err error) {
+			w.send(append(appendErrorObject(nil, err), '\n'))
+		}
+	}
+	return convert.Convert(cr, w, format, convert.WithInferTypes(inferTypes))
+}
+
+func contentType(format convert.Format) string {
+	switch format {
+	case convert.FormatJSON:
+		return "application/json"
+	case convert.FormatNDJSON:
+		return "application/x-ndjson"
+	case convert.FormatCSV:
+		return "text/csv; charset=utf-8"
+	case convert.FormatTSV:
+		return "text/tab-separated-values; charset=utf-8"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+func readerOptions(r *http.Request) ([]csv.ReaderOption, error) {
+	query := r.URL.Query()
+
+	delimiter, err := byteParam("delimiter", query.Get("delimiter"), ',')
+	if err != nil {
+		return nil, err
+	}
+	quote, err := byteParam("quote", query.Get("quote"), '"')
+	if err != nil {
+		return nil, err
+	}
+	hasHeader, err := boolParam(query.Get("header"), true)
+	if err != nil {
+		return nil, err
+	}
+	lenient, err := boolParam(query.Get("lenient"), false)
+	if err != nil {
+		return nil, err
+	}
+	encoding, err := csv.ParseEncoding(query.Get("encoding"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errBadRequest, err)
+	}
+
+	return []csv.ReaderOption{
+		csv.WithDelimiter(delimiter),
+		csv.WithEscapeChar(quote),
+		csv.WithHeader(hasHeader),
+		csv.WithEncoding(encoding),
+		csv.WithLenient(lenient),
+		csv.WithSepLineDetection(true),
+	}, nil
+}
+
+func byteParam(name, value string, defaultValue byte) (byte, error) {
+	switch value {
+	case "":
+		return defaultValue, nil
+	case `\t`, "tab":
+		return '\t', nil
+	}
+
+	if len(value) != 1 {
+		return 0, fmt.Errorf("%w: %s must be a single byte, got %q", errBadRequest, name, value)
+	}
+	return value[0], nil
+}
+
+func boolParam(value string, defaultValue bool) (bool, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", errBadRequest, err)
+	}
+	return b, nil
+}
+
+func statusOf(err error) int {
+	var parseErr *csv.ParseError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, errTooLarge), errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return http.StatusRequestTimeout
+	case errors.As(err, &parseErr):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, errBadRequest), errors.Is(err, csv.ErrUnknownColumn):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(appendErrorObject(nil, err), '\n'))
+}
+
+func appendErrorObject(buf []byte, err error) []byte {
+	buf = append(buf, `{"error":`...)
+	buf = convert.AppendJSONString(buf, err.Error())
+	return append(buf, '}')
+}
+
+// responseWriter sends whole lines, flushing each write to the client so that the response
+// streams. An error can then follow as a line of its own, instead of ending a line cut short.
+type responseWriter struct {
+	http.ResponseWriter
+	controller *http.ResponseController
+	pending    []byte
+	wrote      bool
+	// onError reports an error once the response has started, besides the trailer
+	onError func(err error)
+}
+
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	rw.pending = append(rw.pending, p...)
+	end := bytes.LastIndexByte(rw.pending, '\n')
+	if end < 0 {
+		return len(p), nil
+	}
+
+	if err := rw.send(rw.pending[:end+1]); err != nil {
+		return 0, err
+	}
+	rw.pending = append(rw.pending[:0], rw.pending[end+1:]...)
+	return len(p), nil
+}
+
+// finish sends what is left of a response that does not end with a newline
+func (rw *responseWriter) finish() error {
+	if len(rw.pending) == 0 {
+		return nil
+	}
+	return rw.send(rw.pending)
+}
+
+func (rw *responseWriter) send(p []byte) error {
+	rw.wrote = true
+	if _, err := rw.ResponseWriter.Write(p); err != nil {
+		return err
+	}
+	return rw.controller.Flush()
+}
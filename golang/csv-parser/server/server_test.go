@@ -0,0 +1,264 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func gzipped(s string) string {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	gw.Write([]byte(s))
+	gw.Close()
+	return buf.String()
+}
+
+func multipartForm(name, content string) (string, string) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.WriteField("note", "ignored")
+	part, _ := mw.CreateFormFile("upload", name)
+	part.Write([]byte(content))
+	mw.Close()
+	return buf.String(), mw.FormDataContentType()
+}
+
+func TestServer(t *testing.T) {
+	form, formType := multipartForm("data.csv", "id;name\n1;x\n")
+	gzippedForm, gzippedFormType := multipartForm("data.csv.gz", gzipped("id,name\n1,x\n"))
+
+	testCases := []struct {
+		name        string
+		method      string
+		path        string
+		body        string
+		contentType string
+		options     []Option
+		status      int
+		expected    string
+	}{
+		{
+			name:     "records as json",
+			path:     "/records",
+			body:     "id,name\n1,x\n2,\"y,z\"\n",
+			status:   http.StatusOK,
+			expected: "[\n{\"id\":\"1\",\"name\":\"x\"},\n{\"id\":\"2\",\"name\":\"y,z\"}\n]\n",
+		},
+		{
+			name:     "gzipped records as ndjson with types",
+			path:     "/records?format=ndjson&infer=true",
+			body:     gzipped("id,ok,note\n1,true,\n"),
+			status:   http.StatusOK,
+			expected: "{\"id\":1,\"ok\":true,\"note\":null}\n",
+		},
+		{
+			name:        "multipart",
+			path:        "/records?format=csv&delimiter=%3B",
+			body:        form,
+			contentType: formType,
+			status:      http.StatusOK,
+			expected:    "id,name\n1,x\n",
+		},
+		{
+			name:        "gzipped multipart",
+			path:        "/records?format=ndjson",
+			body:        gzippedForm,
+			contentType: gzippedFormType,
+			status:      http.StatusOK,
+			expected:    "{\"id\":\"1\",\"name\":\"x\"}\n",
+		},
+		{
+			name:     "validate",
+			path:     "/validate",
+			body:     "a,b\n1,2\n3\n\"4\"x,5\n6,7\n",
+			status:   http.StatusOK,
+			expected: "{\"errors\":[\n{\"line\":3,\"column\":1,\"start_line\":3,\"error\":\"wrong number of fields\"},\n{\"line\":4,\"column\":4,\"start_line\":4,\"error\":\"mismatched escape char\"}\n],\"records\":2,\"valid\":false}\n",
+		},
+		{
+			name:     "validate ok",
+			path:     "/validate?header=false",
+			body:     "1,2\n",
+			status:   http.StatusOK,
+			expected: "{\"errors\":[],\"records\":1,\"valid\":true}\n",
+		},
+		{
+			name:     "profile",
+			path:     "/profile",
+			body:     "id,name\n2,x\n10,\n",
+			status:   http.StatusOK,
+			expected: `{"records":2,"columns":[{"name":"id","type":"integer","count":2,"empty":0,"distinct":2,"min":"2","max":"10","min_length":1,"max_length":2},{"name":"name","type":"string","count":1,"empty":1,"distinct":1,"min":"x","max":"x","min_length":1,"max_length":1}]}` + "\n",
+		},
+		{
+			name:     "parse error",
+			path:     "/records",
+			body:     "a,b\n1\n",
+			status:   http.StatusUnprocessableEntity,
+			expected: "{\"error\":\"wrong number of fields at line: 2, column: 1\"}\n",
+		},
+		{
+			name:     "bad parameter",
+			path:     "/records?delimiter=ab",
+			body:     "a\n",
+			status:   http.StatusBadRequest,
+			expected: "{\"error\":\"bad request: delimiter must be a single byte, got \\\"ab\\\"\"}\n",
+		},
+		{
+			name:     "unknown format",
+			path:     "/records?format=xml",
+			body:     "a\n",
+			status:   http.StatusBadRequest,
+			expected: "{\"error\":\"bad request: unsupported format: xml\"}\n",
+		},
+		{
+			name:     "too large",
+			path:     "/records",
+			body:     strings.Repeat("a\n", 100),
+			options:  []Option{WithMaxSize(64)},
+			status:   http.StatusRequestEntityTooLarge,
+			expected: "{\"error\":\"bad request: http: request body too large\"}\n",
+		},
+		{
+			name:     "too large once decompressed",
+			path:     "/records",
+			body:     gzipped(strings.Repeat("a\n", 1000)),
+			options:  []Option{WithMaxSize(256)},
+			status:   http.StatusRequestEntityTooLarge,
+			expected: "{\"error\":\"upload too large\"}\n",
+		},
+		{
+			name:     "exactly the limit",
+			path:     "/validate?header=false",
+			body:     strings.Repeat("a\n", 32),
+			options:  []Option{WithMaxSize(64)},
+			status:   http.StatusOK,
+			expected: "{\"errors\":[],\"records\":32,\"valid\":true}\n",
+		},
+		{
+			name:     "exactly the limit once decompressed",
+			path:     "/validate?header=false",
+			body:     gzipped(strings.Repeat("a\n", 128)),
+			options:  []Option{WithMaxSize(256)},
+			status:   http.StatusOK,
+			expected: "{\"errors\":[],\"records\":128,\"valid\":true}\n",
+		},
+		{
+			name:     "get",
+			method:   http.MethodGet,
+			path:     "/records",
+			status:   http.StatusMethodNotAllowed,
+			expected: "{\"error\":\"bad request: use POST\"}\n",
+		},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(NewServer(currTestCase.options...))
+			defer server.Close()
+
+			method := currTestCase.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req, err := http.NewRequest(method, server.URL+currTestCase.path, strings.NewReader(currTestCase.body))
+			assert.NoError(t, err)
+			if currTestCase.contentType != "" {
+				req.Header.Set("Content-Type", currTestCase.contentType)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, currTestCase.status, resp.StatusCode)
+			assert.Equal(t, currTestCase.expected, string(body))
+		})
+	}
+}
+
+func TestInputReaderLimit(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		limit    int64
+		expected string
+		err      error
+	}{
+		{name: "under the limit", input: "abc", limit: 4, expected: "abc"},
+		{name: "exactly the limit", input: "abcd", limit: 4, expected: "abcd"},
+		{name: "over the limit", input: "abcde", limit: 4, expected: "abcd", err: errTooLarge},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			ir := &inputReader{ctx: context.Background(), r: strings.NewReader(currTestCase.input), remaining: currTestCase.limit, limited: true}
+			read, err := io.ReadAll(ir)
+			assert.Equal(t, currTestCase.expected, string(read))
+			if currTestCase.err != nil {
+				assert.True(t, errors.Is(err, currTestCase.err), err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestServerErrorAfterStreaming(t *testing.T) {
+	server := httptest.NewServer(NewServer())
+	defer server.Close()
+
+	body := "n\n" + strings.Repeat("1234567890\n", 2000) + "\"x\"y\n"
+	resp, err := http.Post(server.URL+"/records?format=ndjson", "text/csv", strings.NewReader(body))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	output, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// the records sent before the error are whole lines, the error is the last one
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	assert.Greater(t, len(lines), 1)
+	for _, line := range lines[:len(lines)-1] {
+		assert.Equal(t, "{\"n\":\"1234567890\"}", line)
+	}
+	assert.Equal(t, "{\"error\":\"mismatched escape char at line: 2002, column: 4\"}", lines[len(lines)-1])
+	assert.Equal(t, "mismatched escape char at line: 2002, column: 4", resp.Trailer.Get("X-Error"))
+}
+
+func TestServerTimeout(t *testing.T) {
+	server := httptest.NewServer(NewServer(WithTimeout(50 * time.Millisecond)))
+	defer server.Close()
+
+	// the client stalls after the first record
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go pw.Write([]byte("a\n1\n"))
+
+	resp, err := http.Post(server.URL+"/profile", "text/csv", pr)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusRequestTimeout, resp.StatusCode)
+	assert.Contains(t, string(body), "timeout")
+}
@@ -0,0 +1,335 @@
+package split
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jeremyseow/csv-parser/csv"
+)
+
+var (
+	errNoLimit  = errors.New("split needs a row limit, a byte limit or a column")
+	errNoHeader = errors.New("splitting by column needs a header")
+)
+
+type splitter struct {
+	maxRows       int
+	maxBytes      int64
+	column        string
+	maxOpenFiles  int
+	writerOptions []csv.WriterOption
+
+	dir    string
+	prefix string
+	header []byte
+	// scratch encodes each record once, so its size is known before it is written
+	scratch       bytes.Buffer
+	scratchWriter *csv.CsvWriter
+
+	groups map[string]*group
+	names  map[string]bool
+	open   []*part
+	paths  []string
+}
+
+type Option func(*splitter)
+
+// WithMaxRows starts a new file once a file holds n records
+func WithMaxRows(n int) Option {
+	return func(s *splitter) {
+		s.maxRows = n
+	}
+}
+
+// WithMaxBytes starts a new file before a record would take a file, header included, past n
+// bytes. A record bigger than n on its own still gets a file.
+func WithMaxBytes(n int64) Option {
+	return func(s *splitter) {
+		s.maxBytes = n
+	}
+}
+
+// WithColumn writes the records of each value of the column to their own files
+func WithColumn(name string) Option {
+	return func(s *splitter) {
+		s.column = name
+	}
+}
+
+// WithMaxOpenFiles sets how many files are kept open at once when splitting by column,
+// the least recently used is closed and reopened later if needed. It defaults to 64.
+func WithMaxOpenFiles(n int) Option {
+	return func(s *splitter) {
+		s.maxOpenFiles = n
+	}
+}
+
+// WithWriterOptions configures how the files are written
+func WithWriterOptions(writerOptions ...csv.WriterOption) Option {
+	return func(s *splitter) {
+		s.writerOptions = writerOptions
+	}
+}
+
+// group is the files of a column value, or of the whole input when not splitting by column
+type group struct {
+	name    string
+	partNum int
+	current *part
+}
+
+type part struct {
+	path   string
+	file   *os.File
+	writer *bufio.Writer
+	rows   int
+	bytes  int64
+}
+
+// Split writes the records of r to files in dir named prefix-0001.csv, prefix-0002.csv and
+// so on, or prefix-<value>.csv when splitting by column, with prefix-<value>-0001.csv when
+// limits are set too. Every file starts with the header. Records are only ever split between
+// records, so quoted fields spanning several lines stay whole. Split returns the paths of the
+// files in the order they were created.
+func Split(r csv.RecordReader, dir, prefix string, options ...Option) ([]string, error) {
+	s := &splitter{
+		maxOpenFiles: 64,
+		dir:          dir,
+		prefix:       prefix,
+		groups:       map[string]*group{},
+		names:        map[string]bool{},
+	}
+	for _, op := range options {
+		op(s)
+	}
+	if s.maxRows <= 0 && s.maxBytes <= 0 && s.column == "" {
+		return nil, errNoLimit
+	}
+	s.scratchWriter = csv.NewCsvWriter(&s.scratch, s.writerOptions...)
+
+	err := s.split(r)
+	if closeErr := s.closeAll(); err == nil {
+		err = closeErr
+	}
+	return s.paths, err
+}
+
+func (s *splitter) split(r csv.RecordReader) error {
+	header, err := r.Header()
+	if err != nil {
+		return err
+	}
+
+	column := -1
+	if s.column != "" {
+		if header == nil {
+			return errNoHeader
+		}
+		positions, err := csv.ColumnPositions(header, []string{s.column})
+		if err != nil {
+			return err
+		}
+		column = positions[0]
+	}
+
+	if header != nil {
+		if s.header, err = s.encode(header); err != nil {
+			return err
+		}
+		s.header = append([]byte{}, s.header...)
+	}
+
+	for {
+		record, err := r.ReadRecord()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		value := ""
+		if column >= 0 && column < len(record) {
+			value = record[column]
+		}
+
+		encoded, err := s.encode(record)
+		if err != nil {
+			return err
+		}
+		if err := s.write(value, encoded); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *splitter) encode(record []string) ([]byte, error) {
+	s.scratch.Reset()
+	if err := s.scratchWriter.Write(record); err != nil {
+		return nil, err
+	}
+	if err := s.scratchWriter.Flush(); err != nil {
+		return nil, err
+	}
+	return s.scratch.Bytes(), nil
+}
+
+func (s *splitter) write(value string, encoded []byte) error {
+	g, ok := s.groups[value]
+	if !ok {
+		g = &group{name: s.groupName(value)}
+		s.groups[value] = g
+	}
+
+	p := g.current
+	full := p != nil && p.rows > 0 &&
+		((s.maxRows > 0 && p.rows >= s.maxRows) ||
+			(s.maxBytes > 0 && p.bytes+int64(len(encoded)) > s.maxBytes))
+	if p == nil || full {
+		// a full part may have been closed already to make room for other groups
+		if p != nil && p.file != nil {
+			if err := s.close(p); err != nil {
+				return err
+			}
+		}
+
+		g.partNum++
+		var err error
+		if p, err = s.create(g); err != nil {
+			return err
+		}
+		g.current = p
+	} else if p.file == nil {
+		if err := s.reopen(p); err != nil {
+			return err
+		}
+	} else {
+		s.touch(p)
+	}
+
+	if _, err := p.writer.Write(encoded); err != nil {
+		return err
+	}
+	p.rows++
+	p.bytes += int64(len(encoded))
+	return nil
+}
+
+// groupName turns a column value into part of a file name, keeping names apart when
+// different values clean up to the same name
+func (s *splitter) groupName(value string) string {
+	if s.column == "" {
+		return s.prefix
+	}
+
+	clean := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, value)
+	if clean == "" || strings.Trim(clean, ".") == "" {
+		clean = "empty"
+	}
+
+	name := s.prefix + "-" + clean
+	for i := 2; s.names[name]; i++ {
+		name = fmt.Sprintf("%s-%s~%d", s.prefix, clean, i)
+	}
+	s.names[name] = true
+	return name
+}
+
+func (s *splitter) create(g *group) (*part, error) {
+	name := g.name
+	if s.column == "" || s.maxRows > 0 || s.maxBytes > 0 {
+		name = fmt.Sprintf("%s-%04d", name, g.partNum)
+	}
+
+	p := &part{path: filepath.Join(s.dir, name+".csv")}
+	file, err := os.Create(p.path)
+	if err != nil {
+		return nil, err
+	}
+	s.paths = append(s.paths, p.path)
+	s.opened(p, file)
+
+	if s.header != nil {
+		if _, err := p.writer.Write(s.header); err != nil {
+			return nil, err
+		}
+		p.bytes += int64(len(s.header))
+	}
+	return p, s.limitOpen()
+}
+
+func (s *splitter) reopen(p *part) error {
+	file, err := os.OpenFile(p.path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	s.opened(p, file)
+	return s.limitOpen()
+}
+
+func (s *splitter) opened(p *part, file *os.File) {
+	p.file = file
+	p.writer = bufio.NewWriter(file)
+	s.open = append(s.open, p)
+}
+
+// touch moves p to the back of the open files, which are kept least recently used first
+func (s *splitter) touch(p *part) {
+	if s.open[len(s.open)-1] == p {
+		return
+	}
+	for i, o := range s.open {
+		if o == p {
+			copy(s.open[i:], s.open[i+1:])
+			s.open[len(s.open)-1] = p
+			return
+		}
+	}
+}
+
+func (s *splitter) limitOpen() error {
+	for len(s.open) > max(s.maxOpenFiles, 1) {
+		if err := s.close(s.open[0]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *splitter) close(p *part) error {
+	for i, o := range s.open {
+		if o == p {
+			s.open = append(s.open[:i], s.open[i+1:]...)
+			break
+		}
+	}
+
+	err := p.writer.Flush()
+	if closeErr := p.file.Close(); err == nil {
+		err = closeErr
+	}
+	p.file, p.writer = nil, nil
+	return err
+}
+
+func (s *splitter) closeAll() error {
+	var err error
+	for len(s.open) > 0 {
+		if closeErr := s.close(s.open[0]); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
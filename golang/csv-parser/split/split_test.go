@@ -0,0 +1,118 @@
+package split
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jeremyseow/csv-parser/csv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplit(t *testing.T) {
+	input := "id,country,note\n1,SG,a\n2,MY,\"multi\nline\"\n3,SG,c\n4,,d\n5,SG/x,e\n6,SG_x,f\n"
+
+	testCases := []struct {
+		name     string
+		noHeader bool
+		options  []Option
+		expected map[string]string
+		err      error
+	}{
+		{
+			name:    "rows",
+			options: []Option{WithMaxRows(2)},
+			expected: map[string]string{
+				"part-0001.csv": "id,country,note\n1,SG,a\n2,MY,\"multi\nline\"\n",
+				"part-0002.csv": "id,country,note\n3,SG,c\n4,,d\n",
+				"part-0003.csv": "id,country,note\n5,SG/x,e\n6,SG_x,f\n",
+			},
+		},
+		{
+			name:    "bytes never split a record",
+			options: []Option{WithMaxBytes(40)},
+			expected: map[string]string{
+				"part-0001.csv": "id,country,note\n1,SG,a\n",
+				"part-0002.csv": "id,country,note\n2,MY,\"multi\nline\"\n",
+				"part-0003.csv": "id,country,note\n3,SG,c\n4,,d\n5,SG/x,e\n",
+				"part-0004.csv": "id,country,note\n6,SG_x,f\n",
+			},
+		},
+		{
+			name:    "column",
+			options: []Option{WithColumn("country"), WithMaxOpenFiles(2)},
+			expected: map[string]string{
+				"part-SG.csv":     "id,country,note\n1,SG,a\n3,SG,c\n",
+				"part-MY.csv":     "id,country,note\n2,MY,\"multi\nline\"\n",
+				"part-empty.csv":  "id,country,note\n4,,d\n",
+				"part-SG_x.csv":   "id,country,note\n5,SG/x,e\n",
+				"part-SG_x~2.csv": "id,country,note\n6,SG_x,f\n",
+			},
+		},
+		{
+			name:    "column and rows",
+			options: []Option{WithColumn("country"), WithMaxRows(1)},
+			expected: map[string]string{
+				"part-SG-0001.csv":     "id,country,note\n1,SG,a\n",
+				"part-SG-0002.csv":     "id,country,note\n3,SG,c\n",
+				"part-MY-0001.csv":     "id,country,note\n2,MY,\"multi\nline\"\n",
+				"part-empty-0001.csv":  "id,country,note\n4,,d\n",
+				"part-SG_x-0001.csv":   "id,country,note\n5,SG/x,e\n",
+				"part-SG_x~2-0001.csv": "id,country,note\n6,SG_x,f\n",
+			},
+		},
+		{
+			name:    "column and rows with fewer open files than groups",
+			options: []Option{WithColumn("country"), WithMaxRows(1), WithMaxOpenFiles(1)},
+			expected: map[string]string{
+				"part-SG-0001.csv":     "id,country,note\n1,SG,a\n",
+				"part-SG-0002.csv":     "id,country,note\n3,SG,c\n",
+				"part-MY-0001.csv":     "id,country,note\n2,MY,\"multi\nline\"\n",
+				"part-empty-0001.csv":  "id,country,note\n4,,d\n",
+				"part-SG_x-0001.csv":   "id,country,note\n5,SG/x,e\n",
+				"part-SG_x~2-0001.csv": "id,country,note\n6,SG_x,f\n",
+			},
+		},
+		{
+			name:     "without header",
+			noHeader: true,
+			options:  []Option{WithMaxRows(4)},
+			expected: map[string]string{
+				"part-0001.csv": "id,country,note\n1,SG,a\n2,MY,\"multi\nline\"\n3,SG,c\n",
+				"part-0002.csv": "4,,d\n5,SG/x,e\n6,SG_x,f\n",
+			},
+		},
+		{name: "no limit", err: errNoLimit},
+		{name: "unknown column", options: []Option{WithColumn("nope")}, err: csv.ErrUnknownColumn},
+	}
+
+	for _, testCase := range testCases {
+		currTestCase := testCase
+		t.Run(currTestCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			reader := csv.NewCsvReader(strings.NewReader(input), csv.WithHeader(!currTestCase.noHeader))
+			paths, err := Split(reader, dir, "part", currTestCase.options...)
+			if currTestCase.err != nil {
+				assert.True(t, errors.Is(err, currTestCase.err), err)
+				return
+			}
+			assert.NoError(t, err)
+
+			files := map[string]string{}
+			for _, path := range paths {
+				content, err := os.ReadFile(path)
+				assert.NoError(t, err)
+				files[filepath.Base(path)] = string(content)
+			}
+			assert.Equal(t, currTestCase.expected, files)
+
+			entries, err := os.ReadDir(dir)
+			assert.NoError(t, err)
+			assert.Len(t, entries, len(paths))
+		})
+	}
+}